@@ -36,4 +36,13 @@ func InitConfiguration() {
 
 	// sensible defaults for local development/tests
 	viper.SetDefault("DATABASE.DRIVER", "mysql")
+	viper.SetDefault("SCHEDULER.INTERVAL", "1h")
+	viper.SetDefault("STORAGE.DIR", "uploads")
+	viper.SetDefault("STORAGE.QUOTA_MB", 100)
+	viper.SetDefault("TRASH.RETENTION_DAYS", 30)
+
+	TrashRetentionDays = viper.GetInt("TRASH.RETENTION_DAYS")
 }
+
+// TrashRetentionDays lama catatan disimpan di sampah sebelum dihapus permanen, 0 berarti tidak pernah
+var TrashRetentionDays = 30
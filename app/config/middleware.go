@@ -1,9 +1,18 @@
 package config
 
 import (
+	"context"
+	"encoding/json"
+	"log"
 	"net/http"
+	"strings"
 )
 
+type contextKey string
+
+// key user id di context request, diisi oleh APIAuthOnly
+const userIdContextKey contextKey = "user_id"
+
 func GuestOnly(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		session, _ := Store.Get(r, SESSION_ID)
@@ -25,3 +34,72 @@ func AuthOnly(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(w, r)
 	}
 }
+
+// APITokenAuthenticator mencari pemilik token API, diimplementasikan oleh models.APITokenModel
+type APITokenAuthenticator interface {
+	AuthenticateAPIToken(token string) (userId string, writable bool, err error)
+}
+
+// APIAuthOnly seperti AuthOnly tetapi membalas 401 dalam bentuk JSON. Selain session
+// cookie, request boleh memakai header "Authorization: Bearer <token>". Token read
+// hanya boleh dipakai untuk GET dan HEAD. User id yang login disimpan di context request.
+func APIAuthOnly(tokens APITokenAuthenticator) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+
+			// request dengan header Authorization tidak memakai session
+			if header := r.Header.Get("Authorization"); header != "" {
+				scheme, token, _ := strings.Cut(header, " ")
+				if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_request"`)
+					writeAPIError(w, http.StatusUnauthorized, "unauthorized", "Header Authorization harus berformat Bearer <token>")
+					return
+				}
+
+				userId, writable, err := tokens.AuthenticateAPIToken(strings.TrimSpace(token))
+				if err != nil {
+					// penyebabnya hanya dicatat di server, bisa berisi error database
+					log.Println("Token API ditolak,", err)
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					writeAPIError(w, http.StatusUnauthorized, "invalid_token", "Token tidak valid")
+					return
+				}
+				if !writable && r.Method != http.MethodGet && r.Method != http.MethodHead {
+					w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="write"`)
+					writeAPIError(w, http.StatusForbidden, "insufficient_scope", "Token hanya punya akses read")
+					return
+				}
+
+				next.ServeHTTP(w, r.WithContext(WithUserId(r.Context(), userId)))
+				return
+			}
+
+			session, _ := Store.Get(r, SESSION_ID)
+			userId, _ := session.Values["ID"].(string)
+			if session.Values["LOGGED_IN"] != true || userId == "" {
+				writeAPIError(w, http.StatusUnauthorized, "unauthorized", "Silahkan login terlebih dahulu")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithUserId(r.Context(), userId)))
+		}
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{"code": code, "message": message},
+	})
+}
+
+// WithUserId menyimpan user id ke context
+func WithUserId(ctx context.Context, userId string) context.Context {
+	return context.WithValue(ctx, userIdContextKey, userId)
+}
+
+// UserIdFromContext mengambil user id yang disimpan APIAuthOnly
+func UserIdFromContext(ctx context.Context) string {
+	userId, _ := ctx.Value(userIdContextKey).(string)
+	return userId
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"financial-record/storage"
+	"log"
+
+	"github.com/spf13/viper"
+)
+
+// FileStorage tempat lampiran catatan keuangan disimpan, default folder lokal "uploads"
+var FileStorage storage.Storage = storage.NewLocalStorage("uploads")
+
+// AttachmentQuota total ukuran lampiran per user dalam byte, 0 berarti tanpa batas
+var AttachmentQuota int64 = 100 * 1024 * 1024
+
+// InitStorage memilih storage dari STORAGE.DRIVER: "local" (default, folder STORAGE.DIR)
+// atau "s3" untuk S3 dan storage yang kompatibel seperti MinIO. Kuota lampiran per user
+// diatur dengan STORAGE.QUOTA_MB.
+func InitStorage() {
+
+	AttachmentQuota = viper.GetInt64("STORAGE.QUOTA_MB") * 1024 * 1024
+
+	switch driver := viper.GetString("STORAGE.DRIVER"); driver {
+	case "", "local":
+		FileStorage = storage.NewLocalStorage(viper.GetString("STORAGE.DIR"))
+		log.Println("Lampiran disimpan di folder", viper.GetString("STORAGE.DIR"))
+	case "s3":
+		FileStorage = storage.NewS3Storage(storage.S3Config{
+			Endpoint:  viper.GetString("STORAGE.S3.ENDPOINT"),
+			Bucket:    viper.GetString("STORAGE.S3.BUCKET"),
+			Region:    viper.GetString("STORAGE.S3.REGION"),
+			AccessKey: viper.GetString("STORAGE.S3.ACCESS_KEY"),
+			SecretKey: viper.GetString("STORAGE.S3.SECRET_KEY"),
+		})
+		log.Println("Lampiran disimpan di bucket S3", viper.GetString("STORAGE.S3.BUCKET"))
+	default:
+		log.Fatal("STORAGE.DRIVER tidak dikenal: ", driver)
+	}
+}
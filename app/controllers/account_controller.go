@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"financial-record/config"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/views"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type AccountController struct {
+	db *sql.DB
+}
+
+func NewAccountController(db *sql.DB) *AccountController {
+	return &AccountController{
+		db: db,
+	}
+}
+
+var accountKinds = []string{"tunai", "bank", "ewallet"}
+
+// render template akun yang memakai format mata uang
+func renderAccountTemplate(writer http.ResponseWriter, templateLayout string, data map[string]interface{}) {
+
+	data["kinds"] = accountKinds
+
+	funcMap := template.FuncMap{
+		"formatMoney": views.FormatMoney,
+		"moneyInput":  views.MoneyInput,
+	}
+
+	template, _ := template.New(filepath.Base(templateLayout)).Funcs(funcMap).ParseFiles(templateLayout)
+	template.Execute(writer, data)
+}
+
+// ambil data akun dari form tambah/edit, false jika saldo awal tidak bisa dibaca
+func parseAccountForm(request *http.Request, userId string) (entities.Account, bool) {
+
+	request.ParseForm()
+
+	openingBalance, validBalance := parseMoneyField(request, "opening_balance")
+
+	return entities.Account{
+		UserId:         userId,
+		Name:           strings.TrimSpace(request.Form.Get("name")),
+		Kind:           request.Form.Get("kind"),
+		OpeningBalance: openingBalance,
+	}, validBalance
+}
+
+func (controller *AccountController) Index(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/account/index.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	// tampilkan alert dari session
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		data["success"] = flashes[0]
+	}
+	if flashes := session.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+	}
+	session.Save(request, writer)
+
+	// tampilkan list akun beserta saldo
+	sessionUserId := session.Values["ID"].(string)
+	accounts, err := models.NewAccountModel(controller.db).FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	}
+
+	var total int64
+	for _, account := range accounts {
+		total += account.Balance
+	}
+
+	data["accounts"] = accounts
+	data["total"] = total
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
+	renderAccountTemplate(writer, templateLayout, data)
+}
+
+func (controller *AccountController) AddAccount(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/account/create.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// untuk mencegah <no value> di awal
+	data["account"] = entities.Account{Kind: "bank"}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	if request.Method == http.MethodPost {
+
+		account, validBalance := parseAccountForm(request, sessionUserId)
+
+		// saldo awal harus bisa dibaca sebagai angka
+		if !validBalance {
+			data["validation"] = map[string]interface{}{"OpeningBalance": "Saldo awal harus berupa angka, contoh 1.500.000,50"}
+			data["account"] = account
+			renderAccountTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error sesuai ketentuan di Struct
+		if err := helpers.NewValidator(controller.db).Struct(account); err != nil {
+			data["validation"] = err
+			data["account"] = account
+			renderAccountTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// insert ke database
+		if err := models.NewAccountModel(controller.db).AddAccount(account); err != nil {
+			data["error"] = "Gagal menambahkan akun, " + err.Error()
+			data["account"] = account
+			renderAccountTemplate(writer, templateLayout, data)
+			return
+		}
+
+		session.AddFlash("Berhasil menambahkan akun", "success")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/accounts", http.StatusSeeOther)
+		return
+	}
+
+	renderAccountTemplate(writer, templateLayout, data)
+}
+
+func (controller *AccountController) EditAccount(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/account/edit.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// ambil id dari url
+	idStr := request.URL.Query().Get("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil akun", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/accounts", http.StatusSeeOther)
+		return
+	}
+
+	// tampilkan akun berdasarkan id
+	model := models.NewAccountModel(controller.db)
+	oldAccount, err := model.FindAccountById(id, sessionUserId)
+	if err != nil {
+		session.AddFlash("Akun tidak ditemukan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/accounts", http.StatusSeeOther)
+		return
+	}
+	data["account"] = oldAccount
+
+	if request.Method == http.MethodPost {
+
+		account, validBalance := parseAccountForm(request, sessionUserId)
+		account.Id = oldAccount.Id
+
+		// saldo awal harus bisa dibaca sebagai angka
+		if !validBalance {
+			data["validation"] = map[string]interface{}{"OpeningBalance": "Saldo awal harus berupa angka, contoh 1.500.000,50"}
+			data["account"] = account
+			renderAccountTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error sesuai ketentuan di Struct
+		if err := helpers.NewValidator(controller.db).Struct(account); err != nil {
+			data["validation"] = err
+			data["account"] = account
+			renderAccountTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// update ke database
+		if err := model.EditAccount(account); err != nil {
+			data["error"] = "Gagal mengubah akun, " + err.Error()
+			data["account"] = account
+		} else {
+			session.AddFlash("Berhasil mengubah akun", "success")
+			session.Save(request, writer)
+			http.Redirect(writer, request, "/accounts", http.StatusSeeOther)
+			return
+		}
+	}
+
+	renderAccountTemplate(writer, templateLayout, data)
+}
+
+func (controller *AccountController) DeleteAccount(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	idStr := request.URL.Query().Get("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil akun", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/accounts", http.StatusSeeOther)
+		return
+	}
+
+	model := models.NewAccountModel(controller.db)
+	if _, err := model.FindAccountById(id, sessionUserId); err != nil {
+		session.AddFlash("Akun tidak ditemukan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/accounts", http.StatusSeeOther)
+		return
+	}
+
+	if err := model.DeleteAccount(id, sessionUserId); errors.Is(err, models.ErrAccountInUse) {
+		session.AddFlash("Akun tidak bisa dihapus karena masih digunakan oleh catatan keuangan", "error")
+	} else if err != nil {
+		session.AddFlash("Gagal menghapus akun, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Berhasil menghapus akun", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/accounts", http.StatusSeeOther)
+}
@@ -0,0 +1,521 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"financial-record/config"
+	"financial-record/entities"
+	"financial-record/models"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type APIController struct {
+	db *sql.DB
+}
+
+func NewAPIController(db *sql.DB) *APIController {
+	return &APIController{
+		db: db,
+	}
+}
+
+// bentuk catatan keuangan di response API, nominal ditulis sebagai desimal ("1500000.50")
+type apiRecord struct {
+	Id          int64   `json:"id"`
+	Date        string  `json:"date"`
+	Type        string  `json:"type"`
+	Category    string  `json:"category"`
+	AccountId   int64   `json:"account_id"`
+	AccountName string  `json:"account_name"`
+	TransferId  *int64  `json:"transfer_id"`
+	Nominal     string  `json:"nominal"`
+	Currency    string  `json:"currency"`
+	BaseNominal *string `json:"base_nominal"`
+	Description *string `json:"description"`
+}
+
+// body request tambah/ubah catatan keuangan
+type apiRecordInput struct {
+	Date        string    `json:"date"`
+	Type        string    `json:"type"`
+	Category    string    `json:"category"`
+	AccountId   int64     `json:"account_id"`
+	Nominal     apiAmount `json:"nominal"`
+	Currency    string    `json:"currency"`
+	Description *string   `json:"description"`
+}
+
+type apiCategory struct {
+	Id    int64  `json:"id"`
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Icon  string `json:"icon"`
+}
+
+// apiAmount menerima nominal berupa angka JSON (1500000.5) atau string ("1.500.000,50")
+type apiAmount struct {
+	value  string
+	quoted bool
+}
+
+func (amount *apiAmount) UnmarshalJSON(raw []byte) error {
+
+	if bytes.HasPrefix(raw, []byte(`"`)) {
+		amount.quoted = true
+		return json.Unmarshal(raw, &amount.value)
+	}
+	if string(raw) == "null" {
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err != nil {
+		return err
+	}
+	amount.value = number.String()
+	return nil
+}
+
+// nominal dalam sen, nominal kosong dianggap 0 supaya pesan validasi required yang tampil
+func (amount apiAmount) minorUnits() (int64, bool) {
+
+	if strings.TrimSpace(amount.value) == "" {
+		return 0, true
+	}
+
+	parse := entities.ParseDecimalMoney
+	if amount.quoted {
+		parse = entities.ParseMoney
+	}
+	money, err := parse(amount.value, "")
+	return money.Amount, err == nil
+}
+
+func toAPIRecord(financial entities.Financial) apiRecord {
+
+	record := apiRecord{
+		Id:          financial.Id,
+		Date:        financial.Date.Format("2006-01-02"),
+		Type:        financial.Type,
+		Category:    financial.Category,
+		AccountId:   financial.AccountId,
+		AccountName: financial.AccountName,
+		TransferId:  financial.TransferId,
+		Nominal:     entities.Money{Amount: financial.Nominal}.Decimal(),
+		Currency:    financial.Currency,
+		Description: financial.Description,
+	}
+	if financial.BaseNominal != nil {
+		baseNominal := entities.Money{Amount: *financial.BaseNominal}.Decimal()
+		record.BaseNominal = &baseNominal
+	}
+
+	return record
+}
+
+// tulis response JSON dengan status tertentu
+func writeJSON(writer http.ResponseWriter, status int, body interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(body)
+}
+
+// tulis error JSON, fields berisi pesan validasi per field
+func writeAPIError(writer http.ResponseWriter, status int, code string, message string, fields interface{}) {
+
+	body := map[string]interface{}{
+		"code":    code,
+		"message": message,
+	}
+	if validation, ok := fields.(map[string]interface{}); ok && len(validation) > 0 {
+		jsonFields := make(map[string]interface{})
+		for field, fieldMessage := range validation {
+			jsonFields[jsonFieldName(field)] = fieldMessage
+		}
+		body["fields"] = jsonFields
+	}
+
+	writeJSON(writer, status, map[string]interface{}{"error": body})
+}
+
+// ubah nama field struct ke nama field JSON, contoh AccountId -> account_id
+func jsonFieldName(field string) string {
+
+	var result strings.Builder
+	for i, char := range field {
+		if unicode.IsUpper(char) {
+			if i > 0 {
+				result.WriteByte('_')
+			}
+			char = unicode.ToLower(char)
+		}
+		result.WriteRune(char)
+	}
+	return result.String()
+}
+
+// ambil id catatan dari path /api/v1/records/{id}
+func recordIdFromPath(request *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(request.PathValue("id"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// baca body JSON, hanya menerima Content-Type application/json
+func decodeJSONBody(writer http.ResponseWriter, request *http.Request, target interface{}) bool {
+
+	if mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type")); mediaType != "application/json" {
+		writeAPIError(writer, http.StatusUnsupportedMediaType, "unsupported_media_type", "Body harus berupa JSON (Content-Type: application/json)", nil)
+		return false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(writer, request.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		writeAPIError(writer, http.StatusBadRequest, "invalid_json", "Body JSON tidak valid, "+err.Error(), nil)
+		return false
+	}
+
+	return true
+}
+
+// ubah body request menjadi catatan keuangan, error dikembalikan per field
+func (input apiRecordInput) toFinancial(userId string) (entities.AddFinancial, map[string]interface{}) {
+
+	financial := entities.AddFinancial{
+		UserId:      userId,
+		Type:        input.Type,
+		Category:    input.Category,
+		AccountId:   input.AccountId,
+		Currency:    strings.ToUpper(input.Currency),
+		Description: input.Description,
+	}
+
+	fields := make(map[string]interface{})
+	if input.Date != "" {
+		date, err := time.Parse("2006-01-02", input.Date)
+		if err != nil {
+			fields["Date"] = "Tanggal harus berformat YYYY-MM-DD"
+		}
+		financial.Date = date
+	}
+
+	nominal, validNominal := input.Nominal.minorUnits()
+	if !validNominal {
+		fields["Nominal"] = invalidMoneyMessage
+	}
+	financial.Nominal = nominal
+
+	return financial, fields
+}
+
+// ambil filter periode, tipe dan akun dari query string, sama seperti halaman utama
+func apiFinancialFilter(request *http.Request, userId string) entities.FinancialFilter {
+
+	query := request.URL.Query()
+	startDate, endDate := parseDateRangeFilter(request, make(map[string]interface{}))
+	accountId, _ := strconv.ParseInt(query.Get("account_id"), 10, 64)
+
+	return entities.FinancialFilter{
+		UserId:          userId,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		PemasukanOnly:   query.Get("type") == "pemasukan",
+		PengeluaranOnly: query.Get("type") == "pengeluaran",
+		AccountId:       accountId,
+	}
+}
+
+// GET /api/v1/records
+func (controller *APIController) ListRecords(writer http.ResponseWriter, request *http.Request) {
+
+	userId := config.UserIdFromContext(request.Context())
+	filter := apiFinancialFilter(request, userId)
+	page := parseFinancialPage(request, make(map[string]interface{}))
+
+	financials, hasMore, err := models.NewFinancalModel(controller.db).FindFinancialPage(filter, page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		writeAPIError(writer, http.StatusBadRequest, "invalid_request", "Gagal menampilkan data keuangan, "+err.Error(), nil)
+		return
+	} else if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mengambil data keuangan", nil)
+		return
+	}
+
+	records := []apiRecord{}
+	for _, financial := range financials {
+		records = append(records, toAPIRecord(financial))
+	}
+
+	// posisi halaman sebelum dan sesudah, sama seperti halaman utama
+	meta := map[string]interface{}{
+		"start_date":  filter.StartDate.Format("2006-01-02"),
+		"end_date":    filter.EndDate.Format("2006-01-02"),
+		"sort":        page.Sort,
+		"dir":         "asc",
+		"per_page":    page.Limit,
+		"next_cursor": nil,
+		"prev_cursor": nil,
+	}
+	if page.Desc {
+		meta["dir"] = "desc"
+	}
+	hasNext, hasPrev := hasMore, page.Cursor != ""
+	if page.Backward {
+		hasNext, hasPrev = true, hasMore
+	}
+	if len(financials) > 0 {
+		if hasNext {
+			meta["next_cursor"] = models.EncodeFinancialCursor(page.Sort, financials[len(financials)-1])
+		}
+		if hasPrev {
+			meta["prev_cursor"] = models.EncodeFinancialCursor(page.Sort, financials[0])
+		}
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]interface{}{"data": records, "meta": meta})
+}
+
+// GET /api/v1/records/{id}
+func (controller *APIController) GetRecord(writer http.ResponseWriter, request *http.Request) {
+
+	userId := config.UserIdFromContext(request.Context())
+	id, ok := recordIdFromPath(request)
+	if !ok {
+		writeAPIError(writer, http.StatusNotFound, "not_found", "Data keuangan tidak ditemukan", nil)
+		return
+	}
+
+	financial, err := models.NewFinancalModel(controller.db).FindFinancialById(id, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeAPIError(writer, http.StatusNotFound, "not_found", "Data keuangan tidak ditemukan", nil)
+		return
+	} else if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mengambil data keuangan", nil)
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]interface{}{"data": toAPIRecord(*financial)})
+}
+
+// POST /api/v1/records
+func (controller *APIController) CreateRecord(writer http.ResponseWriter, request *http.Request) {
+
+	userId := config.UserIdFromContext(request.Context())
+
+	var input apiRecordInput
+	if !decodeJSONBody(writer, request, &input) {
+		return
+	}
+
+	// mata uang default mengikuti mata uang utama user
+	if input.Currency == "" {
+		input.Currency = userBaseCurrency(controller.db, userId)
+	}
+
+	financial, fields := input.toFinancial(userId)
+	if len(fields) > 0 {
+		writeAPIError(writer, http.StatusUnprocessableEntity, "validation_failed", "Data tidak valid", fields)
+		return
+	}
+	if err := validateFinancial(controller.db, financial); err != nil {
+		writeAPIError(writer, http.StatusUnprocessableEntity, "validation_failed", "Data tidak valid", err)
+		return
+	}
+
+	model := models.NewFinancalModel(controller.db)
+	id, err := model.AddFinacialRecord(financial)
+	if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal menambahkan data keuangan", nil)
+		return
+	}
+
+	created, err := model.FindFinancialById(id, userId)
+	if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mengambil data keuangan", nil)
+		return
+	}
+
+	writer.Header().Set("Location", "/api/v1/records/"+strconv.FormatInt(id, 10))
+	writeJSON(writer, http.StatusCreated, map[string]interface{}{"data": toAPIRecord(*created)})
+}
+
+// PUT /api/v1/records/{id}
+func (controller *APIController) UpdateRecord(writer http.ResponseWriter, request *http.Request) {
+
+	userId := config.UserIdFromContext(request.Context())
+	id, ok := recordIdFromPath(request)
+	if !ok {
+		writeAPIError(writer, http.StatusNotFound, "not_found", "Data keuangan tidak ditemukan", nil)
+		return
+	}
+
+	model := models.NewFinancalModel(controller.db)
+	oldFinancial, err := model.FindFinancialById(id, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeAPIError(writer, http.StatusNotFound, "not_found", "Data keuangan tidak ditemukan", nil)
+		return
+	} else if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mengambil data keuangan", nil)
+		return
+	}
+
+	// catatan transfer diubah lewat transfer supaya kedua sisinya tetap sama
+	if oldFinancial.TransferId != nil {
+		writeAPIError(writer, http.StatusConflict, "transfer_record", "Catatan transfer tidak bisa diubah lewat endpoint ini", nil)
+		return
+	}
+
+	var input apiRecordInput
+	if !decodeJSONBody(writer, request, &input) {
+		return
+	}
+	if input.Currency == "" {
+		input.Currency = oldFinancial.Currency
+	}
+
+	financial, fields := input.toFinancial(userId)
+	if len(fields) > 0 {
+		writeAPIError(writer, http.StatusUnprocessableEntity, "validation_failed", "Data tidak valid", fields)
+		return
+	}
+	financial.Id = oldFinancial.Id
+	if err := validateFinancial(controller.db, financial); err != nil {
+		writeAPIError(writer, http.StatusUnprocessableEntity, "validation_failed", "Data tidak valid", err)
+		return
+	}
+
+	if err := model.EditFinancialRecord(financial); err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mengubah data keuangan", nil)
+		return
+	}
+
+	updated, err := model.FindFinancialById(id, userId)
+	if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mengambil data keuangan", nil)
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]interface{}{"data": toAPIRecord(*updated)})
+}
+
+// DELETE /api/v1/records/{id}
+func (controller *APIController) DeleteRecord(writer http.ResponseWriter, request *http.Request) {
+
+	userId := config.UserIdFromContext(request.Context())
+	id, ok := recordIdFromPath(request)
+	if !ok {
+		writeAPIError(writer, http.StatusNotFound, "not_found", "Data keuangan tidak ditemukan", nil)
+		return
+	}
+
+	model := models.NewFinancalModel(controller.db)
+	financial, err := model.FindFinancialById(id, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeAPIError(writer, http.StatusNotFound, "not_found", "Data keuangan tidak ditemukan", nil)
+		return
+	} else if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mengambil data keuangan", nil)
+		return
+	}
+
+	// catatan dipindahkan ke sampah, catatan transfer bersama pasangannya
+	if financial.TransferId != nil {
+		err = models.NewTransferModel(controller.db).DeleteTransfer(*financial.TransferId, userId)
+	} else {
+		err = model.DeleteFinancialRecord(id, userId)
+	}
+	if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal menghapus data keuangan", nil)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/v1/totals, default bulan ini atau bulan dari parameter month (2006-01)
+func (controller *APIController) Totals(writer http.ResponseWriter, request *http.Request) {
+
+	userId := config.UserIdFromContext(request.Context())
+	filter := apiFinancialFilter(request, userId)
+
+	if month := request.URL.Query().Get("month"); month != "" {
+		monthStart, err := time.ParseInLocation("2006-01", month, time.Local)
+		if err != nil {
+			writeAPIError(writer, http.StatusBadRequest, "invalid_request", "Parameter month harus berformat YYYY-MM", nil)
+			return
+		}
+		filter.StartDate, filter.EndDate = monthStart, monthStart.AddDate(0, 1, -1)
+	}
+
+	model := models.NewFinancalModel(controller.db)
+	totalPemasukan, totalPengeluaran, err := model.GetFinancialTotalNominal(filter)
+	if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mendapatkan total data keuangan", nil)
+		return
+	}
+	missingRates, err := model.CountMissingExchangeRates(filter)
+	if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal mendapatkan total data keuangan", nil)
+		return
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+		"start_date":    filter.StartDate.Format("2006-01-02"),
+		"end_date":      filter.EndDate.Format("2006-01-02"),
+		"currency":      userBaseCurrency(controller.db, userId),
+		"pemasukan":     entities.Money{Amount: totalPemasukan}.Decimal(),
+		"pengeluaran":   entities.Money{Amount: totalPengeluaran}.Decimal(),
+		"saldo":         entities.Money{Amount: totalPemasukan - totalPengeluaran}.Decimal(),
+		"missing_rates": missingRates,
+	}})
+}
+
+// GET /api/v1/categories, filter tipe optional lewat parameter type
+func (controller *APIController) Categories(writer http.ResponseWriter, request *http.Request) {
+
+	userId := config.UserIdFromContext(request.Context())
+
+	categoryType := request.URL.Query().Get("type")
+	if categoryType != "" && categoryType != "pemasukan" && categoryType != "pengeluaran" {
+		writeAPIError(writer, http.StatusBadRequest, "invalid_request", "Parameter type harus pemasukan atau pengeluaran", nil)
+		return
+	}
+
+	categories, err := models.NewCategoryModel(controller.db).FindAllCategory(userId, categoryType)
+	if err != nil {
+		writeAPIError(writer, http.StatusInternalServerError, "internal_error", "Gagal menampilkan kategori", nil)
+		return
+	}
+
+	result := []apiCategory{}
+	for _, category := range categories {
+		result = append(result, apiCategory{
+			Id:    category.Id,
+			Type:  category.Type,
+			Name:  category.Name,
+			Color: category.Color,
+			Icon:  category.Icon,
+		})
+	}
+
+	writeJSON(writer, http.StatusOK, map[string]interface{}{"data": result})
+}
+
+// GET /api/openapi.json, kontrak semua route aplikasi dalam format OpenAPI 3
+func (controller *APIController) OpenAPI(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	http.ServeFile(writer, request, "docs/openapi.json")
+}
+
+// endpoint API yang tidak terdaftar
+func (controller *APIController) NotFound(writer http.ResponseWriter, request *http.Request) {
+	writeAPIError(writer, http.StatusNotFound, "not_found", "Endpoint tidak ditemukan", nil)
+}
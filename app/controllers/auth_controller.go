@@ -8,6 +8,7 @@ import (
 	"financial-record/models"
 	"financial-record/views"
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -67,6 +68,16 @@ func (controller *AuthController) Register(writer http.ResponseWriter, request *
 			data["error"] = "Registrasi gagal, error: " + err.Error()
 		} else {
 			data["success"] = "Registrasi berhasil, silahkan login"
+
+			// buat kategori bawaan untuk user baru
+			if err := models.NewCategoryModel(controller.db).AddDefaultCategories(register.Id); err != nil {
+				log.Println("Gagal membuat kategori bawaan,", err)
+			}
+
+			// buat akun tunai bawaan untuk user baru
+			if err := models.NewAccountModel(controller.db).AddDefaultAccount(register.Id); err != nil {
+				log.Println("Gagal membuat akun bawaan,", err)
+			}
 		}
 
 		// kirim pesan success dengan session ke halaman login
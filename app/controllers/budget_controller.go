@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"financial-record/config"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/views"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+type BudgetController struct {
+	db *sql.DB
+}
+
+func NewBudgetController(db *sql.DB) *BudgetController {
+	return &BudgetController{
+		db: db,
+	}
+}
+
+// daftar bulan untuk anggaran, 6 bulan ke belakang dan 2 bulan ke depan
+func budgetMonths(currentDate time.Time) []string {
+	var months []string
+	for i := 2; i > -6; i-- {
+		months = append(months, currentDate.AddDate(0, i, 0).Format("January 2006"))
+	}
+	return months
+}
+
+func renderBudgetTemplate(writer http.ResponseWriter, templateLayout string, data map[string]interface{}) {
+
+	funcMap := template.FuncMap{
+		"formatMoney": views.FormatMoney,
+		"moneyInput":  views.MoneyInput,
+	}
+
+	template, _ := template.New(filepath.Base(templateLayout)).Funcs(funcMap).ParseFiles(templateLayout)
+	template.Execute(writer, data)
+}
+
+func (controller *BudgetController) Index(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/budget/index.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	// tampilkan alert dari session
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		data["success"] = flashes[0]
+	}
+	if flashes := session.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+	}
+	session.Save(request, writer)
+
+	// tampilkan dropdown bulan
+	currentDate := time.Now()
+	data["months"] = budgetMonths(currentDate)
+
+	selectedMonth := request.URL.Query().Get("selected_month")
+	if selectedMonth == "" {
+		selectedMonth = currentDate.Format("January 2006")
+	}
+	data["selectedMonth"] = selectedMonth
+
+	// tampilkan anggaran beserta pemakaiannya
+	sessionUserId := session.Values["ID"].(string)
+	budgets, err := models.NewBudgetModel(controller.db).FindBudgetUsage(sessionUserId, selectedMonth)
+	if errors.Is(err, models.ErrInvalidBudgetMonth) {
+		data["validation"] = map[string]interface{}{"Month": "Bulan tidak valid, pilih bulan dari daftar"}
+	} else if err != nil {
+		data["error"] = "Gagal menampilkan anggaran, " + err.Error()
+	} else {
+		data["budgets"] = budgets
+	}
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
+	renderBudgetTemplate(writer, templateLayout, data)
+}
+
+func (controller *BudgetController) AddBudget(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/budget/create.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// bulan anggaran diambil dari url
+	selectedMonth := request.URL.Query().Get("selected_month")
+	monthDate, err := time.Parse("January 2006", selectedMonth)
+	if err != nil {
+		selectedMonth = time.Now().Format("January 2006")
+		monthDate, _ = time.Parse("January 2006", selectedMonth)
+	}
+	data["selectedMonth"] = selectedMonth
+
+	// untuk mencegah <no value> di awal
+	data["budget"] = entities.Budget{}
+
+	// anggaran hanya untuk kategori pengeluaran
+	categoryModel := models.NewCategoryModel(controller.db)
+	categories, err := categoryModel.FindAllCategory(sessionUserId, "pengeluaran")
+	if err != nil {
+		data["error"] = "Gagal menampilkan kategori, " + err.Error()
+	}
+	data["categories"] = categories
+
+	if request.Method == http.MethodPost {
+
+		request.ParseForm()
+
+		categoryId, _ := strconv.ParseInt(request.Form.Get("category_id"), 10, 64)
+		amount, validAmount := parseMoneyField(request, "amount")
+
+		budget := entities.Budget{
+			UserId:     sessionUserId,
+			CategoryId: categoryId,
+			Year:       monthDate.Year(),
+			Month:      int(monthDate.Month()),
+			Amount:     amount,
+		}
+
+		// batas anggaran harus bisa dibaca sebagai angka
+		if !validAmount {
+			data["validation"] = map[string]interface{}{"Amount": "Batas anggaran harus berupa angka, contoh 1.500.000,50"}
+			data["budget"] = budget
+			renderBudgetTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error sesuai ketentuan di Struct
+		if err := helpers.NewValidator(controller.db).Struct(budget); err != nil {
+			data["validation"] = err
+			data["budget"] = budget
+			renderBudgetTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// kategori harus milik user dan bertipe pengeluaran
+		category, err := categoryModel.FindCategoryById(budget.CategoryId, sessionUserId)
+		if err != nil || category.Type != "pengeluaran" {
+			data["validation"] = map[string]interface{}{"CategoryId": "Kategori tidak ditemukan"}
+			data["budget"] = budget
+			renderBudgetTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// satu kategori hanya boleh punya satu anggaran per bulan
+		model := models.NewBudgetModel(controller.db)
+		if exists, err := model.IsBudgetExists(sessionUserId, budget.CategoryId, budget.Year, budget.Month); err != nil || exists {
+			data["validation"] = map[string]interface{}{"CategoryId": "Anggaran kategori ini sudah ada di bulan " + selectedMonth}
+			data["budget"] = budget
+			renderBudgetTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// insert ke database
+		if err := model.AddBudget(budget); err != nil {
+			data["error"] = "Gagal menambahkan anggaran, " + err.Error()
+			data["budget"] = budget
+			renderBudgetTemplate(writer, templateLayout, data)
+			return
+		}
+
+		session.AddFlash("Berhasil menambahkan anggaran", "success")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/budgets?selected_month="+url.QueryEscape(selectedMonth), http.StatusSeeOther)
+		return
+	}
+
+	renderBudgetTemplate(writer, templateLayout, data)
+}
+
+func (controller *BudgetController) EditBudget(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/budget/edit.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// ambil id dari url
+	idStr := request.URL.Query().Get("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil anggaran", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/budgets", http.StatusSeeOther)
+		return
+	}
+
+	// tampilkan anggaran berdasarkan id
+	model := models.NewBudgetModel(controller.db)
+	budget, err := model.FindBudgetById(id, sessionUserId)
+	if err != nil {
+		session.AddFlash("Anggaran tidak ditemukan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/budgets", http.StatusSeeOther)
+		return
+	}
+	data["budget"] = budget
+
+	category, err := models.NewCategoryModel(controller.db).FindCategoryById(budget.CategoryId, sessionUserId)
+	if err == nil {
+		data["category"] = category
+	}
+
+	selectedMonth := time.Date(budget.Year, time.Month(budget.Month), 1, 0, 0, 0, 0, time.Local).Format("January 2006")
+	data["selectedMonth"] = selectedMonth
+
+	if request.Method == http.MethodPost {
+
+		request.ParseForm()
+
+		amount, validAmount := parseMoneyField(request, "amount")
+		budget.Amount = amount
+
+		// batas anggaran harus bisa dibaca sebagai angka
+		if !validAmount {
+			data["validation"] = map[string]interface{}{"Amount": "Batas anggaran harus berupa angka, contoh 1.500.000,50"}
+			renderBudgetTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error sesuai ketentuan di Struct
+		if err := helpers.NewValidator(controller.db).Struct(*budget); err != nil {
+			data["validation"] = err
+			renderBudgetTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// update data di database
+		if err := model.EditBudget(*budget); err != nil {
+			data["error"] = "Gagal mengubah anggaran, " + err.Error()
+		} else {
+			session.AddFlash("Berhasil mengubah anggaran", "success")
+			session.Save(request, writer)
+			http.Redirect(writer, request, "/budgets?selected_month="+url.QueryEscape(selectedMonth), http.StatusSeeOther)
+			return
+		}
+	}
+
+	renderBudgetTemplate(writer, templateLayout, data)
+}
+
+func (controller *BudgetController) DeleteBudget(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	idStr := request.URL.Query().Get("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil anggaran", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/budgets", http.StatusSeeOther)
+		return
+	}
+
+	if err := models.NewBudgetModel(controller.db).DeleteBudget(id, sessionUserId); err != nil {
+		session.AddFlash("Gagal menghapus anggaran, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Berhasil menghapus anggaran", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/budgets?selected_month="+url.QueryEscape(request.URL.Query().Get("selected_month")), http.StatusSeeOther)
+}
+
+// CopyBudget menyalin anggaran bulan sebelumnya ke bulan yang dipilih
+func (controller *BudgetController) CopyBudget(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/budgets", http.StatusSeeOther)
+		return
+	}
+
+	request.ParseForm()
+	selectedMonth := request.Form.Get("selected_month")
+	to, err := time.Parse("January 2006", selectedMonth)
+	if err != nil {
+		session.AddFlash("Bulan tidak valid", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/budgets", http.StatusSeeOther)
+		return
+	}
+	from := to.AddDate(0, -1, 0)
+
+	copied, err := models.NewBudgetModel(controller.db).CopyBudgets(sessionUserId, from, to)
+	if err != nil {
+		session.AddFlash("Gagal menyalin anggaran, "+err.Error(), "error")
+	} else {
+		session.AddFlash(fmt.Sprintf("Berhasil menyalin %d anggaran dari bulan %s", copied, from.Format("January 2006")), "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/budgets?selected_month="+url.QueryEscape(selectedMonth), http.StatusSeeOther)
+}
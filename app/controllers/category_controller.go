@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"database/sql"
+	"financial-record/config"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/views"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CategoryController struct {
+	db *sql.DB
+}
+
+func NewCategoryController(db *sql.DB) *CategoryController {
+	return &CategoryController{
+		db: db,
+	}
+}
+
+func (controller *CategoryController) Index(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/category/index.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	// tampilkan alert dari session
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		data["success"] = flashes[0]
+	}
+	if flashes := session.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+	}
+	session.Save(request, writer)
+
+	// tampilkan list kategori
+	sessionUserId := session.Values["ID"].(string)
+	model := models.NewCategoryModel(controller.db)
+
+	pemasukan, err := model.FindAllCategory(sessionUserId, "pemasukan")
+	if err != nil {
+		data["error"] = "Gagal menampilkan kategori, " + err.Error()
+	}
+	pengeluaran, err := model.FindAllCategory(sessionUserId, "pengeluaran")
+	if err != nil {
+		data["error"] = "Gagal menampilkan kategori, " + err.Error()
+	}
+
+	data["pemasukan"] = pemasukan
+	data["pengeluaran"] = pengeluaran
+
+	views.RenderTemplate(writer, templateLayout, data)
+}
+
+func (controller *CategoryController) AddCategory(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/category/create.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// untuk mencegah <no value> di awal
+	data["category"] = entities.Category{Type: request.URL.Query().Get("type"), Color: "#6c757d"}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	if request.Method == http.MethodPost {
+
+		request.ParseForm()
+
+		// ambil user id
+		sessionUserId, _ := session.Values["ID"].(string)
+
+		// masukkan ke struct
+		category := entities.Category{
+			UserId: sessionUserId,
+			Type:   request.Form.Get("type"),
+			Name:   strings.TrimSpace(request.Form.Get("name")),
+			Color:  request.Form.Get("color"),
+			Icon:   strings.TrimSpace(request.Form.Get("icon")),
+		}
+
+		// tampilkan error sesuai ketentuan di Struct
+		model := models.NewCategoryModel(controller.db)
+		if err := helpers.NewValidator(controller.db).Struct(category); err != nil {
+			data["validation"] = err
+			data["category"] = category
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// nama kategori tidak boleh sama dalam satu tipe
+		if taken, err := model.IsCategoryNameTaken(sessionUserId, category.Type, category.Name, 0); err != nil || taken {
+			data["validation"] = map[string]interface{}{"Name": "Nama sudah digunakan"}
+			data["category"] = category
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// insert ke database
+		if err := model.AddCategory(category); err != nil {
+			data["error"] = "Gagal menambahkan kategori, " + err.Error()
+			data["category"] = category
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		session.AddFlash("Berhasil menambahkan kategori", "success")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+		return
+	}
+
+	views.RenderTemplate(writer, templateLayout, data)
+}
+
+func (controller *CategoryController) EditCategory(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/category/edit.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// ambil id dari url
+	idStr := request.URL.Query().Get("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil kategori", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+		return
+	}
+
+	// tampilkan kategori berdasarkan id
+	model := models.NewCategoryModel(controller.db)
+	oldCategory, err := model.FindCategoryById(id, sessionUserId)
+	if err != nil {
+		session.AddFlash("Kategori tidak ditemukan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+		return
+	}
+	data["category"] = oldCategory
+
+	// kategori lain dengan tipe yang sama untuk tujuan penggabungan
+	categories, _ := model.FindAllCategory(sessionUserId, oldCategory.Type)
+	var mergeTargets []entities.Category
+	for _, category := range categories {
+		if category.Id != oldCategory.Id {
+			mergeTargets = append(mergeTargets, category)
+		}
+	}
+	data["mergeTargets"] = mergeTargets
+
+	if request.Method == http.MethodPost {
+
+		request.ParseForm()
+
+		// tipe kategori tidak bisa diubah supaya catatan keuangan tetap konsisten
+		category := entities.Category{
+			Id:     oldCategory.Id,
+			UserId: sessionUserId,
+			Type:   oldCategory.Type,
+			Name:   strings.TrimSpace(request.Form.Get("name")),
+			Color:  request.Form.Get("color"),
+			Icon:   strings.TrimSpace(request.Form.Get("icon")),
+		}
+
+		// tampilkan error sesuai ketentuan di Struct
+		if err := helpers.NewValidator(controller.db).Struct(category); err != nil {
+			data["validation"] = err
+			data["category"] = category
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// nama kategori tidak boleh sama dalam satu tipe
+		if taken, err := model.IsCategoryNameTaken(sessionUserId, category.Type, category.Name, category.Id); err != nil || taken {
+			data["validation"] = map[string]interface{}{"Name": "Nama sudah digunakan, gunakan fitur gabungkan kategori"}
+			data["category"] = category
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// update kategori sekaligus catatan keuangan yang memakai nama lama
+		if err := model.EditCategory(*oldCategory, category); err != nil {
+			data["error"] = "Gagal mengubah kategori, " + err.Error()
+			data["category"] = category
+		} else {
+			session.AddFlash("Berhasil mengubah kategori", "success")
+			session.Save(request, writer)
+			http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+			return
+		}
+	}
+
+	views.RenderTemplate(writer, templateLayout, data)
+}
+
+func (controller *CategoryController) MergeCategory(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+		return
+	}
+
+	request.ParseForm()
+	sourceId, errSource := strconv.ParseInt(request.Form.Get("source_id"), 10, 64)
+	targetId, errTarget := strconv.ParseInt(request.Form.Get("target_id"), 10, 64)
+	if errSource != nil || errTarget != nil || sourceId == targetId {
+		session.AddFlash("Pilih kategori tujuan penggabungan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+		return
+	}
+
+	model := models.NewCategoryModel(controller.db)
+	source, errSource := model.FindCategoryById(sourceId, sessionUserId)
+	target, errTarget := model.FindCategoryById(targetId, sessionUserId)
+	if errSource != nil || errTarget != nil {
+		session.AddFlash("Kategori tidak ditemukan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+		return
+	}
+
+	if err := model.MergeCategory(*source, *target); err != nil {
+		session.AddFlash("Gagal menggabungkan kategori, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Berhasil menggabungkan kategori "+source.Name+" ke "+target.Name, "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+}
+
+func (controller *CategoryController) DeleteCategory(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	idStr := request.URL.Query().Get("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil kategori", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+		return
+	}
+
+	model := models.NewCategoryModel(controller.db)
+	category, err := model.FindCategoryById(id, sessionUserId)
+	if err != nil {
+		session.AddFlash("Kategori tidak ditemukan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+		return
+	}
+
+	if err := model.DeleteCategory(*category); err != nil {
+		session.AddFlash("Gagal menghapus kategori, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Berhasil menghapus kategori", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/categories", http.StatusSeeOther)
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"database/sql"
+	"financial-record/config"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/views"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExchangeRateController struct {
+	db *sql.DB
+}
+
+func NewExchangeRateController(db *sql.DB) *ExchangeRateController {
+	return &ExchangeRateController{
+		db: db,
+	}
+}
+
+// tampilkan halaman kurs beserta form tambah dan import
+func (controller *ExchangeRateController) renderIndex(writer http.ResponseWriter, userId string, data map[string]interface{}) {
+
+	templateLayout := "views/exchange_rate/index.html"
+
+	rates, err := models.NewExchangeRateModel(controller.db).FindAllExchangeRate(userId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan kurs, " + err.Error()
+	}
+	data["rates"] = rates
+	data["currencies"] = helpers.Currencies
+
+	baseCurrency := userBaseCurrency(controller.db, userId)
+	data["baseCurrency"] = baseCurrency
+
+	// untuk mencegah <no value> di awal
+	if _, ok := data["rate"]; !ok {
+		data["rate"] = entities.ExchangeRate{BaseCurrency: baseCurrency, Date: time.Now()}
+	}
+
+	views.RenderTemplate(writer, templateLayout, data)
+}
+
+func (controller *ExchangeRateController) Index(writer http.ResponseWriter, request *http.Request) {
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	// tampilkan alert dari session
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		data["success"] = flashes[0]
+	}
+	if flashes := session.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+	}
+	session.Save(request, writer)
+
+	sessionUserId := session.Values["ID"].(string)
+	controller.renderIndex(writer, sessionUserId, data)
+}
+
+func (controller *ExchangeRateController) AddExchangeRate(writer http.ResponseWriter, request *http.Request) {
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/exchange_rates", http.StatusSeeOther)
+		return
+	}
+
+	request.ParseForm()
+
+	date, _ := time.Parse("2006-01-02", request.Form.Get("date"))
+	rate, _ := strconv.ParseFloat(strings.Replace(request.Form.Get("rate"), ",", ".", 1), 64)
+
+	exchangeRate := entities.ExchangeRate{
+		UserId:       sessionUserId,
+		Currency:     strings.ToUpper(request.Form.Get("currency")),
+		BaseCurrency: strings.ToUpper(request.Form.Get("base_currency")),
+		Date:         date,
+		Rate:         rate,
+	}
+
+	// tampilkan error sesuai ketentuan di Struct
+	if err := helpers.NewValidator(controller.db).Struct(exchangeRate); err != nil {
+		data["validation"] = err
+		data["rate"] = exchangeRate
+		controller.renderIndex(writer, sessionUserId, data)
+		return
+	}
+
+	if exchangeRate.Currency == exchangeRate.BaseCurrency {
+		data["validation"] = map[string]interface{}{"Currency": "Mata uang harus berbeda dengan mata uang utama"}
+		data["rate"] = exchangeRate
+		controller.renderIndex(writer, sessionUserId, data)
+		return
+	}
+
+	// insert ke database, kurs di tanggal yang sama ditimpa
+	if err := models.NewExchangeRateModel(controller.db).AddExchangeRate(exchangeRate); err != nil {
+		data["error"] = "Gagal menyimpan kurs, " + err.Error()
+		data["rate"] = exchangeRate
+		controller.renderIndex(writer, sessionUserId, data)
+		return
+	}
+
+	session.AddFlash("Berhasil menyimpan kurs", "success")
+	session.Save(request, writer)
+	http.Redirect(writer, request, "/exchange_rates", http.StatusSeeOther)
+}
+
+func (controller *ExchangeRateController) ImportExchangeRates(writer http.ResponseWriter, request *http.Request) {
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/exchange_rates", http.StatusSeeOther)
+		return
+	}
+
+	request.ParseMultipartForm(2 * 1024 * 1024) // file maksimal 2MB
+
+	file, _, err := request.FormFile("file")
+	if err != nil {
+		data["importErrors"] = []string{"Pilih file CSV yang akan diimport"}
+		controller.renderIndex(writer, sessionUserId, data)
+		return
+	}
+	defer file.Close()
+
+	// baca CSV, semua baris harus valid supaya import tidak setengah jalan
+	rates, importErrors := helpers.ParseExchangeRateCSV(file, sessionUserId, userBaseCurrency(controller.db, sessionUserId))
+	validator := helpers.NewValidator(controller.db)
+	for i, rate := range rates {
+		if err := validator.Struct(rate); err != nil {
+			for _, message := range err.(map[string]interface{}) {
+				importErrors = append(importErrors, fmt.Sprintf("Data ke-%d (%s): %v", i+1, rate.Currency, message))
+			}
+		}
+	}
+
+	if len(importErrors) > 0 {
+		data["importErrors"] = importErrors
+		controller.renderIndex(writer, sessionUserId, data)
+		return
+	}
+
+	if err := models.NewExchangeRateModel(controller.db).ImportExchangeRates(rates); err != nil {
+		data["error"] = "Gagal mengimport kurs, " + err.Error()
+		controller.renderIndex(writer, sessionUserId, data)
+		return
+	}
+
+	session.AddFlash(fmt.Sprintf("Berhasil mengimport %d kurs", len(rates)), "success")
+	session.Save(request, writer)
+	http.Redirect(writer, request, "/exchange_rates", http.StatusSeeOther)
+}
+
+func (controller *ExchangeRateController) DeleteExchangeRate(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	idStr := request.URL.Query().Get("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil kurs", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/exchange_rates", http.StatusSeeOther)
+		return
+	}
+
+	if err := models.NewExchangeRateModel(controller.db).DeleteExchangeRate(id, sessionUserId); err != nil {
+		session.AddFlash("Gagal menghapus kurs, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Berhasil menghapus kurs", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/exchange_rates", http.StatusSeeOther)
+}
@@ -2,15 +2,18 @@ package controllers
 
 import (
 	"database/sql"
+	"errors"
 	"financial-record/config"
 	"financial-record/entities"
 	"financial-record/helpers"
 	"financial-record/models"
+	"financial-record/storage"
 	"financial-record/views"
 	"fmt"
-	"html/template"
+	"io"
+	"log"
+	"mime"
 	"net/http"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -26,18 +29,240 @@ func NewFinancialController(db *sql.DB) *FinancialController {
 	}
 }
 
-// format rupiah
-func formatIDR(n int64) string {
-	str := fmt.Sprintf("%d", n)
-	var result []string
-	for len(str) > 3 {
-		result = append([]string{str[len(str)-3:]}, result...)
-		str = str[:len(str)-3]
+// ambil mata uang utama user, total dan saldo ditampilkan dalam mata uang ini
+func userBaseCurrency(db *sql.DB, userId string) string {
+	currency, err := models.NewUserModel(db).GetBaseCurrency(userId)
+	if err != nil || currency == "" {
+		return "IDR"
 	}
-	if len(str) > 0 {
-		result = append([]string{str}, result...)
+	return currency
+}
+
+// pesan validasi untuk input nominal yang tidak bisa dibaca
+const invalidMoneyMessage = "Nominal harus berupa angka, contoh 1.500.000,50"
+
+// ambil nominal (dalam sen) dari form, input kosong dianggap 0 supaya pesan validasi required yang tampil
+func parseMoneyField(request *http.Request, field string) (int64, bool) {
+	value := strings.TrimSpace(request.Form.Get(field))
+	if value == "" {
+		return 0, true
+	}
+	money, err := entities.ParseMoney(value, "")
+	return money.Amount, err == nil
+}
+
+// ambil periode filter dari url (preset, start_date, end_date) dan kirim ke html
+func parseDateRangeFilter(request *http.Request, data map[string]interface{}) (time.Time, time.Time) {
+
+	query := request.URL.Query()
+	startDate, endDate, preset := helpers.ResolveDateRange(query.Get("preset"), query.Get("start_date"), query.Get("end_date"), time.Now())
+
+	data["presets"] = helpers.DateRangePresets
+	data["preset"] = preset
+	data["startDate"] = startDate.Format("2006-01-02")
+	data["endDate"] = endDate.Format("2006-01-02")
+	data["periodLabel"] = startDate.Format("02 Jan 2006") + " - " + endDate.Format("02 Jan 2006")
+
+	return startDate, endDate
+}
+
+// pilihan jumlah data per halaman di list keuangan
+var perPageOptions = []int{10, 25, 50, 100}
+
+// ambil urutan dan posisi halaman dari url (sort, dir, per_page, after, before) dan kirim ke html
+func parseFinancialPage(request *http.Request, data map[string]interface{}) entities.FinancialPage {
+
+	query := request.URL.Query()
+
+	page := entities.FinancialPage{Sort: "date", Limit: 25}
+	switch sort := query.Get("sort"); sort {
+	case "date", "nominal", "category", "created_at":
+		page.Sort = sort
+	}
+	page.Desc = query.Get("dir") == "desc"
+	if perPage, err := strconv.Atoi(query.Get("per_page")); err == nil {
+		for _, option := range perPageOptions {
+			if perPage == option {
+				page.Limit = perPage
+			}
+		}
+	}
+	if before := query.Get("before"); before != "" {
+		page.Cursor = before
+		page.Backward = true
+	} else {
+		page.Cursor = query.Get("after")
+	}
+
+	data["sort"] = page.Sort
+	data["dir"] = "asc"
+	if page.Desc {
+		data["dir"] = "desc"
+	}
+	data["perPage"] = page.Limit
+	data["perPageOptions"] = perPageOptions
+
+	return page
+}
+
+// validasi catatan keuangan, kategori dan akun harus milik user
+func validateFinancial(db *sql.DB, financial entities.AddFinancial) interface{} {
+
+	if err := helpers.NewValidator(db).Struct(financial); err != nil {
+		return err
+	}
+
+	// kategori harus milik user dan sesuai tipe
+	if valid, err := models.NewCategoryModel(db).IsUserCategory(financial.UserId, financial.Type, financial.Category); err != nil || !valid {
+		return map[string]interface{}{"Category": "Kategori tidak ditemukan"}
+	}
+
+	// akun harus milik user
+	if valid, err := models.NewAccountModel(db).IsUserAccount(financial.UserId, financial.AccountId); err != nil || !valid {
+		return map[string]interface{}{"AccountId": "Akun tidak ditemukan"}
+	}
+
+	return nil
+}
+
+// file lampiran yang diupload dari form, sudah dicek ukuran dan tipenya
+type attachmentUpload struct {
+	filename string
+	content  []byte
+}
+
+// total ukuran body form catatan keuangan, cukup untuk lampiran maksimal ditambah field lain
+const maxFinancialFormSize = helpers.MaxAttachmentFiles*helpers.MaxAttachmentSize + 1024*1024
+
+// baca form catatan keuangan, multipart jika ada lampiran. Pesan berisi error validasi
+// jika body lebih besar dari batas upload.
+func parseFinancialForm(writer http.ResponseWriter, request *http.Request) string {
+
+	request.Body = http.MaxBytesReader(writer, request.Body, maxFinancialFormSize)
+	err := request.ParseMultipartForm(helpers.MaxAttachmentSize)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return fmt.Sprintf("Gagal membaca form, maksimal %d lampiran masing-masing 5MB", helpers.MaxAttachmentFiles)
+	}
+	return ""
+}
+
+// baca file lampiran dari input "attachments" yang boleh berisi beberapa file.
+// Pesan berisi error validasi jika file terlalu banyak, terlalu besar atau bukan gambar/PDF.
+func readAttachmentFields(request *http.Request) (uploads []attachmentUpload, message string) {
+
+	if request.MultipartForm == nil {
+		return nil, ""
+	}
+	files := request.MultipartForm.File["attachments"]
+	if len(files) > helpers.MaxAttachmentFiles {
+		return nil, fmt.Sprintf("Maksimal %d lampiran sekali simpan", helpers.MaxAttachmentFiles)
+	}
+
+	for _, handler := range files {
+		if handler.Size > helpers.MaxAttachmentSize {
+			return nil, handler.Filename + " terlalu besar, maksimal 5MB"
+		}
+		file, err := handler.Open()
+		if err != nil {
+			return nil, "Gagal membaca lampiran " + handler.Filename
+		}
+		content, err := io.ReadAll(io.LimitReader(file, helpers.MaxAttachmentSize))
+		file.Close()
+		if err != nil {
+			return nil, "Gagal membaca lampiran " + handler.Filename
+		}
+
+		// tipe file dibaca dari isinya, ekstensi dan content type dari browser tidak dipakai
+		if _, ok := helpers.AttachmentContentType(content); !ok {
+			return nil, handler.Filename + ": " + helpers.ErrAttachmentType.Error()
+		}
+		uploads = append(uploads, attachmentUpload{filename: handler.Filename, content: content})
 	}
-	return strings.Join(result, ".") + ",00"
+	return uploads, ""
+}
+
+// cek kuota lampiran user sebelum file baru disimpan, freed adalah ukuran lampiran yang ikut dihapus
+func checkAttachmentQuota(db *sql.DB, userId string, uploads []attachmentUpload, freed int64) (string, error) {
+
+	if len(uploads) == 0 || config.AttachmentQuota <= 0 {
+		return "", nil
+	}
+	usage, err := models.NewAttachmentModel(db).GetStorageUsage(userId)
+	if err != nil {
+		return "", err
+	}
+
+	total := usage - freed
+	for _, upload := range uploads {
+		total += int64(len(upload.content))
+	}
+	if total > config.AttachmentQuota {
+		return fmt.Sprintf("Kuota lampiran tidak cukup, terpakai %s dari %s", formatFileSize(usage), formatFileSize(config.AttachmentQuota)), nil
+	}
+	return "", nil
+}
+
+// ukuran file dalam KB atau MB untuk pesan ke user
+func formatFileSize(size int64) string {
+	if size < 1024*1024 {
+		return fmt.Sprintf("%.0f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+}
+
+// simpan lampiran ke storage, file yang sudah tersimpan dihapus lagi jika salah satunya gagal
+func saveAttachments(userId string, uploads []attachmentUpload) ([]entities.Attachment, error) {
+
+	var attachments []entities.Attachment
+	for _, upload := range uploads {
+		attachment, err := helpers.SaveAttachment(config.FileStorage, userId, upload.filename, upload.content)
+		if err != nil {
+			deleteAttachments(attachments)
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// baca seluruh isi file lampiran dari storage
+func readAttachment(key string) ([]byte, error) {
+
+	file, err := config.FileStorage.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(io.LimitReader(file, helpers.MaxAttachmentSize))
+}
+
+// hapus file lampiran dan thumbnail-nya yang sudah tidak dipakai, kegagalan hanya dicatat
+func deleteAttachments(attachments []entities.Attachment) {
+	for _, attachment := range attachments {
+		for _, key := range helpers.AttachmentFileKeys(attachment) {
+			if err := config.FileStorage.Delete(key); err != nil {
+				log.Println("Gagal menghapus lampiran,", err)
+			}
+		}
+	}
+}
+
+// isi lampiran tiap catatan di list dengan satu query
+func loadFinancialAttachments(db *sql.DB, userId string, financials []entities.Financial) error {
+
+	ids := make([]int64, len(financials))
+	for i, financial := range financials {
+		ids[i] = financial.Id
+	}
+	attachments, err := models.NewAttachmentModel(db).FindAttachmentsByRecords(userId, ids)
+	if err != nil {
+		return err
+	}
+	for i := range financials {
+		financials[i].Attachments = attachments[financials[i].Id]
+	}
+	return nil
 }
 
 func (controller *FinancialController) Home(writer http.ResponseWriter, request *http.Request) {
@@ -55,22 +280,17 @@ func (controller *FinancialController) Home(writer http.ResponseWriter, request
 		data["success"] = flashes[0]
 		sessions.Save(request, writer)
 	}
-
-	// tampilkan dropdown bulan
-	currentDate := time.Now()
-	var months []string
-	for i := 0; i < 6; i++ {
-		previousMonth := currentDate.AddDate(0, -i, 0)
-		months = append(months, previousMonth.Format("January 2006"))
+	if flashes := sessions.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+		sessions.Save(request, writer)
 	}
-	data["months"] = months
 
-	// trigger ketika dropdown bulan dipilih
-	selectedMonth := request.URL.Query().Get("selected_month")
-	if selectedMonth == "" {
-		selectedMonth = currentDate.Format("January 2006")
-	}
-	data["selectedMonth"] = selectedMonth
+	// trigger ketika filter periode dipilih
+	startDate, endDate := parseDateRangeFilter(request, data)
+
+	// anggaran ditampilkan untuk bulan dari tanggal akhir periode
+	budgetMonth := endDate.Format("January 2006")
+	data["budgetMonth"] = budgetMonth
 
 	// trigger ketika checkbox Pemasukan/Pengeluaran dipilih
 	pemasukanOnly := request.URL.Query().Get("pemasukanOnly") == "true"
@@ -78,10 +298,33 @@ func (controller *FinancialController) Home(writer http.ResponseWriter, request
 	pengeluaranOnly := request.URL.Query().Get("pengeluaranOnly") == "true"
 	data["pengeluaranOnly"] = pengeluaranOnly
 
-	// tampilkan total Pemasukan dan Pengeluaran
+	// trigger ketika dropdown akun dipilih
+	accountId, _ := strconv.ParseInt(request.URL.Query().Get("account_id"), 10, 64)
+	data["accountId"] = accountId
+
+	// tampilkan saldo tiap akun
 	sessionUserId := sessions.Values["ID"].(string)
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+	accountModel := models.NewAccountModel(controller.db)
+	accounts, err := accountModel.FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	} else {
+		data["accounts"] = accounts
+	}
+
+	filter := entities.FinancialFilter{
+		UserId:          sessionUserId,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		PemasukanOnly:   pemasukanOnly,
+		PengeluaranOnly: pengeluaranOnly,
+		AccountId:       accountId,
+	}
+
+	// tampilkan total Pemasukan dan Pengeluaran
 	model := models.NewFinancalModel(controller.db)
-	totalPemasukan, totalPengeluaran, err := model.GetFinancialTotalNominal(sessionUserId, selectedMonth, pemasukanOnly, pengeluaranOnly)
+	totalPemasukan, totalPengeluaran, err := model.GetFinancialTotalNominal(filter)
 	if err != nil {
 		data["error"] = "Gagal mendapatkan total data keuangan, " + err.Error()
 	} else {
@@ -89,21 +332,175 @@ func (controller *FinancialController) Home(writer http.ResponseWriter, request
 		data["total_pengeluaran"] = totalPengeluaran
 	}
 
-	// tampilkan list keuangan
-	financials, err := model.FindAllFinancial(sessionUserId, selectedMonth, pemasukanOnly, pengeluaranOnly)
+	// catatan mata uang asing yang belum punya kurs tidak ikut dihitung di total
+	if missingRates, err := model.CountMissingExchangeRates(filter); err == nil {
+		data["missingRates"] = missingRates
+	}
+
+	// tampilkan list keuangan per halaman
+	page := parseFinancialPage(request, data)
+	financials, hasMore, err := model.FindFinancialPage(filter, page)
 	if err != nil {
 		data["error"] = "Gagal menampilkan list data keuangan, " + err.Error()
 	} else {
+		// tampilkan lampiran tiap catatan di halaman ini
+		if err := loadFinancialAttachments(controller.db, sessionUserId, financials); err != nil {
+			data["error"] = "Gagal menampilkan lampiran, " + err.Error()
+		}
 		data["financials"] = financials
+
+		// posisi halaman sebelum dan sesudah
+		hasNext, hasPrev := hasMore, page.Cursor != ""
+		if page.Backward {
+			hasNext, hasPrev = true, hasMore
+		}
+		if len(financials) > 0 {
+			if hasNext {
+				data["nextCursor"] = models.EncodeFinancialCursor(page.Sort, financials[len(financials)-1])
+			}
+			if hasPrev {
+				data["prevCursor"] = models.EncodeFinancialCursor(page.Sort, financials[0])
+			}
+		}
+
+		// total halaman ini dibandingkan dengan total periode
+		var pagePemasukan, pagePengeluaran int64
+		for _, financial := range financials {
+			if financial.BaseNominal == nil {
+				continue
+			}
+			switch financial.Type {
+			case "pemasukan":
+				pagePemasukan += *financial.BaseNominal
+			case "pengeluaran":
+				pagePengeluaran += *financial.BaseNominal
+			}
+		}
+		data["page_pemasukan"] = pagePemasukan
+		data["page_pengeluaran"] = pagePengeluaran
 	}
 
-	funcMap := template.FuncMap{
-		"formatIDR": formatIDR,
-		"indexNo":   func(a, b int) int { return a + b },
+	// tampilkan saldo berjalan kalau list difilter per akun dan diurutkan berdasarkan tanggal
+	if accountId != 0 && !pemasukanOnly && !pengeluaranOnly && page.Sort == "date" && !page.Desc && len(financials) > 0 && err == nil {
+		balance, err := accountModel.GetBalanceBefore(accountId, sessionUserId, startDate)
+		if err == nil {
+			// tambahkan catatan periode ini yang ada di halaman sebelumnya
+			var net int64
+			net, err = model.GetNetNominalBefore(filter, financials[0])
+			balance += net
+		}
+		if err == nil {
+			data["openingBalance"] = balance
+			for i := range financials {
+				// catatan yang kursnya belum tersedia tidak mengubah saldo, sama seperti saldo akun
+				var nominal int64
+				if financials[i].BaseNominal != nil {
+					nominal = *financials[i].BaseNominal
+				}
+				switch financials[i].Type {
+				case "pemasukan", models.TransferInType:
+					balance += nominal
+				case "pengeluaran", models.TransferOutType:
+					balance -= nominal
+				}
+				runningBalance := balance
+				financials[i].RunningBalance = &runningBalance
+			}
+			data["showRunningBalance"] = true
+		}
+	}
+
+	// tampilkan pemakaian anggaran bulan yang dipilih
+	budgets, err := models.NewBudgetModel(controller.db).FindBudgetUsage(sessionUserId, budgetMonth)
+	if err != nil {
+		data["error"] = "Gagal menampilkan anggaran, " + err.Error()
+	} else {
+		var overBudgets []entities.BudgetUsage
+		for _, budget := range budgets {
+			if budget.Over {
+				overBudgets = append(overBudgets, budget)
+			}
+		}
+		data["budgets"] = budgets
+		data["overBudgets"] = overBudgets
 	}
 
-	template, _ := template.New(filepath.Base(templateLayout)).Funcs(funcMap).ParseFiles(templateLayout)
-	template.Execute(writer, data)
+	views.RenderTemplate(writer, templateLayout, data)
+}
+
+// jumlah maksimal hasil pencarian yang ditampilkan
+const searchLimit = 100
+
+func (controller *FinancialController) SearchFinancialRecord(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/financial/search.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
+	// ambil kriteria pencarian dari url
+	query := request.URL.Query()
+	keyword := strings.TrimSpace(query.Get("q"))
+	data["q"] = keyword
+	data["nominalMin"] = query.Get("nominal_min")
+	data["nominalMax"] = query.Get("nominal_max")
+	data["startDate"] = query.Get("start_date")
+	data["endDate"] = query.Get("end_date")
+
+	search := entities.FinancialSearch{UserId: sessionUserId, Limit: searchLimit}
+	search.Terms, search.Nominals = helpers.ParseSearchQuery(keyword)
+
+	validation := make(map[string]interface{})
+	if value := strings.TrimSpace(query.Get("nominal_min")); value != "" {
+		if money, err := entities.ParseMoney(value, ""); err == nil {
+			search.NominalMin = &money.Amount
+		} else {
+			validation["NominalMin"] = invalidMoneyMessage
+		}
+	}
+	if value := strings.TrimSpace(query.Get("nominal_max")); value != "" {
+		if money, err := entities.ParseMoney(value, ""); err == nil {
+			search.NominalMax = &money.Amount
+		} else {
+			validation["NominalMax"] = invalidMoneyMessage
+		}
+	}
+	if date, err := time.ParseInLocation("2006-01-02", query.Get("start_date"), time.Local); err == nil {
+		search.StartDate = &date
+	}
+	if date, err := time.ParseInLocation("2006-01-02", query.Get("end_date"), time.Local); err == nil {
+		search.EndDate = &date
+	}
+
+	if len(validation) > 0 {
+		data["validation"] = validation
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	// tampilkan form saja kalau belum ada kriteria
+	if len(search.Terms) == 0 && len(search.Nominals) == 0 && search.NominalMin == nil && search.NominalMax == nil && search.StartDate == nil && search.EndDate == nil {
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	financials, err := models.NewFinancalModel(controller.db).SearchFinancial(search)
+	if err != nil {
+		data["error"] = "Gagal mencari data keuangan, " + err.Error()
+	} else {
+		data["financials"] = financials
+		data["limitReached"] = len(financials) >= searchLimit
+	}
+	data["searched"] = true
+	data["terms"] = search.Terms
+	data["limit"] = searchLimit
+
+	views.RenderTemplate(writer, templateLayout, data)
 }
 
 func (controller *FinancialController) AddFinacialRecord(writer http.ResponseWriter, request *http.Request) {
@@ -116,22 +513,50 @@ func (controller *FinancialController) AddFinacialRecord(writer http.ResponseWri
 	// panggil session
 	session, _ := config.Store.Get(request, config.SESSION_ID)
 
+	// ambil user id
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// tampilkan pilihan kategori milik user
+	categoryModel := models.NewCategoryModel(controller.db)
+	categories, err := categoryModel.FindAllCategory(sessionUserId, "")
+	if err != nil {
+		data["error"] = "Gagal menampilkan kategori, " + err.Error()
+	}
+	data["categories"] = categories
+
+	// tampilkan pilihan akun milik user
+	accountModel := models.NewAccountModel(controller.db)
+	accounts, err := accountModel.FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	}
+	data["accounts"] = accounts
+
+	// tampilkan pilihan mata uang
+	data["currencies"] = helpers.Currencies
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
 	if request.Method == http.MethodPost {
 
-		request.ParseForm()
+		formMessage := parseFinancialForm(writer, request)
 
 		// ambil tanggal
 		dateStr := request.Form.Get("date")
 		date, _ := time.Parse("2006-01-02", dateStr)
 
-		// ambil nominal
-		nominalStr := request.Form.Get("nominal")
-		nominal, _ := strconv.ParseInt(nominalStr, 10, 64)
+		// ambil nominal, boleh memakai pemisah ribuan seperti 1.500.000,50
+		nominal, validNominal := parseMoneyField(request, "nominal")
+
+		// ambil akun
+		accountId, _ := strconv.ParseInt(request.Form.Get("account_id"), 10, 64)
 
-		// ambil attachment
-		var attachment *string
-		if attachmentValue := request.Form.Get("attachment"); attachmentValue != "" {
-			attachment = &attachmentValue
+		// ambil mata uang
+		currency := strings.ToUpper(request.Form.Get("currency"))
+
+		// ambil file lampiran, disimpan ke storage setelah validasi berhasil
+		uploads, attachmentMessage := readAttachmentFields(request)
+		if formMessage != "" {
+			attachmentMessage = formMessage
 		}
 
 		// ambil deskripsi
@@ -140,30 +565,67 @@ func (controller *FinancialController) AddFinacialRecord(writer http.ResponseWri
 			description = &descriptionValue
 		}
 
-		// ambil user id
-		sessionUserId, _ := session.Values["ID"].(string)
-
 		// masukkan ke struct
 		financial := entities.AddFinancial{
 			UserId:      sessionUserId,
 			Date:        date,
 			Type:        request.Form.Get("type"),
 			Category:    request.Form.Get("category"),
+			AccountId:   accountId,
 			Nominal:     nominal,
+			Currency:    currency,
 			Description: description,
-			Attachment:  attachment,
 		}
 
-		// tampilkan error sesuai ketentuan di Struct
-		if err := helpers.NewValidator(controller.db).Struct(financial); err != nil {
+		// nominal harus bisa dibaca sebagai angka
+		if !validNominal {
+			data["validation"] = map[string]interface{}{"Nominal": invalidMoneyMessage}
+			data["financial"] = financial
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// lampiran harus gambar atau PDF dan masih cukup kuotanya
+		if attachmentMessage == "" {
+			if attachmentMessage, err = checkAttachmentQuota(controller.db, sessionUserId, uploads, 0); err != nil {
+				attachmentMessage = "Gagal menghitung kuota lampiran, " + err.Error()
+			}
+		}
+		if attachmentMessage != "" {
+			data["validation"] = map[string]interface{}{"Attachments": attachmentMessage}
+			data["financial"] = financial
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error validasi
+		if err := validateFinancial(controller.db, financial); err != nil {
 			data["validation"] = err
 			data["financial"] = financial
 			views.RenderTemplate(writer, templateLayout, data)
 			return
 		}
 
-		// insert ke database
-		if err := models.NewFinancalModel(controller.db).AddFinacialRecord(financial); err != nil {
+		// simpan lampiran ke storage, database hanya menyimpan key-nya
+		attachments, err := saveAttachments(sessionUserId, uploads)
+		if err != nil {
+			data["error"] = "Gagal menyimpan lampiran, " + err.Error()
+			data["financial"] = financial
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// insert ke database, catatan dihapus permanen lagi jika lampirannya gagal disimpan
+		// supaya tidak muncul di sampah
+		model := models.NewFinancalModel(controller.db)
+		id, err := model.AddFinacialRecord(financial)
+		if err == nil && len(attachments) > 0 {
+			if err = models.NewAttachmentModel(controller.db).UpdateRecordAttachments(id, sessionUserId, attachments, nil); err != nil {
+				model.RemoveFinancialRecord(id, sessionUserId)
+			}
+		}
+		if err != nil {
+			deleteAttachments(attachments)
 			data["error"] = "Gagal menambahkan data keuangan, " + err.Error()
 			views.RenderTemplate(writer, templateLayout, data)
 			return
@@ -179,13 +641,21 @@ func (controller *FinancialController) AddFinacialRecord(writer http.ResponseWri
 
 }
 
+// DeleteFinancialRecord memindahkan catatan ke sampah, hanya lewat POST supaya tidak
+// terhapus dari link biasa. Lampiran tetap disimpan sampai catatan dihapus permanen.
 func (controller *FinancialController) DeleteFinancialRecord(writer http.ResponseWriter, request *http.Request) {
 
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
 	// panggil session
 	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
 
-	idStr := request.URL.Query().Get("id")
-	id, err := strconv.ParseInt(idStr, 10, 16)
+	idStr := request.FormValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
 	if idStr == "" || err != nil {
 		session.AddFlash("Gagal mengambil data keuangan", "error")
 		session.Save(request, writer)
@@ -193,64 +663,407 @@ func (controller *FinancialController) DeleteFinancialRecord(writer http.Respons
 		return
 	}
 
-	if err := models.NewFinancalModel(controller.db).DeleteFinancialRecord(int16(id)); err != nil {
-		session.AddFlash("Gagal menghapus data keuangan, "+err.Error(), "error")
+	// catatan hanya bisa dihapus oleh pemiliknya
+	model := models.NewFinancalModel(controller.db)
+	financial, err := model.FindFinancialById(id, sessionUserId)
+	if errors.Is(err, sql.ErrNoRows) {
+		views.RenderNotFound(writer, "Data keuangan tidak ditemukan")
+		return
+	} else if err != nil {
+		session.AddFlash("Gagal mengambil data keuangan, "+err.Error(), "error")
 		session.Save(request, writer)
-	} else {
-		session.AddFlash("Berhasil menghapus data keuangan", "success")
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	// catatan transfer dipindahkan ke sampah bersama pasangannya
+	if financial.TransferId != nil {
+		if err := models.NewTransferModel(controller.db).DeleteTransfer(*financial.TransferId, sessionUserId); err != nil {
+			session.AddFlash("Gagal menghapus transfer, "+err.Error(), "error")
+		} else {
+			session.AddFlash("Transfer dipindahkan ke sampah", "success")
+		}
 		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
 	}
 
+	if err := model.DeleteFinancialRecord(id, sessionUserId); err != nil {
+		session.AddFlash("Gagal menghapus data keuangan, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Data keuangan dipindahkan ke sampah", "success")
+	}
+	session.Save(request, writer)
+
 	http.Redirect(writer, request, "/home", http.StatusSeeOther)
 }
 
-func (controller *FinancialController) DownloadFinancialRecord(writer http.ResponseWriter, request *http.Request) {
+// Trash menampilkan catatan di sampah yang masih bisa dikembalikan atau dihapus permanen
+func (controller *FinancialController) Trash(writer http.ResponseWriter, request *http.Request) {
 
-	templateLayout := "views/financial/download.html"
+	templateLayout := "views/financial/trash.html"
 
 	// untuk mengirim data ke html
 	var data = make(map[string]interface{})
 
 	// panggil session
-	sessions, _ := config.Store.Get(request, config.SESSION_ID)
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
 
-	// tampilkan bulan yang dipilih
-	selectedMonth := request.URL.Query().Get("selected_month")
-	data["selectedMonth"] = selectedMonth
+	// tampilkan alert dari session
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		data["success"] = flashes[0]
+		session.Save(request, writer)
+	}
+	if flashes := session.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+		session.Save(request, writer)
+	}
 
-	// tampilkan data Pemasukan/Pengeluaran yang dipilih
-	pemasukanOnly := request.URL.Query().Get("pemasukanOnly") == "true"
-	data["pemasukanOnly"] = pemasukanOnly
-	pengeluaranOnly := request.URL.Query().Get("pengeluaranOnly") == "true"
-	data["pengeluaranOnly"] = pengeluaranOnly
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+	data["retentionDays"] = config.TrashRetentionDays
 
-	// tampilkan total Pemasukan dan Pengeluaran
-	sessionUserId := sessions.Values["ID"].(string)
-	model := models.NewFinancalModel(controller.db)
-	totalPemasukan, totalPengeluaran, err := model.GetFinancialTotalNominal(sessionUserId, selectedMonth, pemasukanOnly, pengeluaranOnly)
+	financials, err := models.NewFinancalModel(controller.db).FindDeletedFinancial(sessionUserId)
+	if err == nil && len(financials) > 0 {
+		err = loadFinancialAttachments(controller.db, sessionUserId, financials)
+	}
 	if err != nil {
-		data["error"] = "Gagal mendapatkan total data keuangan, " + err.Error()
+		data["error"] = "Gagal menampilkan sampah, " + err.Error()
+	}
+	data["financials"] = financials
+
+	views.RenderTemplate(writer, templateLayout, data)
+}
+
+// RestoreFinancialRecord mengembalikan catatan dari sampah, catatan transfer bersama pasangannya
+func (controller *FinancialController) RestoreFinancialRecord(writer http.ResponseWriter, request *http.Request) {
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/financial/trash", http.StatusSeeOther)
+		return
+	}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	id, err := strconv.ParseInt(request.FormValue("id"), 10, 64)
+	if err == nil {
+		err = models.NewFinancalModel(controller.db).RestoreFinancialRecord(id, sessionUserId)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		views.RenderNotFound(writer, "Data keuangan tidak ditemukan di sampah")
+		return
+	} else if err != nil {
+		session.AddFlash("Gagal mengembalikan data keuangan, "+err.Error(), "error")
 	} else {
-		data["total_pemasukan"] = totalPemasukan
-		data["total_pengeluaran"] = totalPengeluaran
+		session.AddFlash("Berhasil mengembalikan data keuangan", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/financial/trash", http.StatusSeeOther)
+}
+
+// PurgeFinancialRecord menghapus permanen catatan di sampah beserta lampirannya, catatan
+// transfer bersama pasangannya
+func (controller *FinancialController) PurgeFinancialRecord(writer http.ResponseWriter, request *http.Request) {
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/financial/trash", http.StatusSeeOther)
+		return
 	}
 
-	// tampilkan list keuangan
-	financials, err := model.FindAllFinancial(sessionUserId, selectedMonth, pemasukanOnly, pengeluaranOnly)
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	id, err := strconv.ParseInt(request.FormValue("id"), 10, 64)
 	if err != nil {
-		data["error"] = "Gagal menampilkan list data keuangan, " + err.Error()
+		views.RenderNotFound(writer, "Data keuangan tidak ditemukan di sampah")
+		return
+	}
+
+	// file lampiran (termasuk milik pasangan transfer) dihapus setelah datanya terhapus
+	attachments, err := models.NewFinancalModel(controller.db).PurgeFinancialRecord(id, sessionUserId)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		views.RenderNotFound(writer, "Data keuangan tidak ditemukan di sampah")
+		return
+	} else if err != nil {
+		session.AddFlash("Gagal menghapus data keuangan, "+err.Error(), "error")
 	} else {
-		data["financials"] = financials
+		deleteAttachments(attachments)
+		session.AddFlash("Data keuangan dihapus permanen", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/financial/trash", http.StatusSeeOther)
+}
+
+// Attachment menampilkan file lampiran catatan keuangan, hanya untuk pemilik lampiran.
+// Dengan thumbnail=true yang dikirim thumbnail-nya jika ada. Tipe file dibaca dari isinya
+// dan browser tidak boleh menebak tipe lain (nosniff).
+func (controller *FinancialController) Attachment(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	id, err := strconv.ParseInt(request.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		views.RenderNotFound(writer, "Lampiran tidak ditemukan")
+		return
+	}
+
+	// lampiran milik user lain dianggap tidak ada
+	attachment, err := models.NewAttachmentModel(controller.db).FindAttachmentById(id, sessionUserId)
+	if errors.Is(err, sql.ErrNoRows) {
+		views.RenderNotFound(writer, "Lampiran tidak ditemukan")
+		return
+	} else if err != nil {
+		http.Error(writer, "Gagal mengambil lampiran", http.StatusInternalServerError)
+		return
+	}
+
+	key, contentType := attachment.StorageKey, attachment.ContentType
+	if request.URL.Query().Get("thumbnail") == "true" && attachment.ThumbnailKey != nil {
+		// thumbnail selalu JPEG
+		key, contentType = *attachment.ThumbnailKey, "image/jpeg"
+	}
+
+	file, err := config.FileStorage.Open(key)
+	if errors.Is(err, storage.ErrNotFound) {
+		views.RenderNotFound(writer, "Lampiran tidak ditemukan")
+		return
+	} else if err != nil {
+		log.Println("Gagal membaca lampiran,", err)
+		http.Error(writer, "Gagal membaca lampiran", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	// 512 byte pertama cukup untuk menebak tipe file, isi yang tidak sesuai tipe saat
+	// upload dikirim sebagai file biasa
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		http.Error(writer, "Gagal membaca lampiran", http.StatusInternalServerError)
+		return
+	}
+	if http.DetectContentType(head[:n]) != contentType {
+		contentType = "application/octet-stream"
+	}
+
+	writer.Header().Set("Content-Type", contentType)
+	writer.Header().Set("X-Content-Type-Options", "nosniff")
+	writer.Header().Set("Cache-Control", "private, max-age=86400")
+	writer.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.Filename}))
+	writer.Write(head[:n])
+	io.Copy(writer, file)
+}
+
+// DownloadFinancialRecord mengunduh laporan keuangan di periode dan akun yang dipilih sebagai
+// file PDF berisi total, rincian per kategori dan daftar transaksi dengan thumbnail lampiran.
+func (controller *FinancialController) DownloadFinancialRecord(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// periode dan filter sama seperti halaman home
+	data := make(map[string]interface{})
+	startDate, endDate := parseDateRangeFilter(request, data)
+	accountId, _ := strconv.ParseInt(request.URL.Query().Get("account_id"), 10, 64)
+	filter := entities.FinancialFilter{
+		UserId:          sessionUserId,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		PemasukanOnly:   request.URL.Query().Get("pemasukanOnly") == "true",
+		PengeluaranOnly: request.URL.Query().Get("pengeluaranOnly") == "true",
+		AccountId:       accountId,
+	}
+
+	statement := helpers.StatementPDF{
+		Period:         data["periodLabel"].(string),
+		Currency:       userBaseCurrency(controller.db, sessionUserId),
+		GeneratedAt:    time.Now(),
+		ReadAttachment: readAttachment,
+	}
+	if user, err := models.NewUserModel(controller.db).FindUserById(sessionUserId); err == nil {
+		statement.UserName = user.Name
+	}
+	if accountId != 0 {
+		if account, err := models.NewAccountModel(controller.db).FindAccountById(accountId, sessionUserId); err == nil {
+			statement.AccountName = account.Name
+		}
+	}
+
+	// semua data dibaca dulu supaya error masih bisa ditampilkan sebelum file dikirim
+	model := models.NewFinancalModel(controller.db)
+	var err error
+	statement.TotalPemasukan, statement.TotalPengeluaran, err = model.GetFinancialTotalNominal(filter)
+	if err == nil {
+		statement.MissingRates, err = model.CountMissingExchangeRates(filter)
+	}
+	if err == nil {
+		statement.Categories, err = model.GetCategoryTotals(filter)
+	}
+	if err == nil {
+		statement.Financials, err = model.FindAllFinancial(filter)
+	}
+	if err == nil && len(statement.Financials) > 0 {
+		err = loadFinancialAttachments(controller.db, sessionUserId, statement.Financials)
+	}
+	if err != nil {
+		session.AddFlash("Gagal mengambil data keuangan, "+err.Error(), "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	filename := fmt.Sprintf("financial-record_%s_%s.pdf", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	writer.Header().Set("Content-Type", "application/pdf")
+	writer.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if err := helpers.WriteStatementPDF(writer, statement); err != nil {
+		log.Println("Gagal membuat PDF data keuangan,", err)
 	}
+}
+
+// content type file export per format
+var exportContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ofx":  "application/x-ofx",
+	"qif":  "application/qif",
+}
+
+// ExportFinancialRecord mengunduh catatan keuangan di periode dan akun yang dipilih sebagai
+// file CSV, XLSX, OFX atau QIF (format=csv/xlsx/ofx/qif). CSV dan XLSX ditulis ke response
+// per catatan beserta baris total, OFX dan QIF untuk dibuka di aplikasi keuangan lain.
+func (controller *FinancialController) ExportFinancialRecord(writer http.ResponseWriter, request *http.Request) {
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	format := request.URL.Query().Get("format")
+	if _, ok := exportContentTypes[format]; !ok {
+		session.AddFlash("Format export tidak dikenal", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	// periode dan filter sama seperti halaman home
+	startDate, endDate := parseDateRangeFilter(request, make(map[string]interface{}))
+	accountId, _ := strconv.ParseInt(request.URL.Query().Get("account_id"), 10, 64)
+	filter := entities.FinancialFilter{
+		UserId:          sessionUserId,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		PemasukanOnly:   request.URL.Query().Get("pemasukanOnly") == "true",
+		PengeluaranOnly: request.URL.Query().Get("pengeluaranOnly") == "true",
+		AccountId:       accountId,
+	}
+
+	model := models.NewFinancalModel(controller.db)
+	currency := userBaseCurrency(controller.db, sessionUserId)
+	filename := fmt.Sprintf("financial-record_%s_%s.%s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), format)
+
+	if format == "csv" || format == "xlsx" {
+
+		// total dihitung dulu supaya error masih bisa ditampilkan sebelum file mulai dikirim
+		totalPemasukan, totalPengeluaran, err := model.GetFinancialTotalNominal(filter)
+		if err != nil {
+			session.AddFlash("Gagal mendapatkan total data keuangan, "+err.Error(), "error")
+			session.Save(request, writer)
+			http.Redirect(writer, request, "/home", http.StatusSeeOther)
+			return
+		}
+
+		writer.Header().Set("Content-Type", exportContentTypes[format])
+		writer.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+		export, err := helpers.NewFinancialExport(writer, format, currency)
+		if err == nil {
+			err = model.EachFinancial(filter, export.WriteFinancial)
+		}
+		if err == nil {
+			err = export.WriteTotals(totalPemasukan, totalPengeluaran)
+		}
+		if err == nil {
+			err = export.Close()
+		}
+
+		// sebagian file sudah terkirim, error hanya bisa dicatat
+		if err != nil {
+			log.Println("Gagal export data keuangan,", err)
+		}
+		return
+	}
+
+	// OFX dan QIF dikelompokkan per akun, lampiran tidak ikut dibaca
+	var financials []entities.Financial
+	err := model.EachFinancial(filter, func(financial entities.Financial) error {
+		financials = append(financials, financial)
+		return nil
+	})
+	if err != nil {
+		session.AddFlash("Gagal mengambil data keuangan, "+err.Error(), "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	accountModel := models.NewAccountModel(controller.db)
+	accounts, err := accountModel.FindAllAccount(sessionUserId)
+	if err != nil {
+		session.AddFlash("Gagal mengambil akun, "+err.Error(), "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	// satu statement per akun, akun tanpa catatan di periode tidak ditulis kecuali dipilih
+	var statements []helpers.ExportStatement
+	for _, account := range accounts {
+		if accountId != 0 && account.Id != accountId {
+			continue
+		}
+
+		statement := helpers.ExportStatement{Account: account}
+		for _, financial := range financials {
+			if financial.AccountId == account.Id {
+				statement.Financials = append(statement.Financials, financial)
+			}
+		}
+		if len(statement.Financials) == 0 && accountId == 0 {
+			continue
+		}
 
-	funcMap := template.FuncMap{
-		"formatIDR": formatIDR,
-		"indexNo":   func(a, b int) int { return a + b },
+		statement.Balance, err = accountModel.GetBalanceBefore(account.Id, sessionUserId, endDate.AddDate(0, 0, 1))
+		if err != nil {
+			session.AddFlash("Gagal menghitung saldo akun, "+err.Error(), "error")
+			session.Save(request, writer)
+			http.Redirect(writer, request, "/home", http.StatusSeeOther)
+			return
+		}
+		statements = append(statements, statement)
 	}
 
-	template, _ := template.New(filepath.Base(templateLayout)).Funcs(funcMap).ParseFiles(templateLayout)
-	template.Execute(writer, data)
+	writer.Header().Set("Content-Type", exportContentTypes[format])
+	writer.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
 
+	if format == "ofx" {
+		err = helpers.WriteOFX(writer, statements, currency, startDate, endDate, time.Now())
+	} else {
+		err = helpers.WriteQIF(writer, statements, currency)
+	}
+	if err != nil {
+		log.Println("Gagal export data keuangan,", err)
+	}
 }
 
 func (controller *FinancialController) EditFinancialRecord(writer http.ResponseWriter, request *http.Request) {
@@ -260,42 +1073,105 @@ func (controller *FinancialController) EditFinancialRecord(writer http.ResponseW
 	// panggil session
 	session, _ := config.Store.Get(request, config.SESSION_ID)
 
+	// ambil user id
+	sessionUserId, _ := session.Values["ID"].(string)
+
 	// untuk mengirim data ke html
 	var data = make(map[string]interface{})
 
+	// tampilkan pilihan kategori milik user
+	categoryModel := models.NewCategoryModel(controller.db)
+	categories, err := categoryModel.FindAllCategory(sessionUserId, "")
+	if err != nil {
+		data["error"] = "Gagal menampilkan kategori, " + err.Error()
+	}
+	data["categories"] = categories
+
+	// tampilkan pilihan akun milik user
+	accountModel := models.NewAccountModel(controller.db)
+	accounts, err := accountModel.FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	}
+	data["accounts"] = accounts
+
+	// tampilkan pilihan mata uang
+	data["currencies"] = helpers.Currencies
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
 	// ambil id dari url
 	idStr := request.URL.Query().Get("id")
-	id, err := strconv.ParseInt(idStr, 10, 16)
+	id, err := strconv.ParseInt(idStr, 10, 64)
 	if idStr == "" || err != nil {
+		data["error"] = "Gagal mengambil data keuangan"
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	// tampilkan data berdasarkan id, catatan milik user lain dianggap tidak ada
+	findFinancial, err := models.NewFinancalModel(controller.db).FindFinancialById(id, sessionUserId)
+	if errors.Is(err, sql.ErrNoRows) {
+		views.RenderNotFound(writer, "Data keuangan tidak ditemukan")
+		return
+	} else if err != nil {
 		data["error"] = "Gagal mengambil data keuangan, " + err.Error()
 		views.RenderTemplate(writer, templateLayout, data)
 		return
 	}
+	data["financial"] = findFinancial
+
+	// catatan transfer diubah lewat form transfer supaya kedua sisinya tetap sama
+	if findFinancial.TransferId != nil {
+		http.Redirect(writer, request, fmt.Sprintf("/transfers/edit?id=%d", *findFinancial.TransferId), http.StatusSeeOther)
+		return
+	}
 
-	// tampilkan data berdasarkan id
-	findFinancial, err := models.NewFinancalModel(controller.db).FindFinancialById(int16(id))
+	// tampilkan lampiran yang sudah ada
+	attachmentModel := models.NewAttachmentModel(controller.db)
+	attachments, err := attachmentModel.FindAttachmentsByRecord(id, sessionUserId)
 	if err != nil {
-		data["error"] = "Data keuangan tidak ditemukan, " + err.Error()
-	} else {
-		data["financial"] = findFinancial
+		data["error"] = "Gagal menampilkan lampiran, " + err.Error()
+		views.RenderTemplate(writer, templateLayout, data)
+		return
 	}
+	data["attachments"] = attachments
 
 	if request.Method == http.MethodPost {
 
-		request.ParseForm()
+		formMessage := parseFinancialForm(writer, request)
 
 		// ambil tanggal
 		dateStr := request.Form.Get("date")
 		date, _ := time.Parse("2006-01-02", dateStr)
 
-		// ambil nominal
-		nominalStr := request.Form.Get("nominal")
-		nominal, _ := strconv.ParseInt(nominalStr, 10, 64)
+		// ambil nominal, boleh memakai pemisah ribuan seperti 1.500.000,50
+		nominal, validNominal := parseMoneyField(request, "nominal")
+
+		// ambil akun
+		accountId, _ := strconv.ParseInt(request.Form.Get("account_id"), 10, 64)
+
+		// ambil mata uang
+		currency := strings.ToUpper(request.Form.Get("currency"))
+
+		// ambil file lampiran baru, disimpan ke storage setelah validasi berhasil
+		uploads, attachmentMessage := readAttachmentFields(request)
+		if formMessage != "" {
+			attachmentMessage = formMessage
+		}
 
-		// ambil attachment
-		var attachment *string
-		if attachmentValue := request.Form.Get("attachment"); attachmentValue != "" {
-			attachment = &attachmentValue
+		// lampiran lama yang dicentang untuk dihapus, id yang bukan milik catatan ini diabaikan
+		var removed []entities.Attachment
+		var removedIds []int64
+		var freed int64
+		for _, attachment := range attachments {
+			for _, value := range request.Form["remove_attachment"] {
+				if value == strconv.FormatInt(attachment.Id, 10) {
+					removed = append(removed, attachment)
+					removedIds = append(removedIds, attachment.Id)
+					freed += attachment.Size
+					break
+				}
+			}
 		}
 
 		// ambil deskripsi
@@ -304,33 +1180,66 @@ func (controller *FinancialController) EditFinancialRecord(writer http.ResponseW
 			description = &descriptionValue
 		}
 
-		// ambil user id
-		sessionUserId, _ := session.Values["ID"].(string)
-
 		// masukkan ke struct
 		financial := entities.AddFinancial{
-			Id:          int16(id),
+			Id:          id,
 			UserId:      sessionUserId,
 			Date:        date,
 			Type:        request.Form.Get("type"),
 			Category:    request.Form.Get("category"),
+			AccountId:   accountId,
 			Nominal:     nominal,
+			Currency:    currency,
 			Description: description,
-			Attachment:  attachment,
 		}
 
-		// tampilkan error sesuai ketentuan di Struct
-		if err := helpers.NewValidator(controller.db).Struct(financial); err != nil {
+		// nominal harus bisa dibaca sebagai angka
+		if !validNominal {
+			data["validation"] = map[string]interface{}{"Nominal": invalidMoneyMessage}
+			data["financial"] = financial
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// lampiran harus gambar atau PDF dan masih cukup kuotanya
+		if attachmentMessage == "" {
+			if attachmentMessage, err = checkAttachmentQuota(controller.db, sessionUserId, uploads, freed); err != nil {
+				attachmentMessage = "Gagal menghitung kuota lampiran, " + err.Error()
+			}
+		}
+		if attachmentMessage != "" {
+			data["validation"] = map[string]interface{}{"Attachments": attachmentMessage}
+			data["financial"] = financial
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error validasi
+		if err := validateFinancial(controller.db, financial); err != nil {
 			data["validation"] = err
 			data["financial"] = financial
 			views.RenderTemplate(writer, templateLayout, data)
 			return
 		}
 
-		// update data di database
-		if err := models.NewFinancalModel(controller.db).EditFinancialRecord(financial); err != nil {
+		// simpan lampiran baru ke storage
+		added, err := saveAttachments(sessionUserId, uploads)
+		if err != nil {
+			data["error"] = "Gagal menyimpan lampiran, " + err.Error()
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// update data di database, file lampiran yang dihapus baru dihapus setelah berhasil
+		err = models.NewFinancalModel(controller.db).EditFinancialRecord(financial)
+		if err == nil {
+			err = attachmentModel.UpdateRecordAttachments(id, sessionUserId, added, removedIds)
+		}
+		if err != nil {
+			deleteAttachments(added)
 			data["error"] = "Gagal mengubah data keuangan, " + err.Error()
 		} else {
+			deleteAttachments(removed)
 			session.AddFlash("Berhasil mengubah data keuangan", "success")
 			session.Save(request, writer)
 			http.Redirect(writer, request, "/home", http.StatusSeeOther)
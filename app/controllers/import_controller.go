@@ -0,0 +1,408 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"financial-record/config"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/views"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ukuran file mutasi maksimal 2MB
+const maxImportFileSize = 2 * 1024 * 1024
+
+type ImportController struct {
+	db *sql.DB
+}
+
+func NewImportController(db *sql.DB) *ImportController {
+	return &ImportController{
+		db: db,
+	}
+}
+
+// kolom CSV yang bisa dipilih di form mapping
+type importColumn struct {
+	Index int
+	Label string
+}
+
+// ambil mapping kolom dari form, kolom yang tidak dipilih bernilai -1
+func parseImportMapping(request *http.Request) entities.ImportMapping {
+
+	column := func(field string) int {
+		index, err := strconv.Atoi(request.Form.Get(field))
+		if err != nil || index < 0 {
+			return -1
+		}
+		return index
+	}
+
+	mapping := entities.ImportMapping{
+		Date:        column("column_date"),
+		Amount:      column("column_amount"),
+		Description: column("column_description"),
+		Type:        column("column_type"),
+		Category:    column("column_category"),
+		DateFormat:  helpers.ImportDateFormats[0].Layout,
+		HasHeader:   request.Form.Get("has_header") == "true",
+	}
+	for _, format := range helpers.ImportDateFormats {
+		if request.Form.Get("date_format") == format.Layout {
+			mapping.DateFormat = format.Layout
+		}
+	}
+
+	return mapping
+}
+
+// Import menampilkan upload CSV mutasi (step upload), pilihan kolom, preview per baris
+// (step preview) lalu menyimpan baris yang dicentang (step import). File dengan preset
+// bank/e-wallet serta file OFX dan QIF langsung ke preview tanpa memilih kolom.
+// Isi file dikirim ulang di setiap step lewat field csv_data supaya tidak perlu disimpan di server.
+func (controller *ImportController) Import(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/financial/import.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// tampilkan pilihan akun, kategori dan mata uang
+	accounts, err := models.NewAccountModel(controller.db).FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	}
+	data["accounts"] = accounts
+
+	categories, err := models.NewCategoryModel(controller.db).FindAllCategory(sessionUserId, "")
+	if err != nil {
+		data["error"] = "Gagal menampilkan kategori, " + err.Error()
+	}
+	data["categories"] = categories
+
+	data["currencies"] = helpers.Currencies
+	data["dateFormats"] = helpers.ImportDateFormats
+	data["presets"] = helpers.StatementPresets
+	data["currency"] = userBaseCurrency(controller.db, sessionUserId)
+	data["preset"] = ""
+	data["accountId"] = int64(0)
+	data["defaultCategories"] = map[string]string{}
+	data["step"] = "upload"
+
+	if request.Method != http.MethodPost {
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	request.ParseMultipartForm(maxImportFileSize)
+	step := request.Form.Get("step")
+
+	// step upload membaca file, step berikutnya membaca csv_data dari form
+	var content []byte
+	if step == "upload" {
+		file, handler, err := request.FormFile("file")
+		if err != nil {
+			data["error"] = "Pilih file CSV yang akan diimport"
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+		defer file.Close()
+
+		if handler.Size > maxImportFileSize {
+			data["error"] = "Ukuran file terlalu besar, maksimal 2MB"
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+		content, err = io.ReadAll(file)
+		if err != nil {
+			data["error"] = "Gagal membaca file, " + err.Error()
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+	} else {
+		content, err = base64.StdEncoding.DecodeString(request.Form.Get("csv_data"))
+		if err != nil {
+			data["error"] = "Data file tidak valid, silahkan upload ulang"
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+	}
+
+	// file OFX dan QIF dikenali dari isinya dan tidak perlu memilih kolom
+	fileFormat := helpers.DetectStatementFormat(content)
+
+	var rows [][]string
+	if fileFormat == "" {
+		rows, err = helpers.ReadStatementCSV(content)
+		if err != nil || len(rows) == 0 {
+			data["error"] = "File bukan CSV, OFX atau QIF yang valid atau tidak berisi data"
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// pilihan kolom dari baris pertama
+		var columns []importColumn
+		for i, name := range rows[0] {
+			label := fmt.Sprintf("Kolom %d", i+1)
+			if name = strings.TrimSpace(name); name != "" {
+				label += " (" + name + ")"
+			}
+			columns = append(columns, importColumn{Index: i, Label: label})
+		}
+		data["columns"] = columns
+		data["sampleRows"] = rows[:min(len(rows), 5)]
+	}
+	data["csvData"] = base64.StdEncoding.EncodeToString(content)
+
+	// akun, mata uang dan kategori default
+	accountId, _ := strconv.ParseInt(request.Form.Get("account_id"), 10, 64)
+	if currency := strings.ToUpper(request.Form.Get("currency")); currency != "" {
+		data["currency"] = currency
+	}
+	defaultCategories := map[string]string{
+		"pemasukan":   request.Form.Get("default_pemasukan"),
+		"pengeluaran": request.Form.Get("default_pengeluaran"),
+	}
+	data["accountId"] = accountId
+	data["defaultCategories"] = defaultCategories
+
+	// akun harus milik user, dipilih di step upload
+	if valid, err := models.NewAccountModel(controller.db).IsUserAccount(sessionUserId, accountId); err != nil || !valid {
+		data["validation"] = map[string]interface{}{"AccountId": "Akun tidak ditemukan"}
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	// file dari bank/e-wallet yang dikenal tidak perlu memilih kolom
+	preset, usePreset := helpers.FindStatementPreset(request.Form.Get("preset"))
+	data["preset"] = preset.Value
+	if fileFormat != "" {
+		data["preset"] = fileFormat
+	}
+
+	var mapping entities.ImportMapping
+	if !usePreset && fileFormat == "" {
+		if step == "upload" {
+			data["mapping"] = helpers.GuessImportMapping(rows[0])
+			data["step"] = "mapping"
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		mapping = parseImportMapping(request)
+		data["mapping"] = mapping
+
+		// kolom tanggal dan nominal wajib dipilih, step mapping kembali ke pilihan kolom dari preview
+		validation := make(map[string]interface{})
+		if mapping.Date < 0 {
+			validation["Date"] = "Pilih kolom tanggal"
+		}
+		if mapping.Amount < 0 {
+			validation["Amount"] = "Pilih kolom nominal"
+		}
+		if step == "mapping" || len(validation) > 0 {
+			data["validation"] = validation
+			data["step"] = "mapping"
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+	}
+
+	base := entities.AddFinancial{UserId: sessionUserId, AccountId: accountId, Currency: data["currency"].(string)}
+
+	var importRows []entities.ImportRow
+	switch {
+	case fileFormat == "ofx":
+		importRows, err = helpers.ParseOFX(content, base, defaultCategories)
+	case fileFormat == "qif":
+		importRows, err = helpers.ParseQIF(content, base, defaultCategories, categories)
+	case usePreset:
+		importRows, err = preset.Parse(rows, base, defaultCategories)
+	default:
+		importRows = helpers.ParseStatementRows(rows, mapping, base, defaultCategories)
+	}
+	if err != nil {
+		data["error"] = "Gagal membaca file, " + err.Error()
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	importRows, err = controller.checkImportRows(importRows, base, categories)
+	if err != nil {
+		data["error"] = "Gagal memeriksa duplikat, " + err.Error()
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	if step == "import" {
+
+		// simpan baris valid yang dicentang
+		selected := make(map[string]bool)
+		for _, line := range request.Form["rows"] {
+			selected[line] = true
+		}
+
+		var records []entities.AddFinancial
+		startDate, endDate := "", ""
+		for _, row := range importRows {
+			if !row.Valid() || !selected[strconv.Itoa(row.Line)] {
+				continue
+			}
+			records = append(records, row.Financial)
+
+			date := row.Financial.Date.Format("2006-01-02")
+			if startDate == "" || date < startDate {
+				startDate = date
+			}
+			if date > endDate {
+				endDate = date
+			}
+		}
+
+		if len(records) == 0 {
+			data["error"] = "Tidak ada baris yang dipilih untuk diimport"
+		} else if err := models.NewFinancalModel(controller.db).ImportFinancialRecords(records); err != nil {
+			data["error"] = "Gagal mengimport data keuangan, tidak ada data yang disimpan, " + err.Error()
+		} else {
+			session.AddFlash(fmt.Sprintf("Berhasil mengimport %d catatan keuangan", len(records)), "success")
+			session.Save(request, writer)
+
+			// tampilkan periode data yang diimport
+			query := url.Values{"preset": {"custom"}, "start_date": {startDate}, "end_date": {endDate}, "account_id": {strconv.FormatInt(accountId, 10)}}
+			http.Redirect(writer, request, "/home?"+query.Encode(), http.StatusSeeOther)
+			return
+		}
+	}
+
+	// hitung ringkasan preview
+	validCount, duplicateCount := 0, 0
+	for _, row := range importRows {
+		if row.Valid() {
+			validCount++
+		}
+		if row.Duplicate {
+			duplicateCount++
+		}
+	}
+	data["rows"] = importRows
+	data["validCount"] = validCount
+	data["invalidCount"] = len(importRows) - validCount
+	data["duplicateCount"] = duplicateCount
+	data["step"] = "preview"
+
+	views.RenderTemplate(writer, templateLayout, data)
+}
+
+// validasi setiap baris dan tandai baris yang sudah ada di akun. Kategori dicek dari
+// daftar kategori user supaya tidak query ke database per baris.
+func (controller *ImportController) checkImportRows(importRows []entities.ImportRow, base entities.AddFinancial, categories []entities.Category) ([]entities.ImportRow, error) {
+
+	userCategories := make(map[string]bool)
+	for _, category := range categories {
+		userCategories[category.Type+"|"+category.Name] = true
+	}
+
+	validator := helpers.NewValidator(controller.db)
+	for i := range importRows {
+		row := &importRows[i]
+		if !row.Valid() {
+			continue
+		}
+
+		if err := validator.Struct(row.Financial); err != nil {
+			messages := err.(map[string]interface{})
+			fields := make([]string, 0, len(messages))
+			for field := range messages {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			for _, field := range fields {
+				row.Errors = append(row.Errors, fmt.Sprint(messages[field]))
+			}
+			continue
+		}
+
+		if !userCategories[row.Financial.Type+"|"+row.Financial.Category] {
+			row.Errors = append(row.Errors, "Kategori \""+row.Financial.Category+"\" tidak ditemukan")
+		}
+	}
+
+	// cari catatan yang sudah ada di periode file
+	var startDate, endDate time.Time
+	for _, row := range importRows {
+		if !row.Valid() {
+			continue
+		}
+		if startDate.IsZero() || row.Financial.Date.Before(startDate) {
+			startDate = row.Financial.Date
+		}
+		if endDate.IsZero() || row.Financial.Date.After(endDate) {
+			endDate = row.Financial.Date
+		}
+	}
+	if startDate.IsZero() {
+		return importRows, nil
+	}
+
+	model := models.NewFinancalModel(controller.db)
+	existing, err := model.CountFinancialImportKeys(base.UserId, base.AccountId, startDate, endDate)
+	if err != nil {
+		return importRows, err
+	}
+
+	// baris dengan FITID yang sudah tersimpan pasti duplikat, catatannya tidak dipakai lagi
+	// untuk menandai baris lain
+	if hasExternalIds(importRows) {
+		externalIds, err := model.FindFinancialExternalIds(base.UserId, base.AccountId, startDate, endDate)
+		if err != nil {
+			return importRows, err
+		}
+		for i := range importRows {
+			row := &importRows[i]
+			if !row.Valid() || row.Financial.ExternalId == nil {
+				continue
+			}
+			if key, ok := externalIds[*row.Financial.ExternalId]; ok {
+				row.Duplicate = true
+				if existing[key] > 0 {
+					existing[key]--
+				}
+			}
+		}
+	}
+
+	// setiap catatan yang sudah ada hanya menandai satu baris
+	for i := range importRows {
+		row := &importRows[i]
+		key := models.FinancialImportKey(row.Financial.Date, row.Financial.Type, row.Financial.Nominal)
+		if row.Valid() && !row.Duplicate && existing[key] > 0 {
+			row.Duplicate = true
+			existing[key]--
+		}
+	}
+
+	return importRows, nil
+}
+
+func hasExternalIds(importRows []entities.ImportRow) bool {
+	for _, row := range importRows {
+		if row.Financial.ExternalId != nil {
+			return true
+		}
+	}
+	return false
+}
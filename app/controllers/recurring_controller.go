@@ -0,0 +1,562 @@
+package controllers
+
+import (
+	"database/sql"
+	"financial-record/config"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/views"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RecurringController struct {
+	db *sql.DB
+}
+
+func NewRecurringController(db *sql.DB) *RecurringController {
+	return &RecurringController{
+		db: db,
+	}
+}
+
+// label frekuensi untuk ditampilkan di html
+var frequencyLabels = map[string]string{
+	"daily":   "Harian",
+	"weekly":  "Mingguan",
+	"monthly": "Bulanan",
+	"yearly":  "Tahunan",
+}
+
+func renderRecurringTemplate(writer http.ResponseWriter, templateLayout string, data map[string]interface{}) {
+
+	funcMap := template.FuncMap{
+		"formatMoney":    views.FormatMoney,
+		"moneyInput":     views.MoneyInput,
+		"frequencyLabel": func(frequency string) string { return frequencyLabels[frequency] },
+	}
+
+	template, _ := template.New(filepath.Base(templateLayout)).Funcs(funcMap).ParseFiles(templateLayout)
+	template.Execute(writer, data)
+}
+
+// ambil data transaksi berulang dari form tambah/edit, false jika nominal tidak bisa dibaca
+func parseRecurringForm(request *http.Request, userId string) (entities.Recurring, bool) {
+
+	request.ParseForm()
+
+	startDate, _ := time.Parse("2006-01-02", request.Form.Get("start_date"))
+	nominal, validNominal := parseMoneyField(request, "nominal")
+	accountId, _ := strconv.ParseInt(request.Form.Get("account_id"), 10, 64)
+	currency := strings.ToUpper(request.Form.Get("currency"))
+
+	var endDate *time.Time
+	if endDateValue, err := time.Parse("2006-01-02", request.Form.Get("end_date")); err == nil {
+		endDate = &endDateValue
+	}
+
+	var occurrences *int
+	if occurrencesValue, err := strconv.Atoi(request.Form.Get("occurrences")); err == nil {
+		occurrences = &occurrencesValue
+	}
+
+	var description *string
+	if descriptionValue := request.Form.Get("description"); descriptionValue != "" {
+		description = &descriptionValue
+	}
+
+	return entities.Recurring{
+		UserId:      userId,
+		Type:        request.Form.Get("type"),
+		Category:    request.Form.Get("category"),
+		AccountId:   accountId,
+		Nominal:     nominal,
+		Currency:    currency,
+		Description: description,
+		Frequency:   request.Form.Get("frequency"),
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Occurrences: occurrences,
+	}, validNominal
+}
+
+// ambil transaksi berulang milik user berdasarkan id di url
+func (controller *RecurringController) findRecurring(writer http.ResponseWriter, request *http.Request, idStr string) (*entities.Recurring, bool) {
+
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err == nil {
+		recurring, err := models.NewRecurringModel(controller.db).FindRecurringById(id, sessionUserId)
+		if err == nil {
+			return recurring, true
+		}
+	}
+
+	session.AddFlash("Transaksi berulang tidak ditemukan", "error")
+	session.Save(request, writer)
+	http.Redirect(writer, request, "/recurring", http.StatusSeeOther)
+	return nil, false
+}
+
+// validasi tanggal pengulangan harus sesuai jadwal seri
+func isRecurrenceDate(recurring *entities.Recurring, date time.Time) bool {
+	dates := helpers.RecurrenceDates(recurring.StartDate, recurring.Frequency, recurring.EndDate, recurring.Occurrences, date)
+	return len(dates) > 0 && dates[len(dates)-1].Format("2006-01-02") == date.Format("2006-01-02")
+}
+
+func (controller *RecurringController) Index(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/recurring/index.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	// tampilkan alert dari session
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		data["success"] = flashes[0]
+	}
+	if flashes := session.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+	}
+	session.Save(request, writer)
+
+	// tampilkan list transaksi berulang beserta jadwal berikutnya
+	sessionUserId := session.Values["ID"].(string)
+	recurrings, err := models.NewRecurringModel(controller.db).FindAllRecurring(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan transaksi berulang, " + err.Error()
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	for i := range recurrings {
+		recurring := &recurrings[i]
+		next := helpers.NextRecurrenceDates(recurring.StartDate, recurring.Frequency, recurring.EndDate, recurring.Occurrences, yesterday, 1)
+		if len(next) > 0 {
+			recurring.NextDate = &next[0]
+		}
+	}
+	data["recurrings"] = recurrings
+
+	renderRecurringTemplate(writer, templateLayout, data)
+}
+
+func (controller *RecurringController) AddRecurring(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/recurring/create.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// untuk mencegah <no value> di awal
+	data["recurring"] = entities.Recurring{Frequency: "monthly", StartDate: time.Now()}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// tampilkan pilihan kategori milik user
+	categoryModel := models.NewCategoryModel(controller.db)
+	categories, err := categoryModel.FindAllCategory(sessionUserId, "")
+	if err != nil {
+		data["error"] = "Gagal menampilkan kategori, " + err.Error()
+	}
+	data["categories"] = categories
+
+	// tampilkan pilihan akun milik user
+	accountModel := models.NewAccountModel(controller.db)
+	accounts, err := accountModel.FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	}
+	data["accounts"] = accounts
+
+	// tampilkan pilihan mata uang
+	data["currencies"] = helpers.Currencies
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
+	if request.Method == http.MethodPost {
+
+		recurring, validNominal := parseRecurringForm(request, sessionUserId)
+
+		// nominal harus bisa dibaca sebagai angka
+		if !validNominal {
+			data["validation"] = map[string]interface{}{"Nominal": invalidMoneyMessage}
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error sesuai ketentuan di Struct
+		if err := helpers.NewValidator(controller.db).Struct(recurring); err != nil {
+			data["validation"] = err
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// kategori harus milik user dan sesuai tipe
+		if valid, err := categoryModel.IsUserCategory(sessionUserId, recurring.Type, recurring.Category); err != nil || !valid {
+			data["validation"] = map[string]interface{}{"Category": "Kategori tidak ditemukan"}
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// akun harus milik user
+		if valid, err := accountModel.IsUserAccount(sessionUserId, recurring.AccountId); err != nil || !valid {
+			data["validation"] = map[string]interface{}{"AccountId": "Akun tidak ditemukan"}
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// insert ke database
+		if err := models.NewRecurringModel(controller.db).AddRecurring(recurring); err != nil {
+			data["error"] = "Gagal menambahkan transaksi berulang, " + err.Error()
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		session.AddFlash("Berhasil menambahkan transaksi berulang", "success")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/recurring", http.StatusSeeOther)
+		return
+	}
+
+	renderRecurringTemplate(writer, templateLayout, data)
+}
+
+func (controller *RecurringController) EditRecurring(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/recurring/edit.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// tampilkan data berdasarkan id
+	oldRecurring, ok := controller.findRecurring(writer, request, request.URL.Query().Get("id"))
+	if !ok {
+		return
+	}
+	data["recurring"] = oldRecurring
+
+	// tampilkan pilihan kategori milik user
+	categoryModel := models.NewCategoryModel(controller.db)
+	categories, err := categoryModel.FindAllCategory(sessionUserId, "")
+	if err != nil {
+		data["error"] = "Gagal menampilkan kategori, " + err.Error()
+	}
+	data["categories"] = categories
+
+	// tampilkan pilihan akun milik user
+	accountModel := models.NewAccountModel(controller.db)
+	accounts, err := accountModel.FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	}
+	data["accounts"] = accounts
+
+	// tampilkan pilihan mata uang
+	data["currencies"] = helpers.Currencies
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
+	if request.Method == http.MethodPost {
+
+		recurring, validNominal := parseRecurringForm(request, sessionUserId)
+		recurring.Id = oldRecurring.Id
+		recurring.Paused = oldRecurring.Paused
+
+		// nominal harus bisa dibaca sebagai angka
+		if !validNominal {
+			data["validation"] = map[string]interface{}{"Nominal": invalidMoneyMessage}
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error sesuai ketentuan di Struct
+		if err := helpers.NewValidator(controller.db).Struct(recurring); err != nil {
+			data["validation"] = err
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// kategori harus milik user dan sesuai tipe
+		if valid, err := categoryModel.IsUserCategory(sessionUserId, recurring.Type, recurring.Category); err != nil || !valid {
+			data["validation"] = map[string]interface{}{"Category": "Kategori tidak ditemukan"}
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// akun harus milik user
+		if valid, err := accountModel.IsUserAccount(sessionUserId, recurring.AccountId); err != nil || !valid {
+			data["validation"] = map[string]interface{}{"AccountId": "Akun tidak ditemukan"}
+			data["recurring"] = recurring
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// update seluruh seri
+		if err := models.NewRecurringModel(controller.db).EditRecurring(recurring); err != nil {
+			data["error"] = "Gagal mengubah transaksi berulang, " + err.Error()
+			data["recurring"] = recurring
+		} else {
+			session.AddFlash("Berhasil mengubah transaksi berulang", "success")
+			session.Save(request, writer)
+			http.Redirect(writer, request, "/recurring", http.StatusSeeOther)
+			return
+		}
+	}
+
+	renderRecurringTemplate(writer, templateLayout, data)
+}
+
+// PauseRecurring menghentikan sementara atau melanjutkan seri
+func (controller *RecurringController) PauseRecurring(writer http.ResponseWriter, request *http.Request) {
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/recurring", http.StatusSeeOther)
+		return
+	}
+
+	request.ParseForm()
+	recurring, ok := controller.findRecurring(writer, request, request.Form.Get("id"))
+	if !ok {
+		return
+	}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	if err := models.NewRecurringModel(controller.db).SetRecurringPaused(*recurring, !recurring.Paused); err != nil {
+		session.AddFlash("Gagal mengubah status transaksi berulang, "+err.Error(), "error")
+	} else if recurring.Paused {
+		session.AddFlash("Transaksi berulang dilanjutkan", "success")
+	} else {
+		session.AddFlash("Transaksi berulang dihentikan sementara", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/recurring", http.StatusSeeOther)
+}
+
+func (controller *RecurringController) DeleteRecurring(writer http.ResponseWriter, request *http.Request) {
+
+	recurring, ok := controller.findRecurring(writer, request, request.URL.Query().Get("id"))
+	if !ok {
+		return
+	}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	if err := models.NewRecurringModel(controller.db).DeleteRecurring(recurring.Id, recurring.UserId); err != nil {
+		session.AddFlash("Gagal menghapus transaksi berulang, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Berhasil menghapus transaksi berulang", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/recurring", http.StatusSeeOther)
+}
+
+// Occurrences menampilkan jadwal pengulangan berikutnya dan riwayat yang sudah dibuat
+func (controller *RecurringController) Occurrences(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/recurring/occurrences.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+
+	// tampilkan alert dari session
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		data["success"] = flashes[0]
+	}
+	if flashes := session.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+	}
+	session.Save(request, writer)
+
+	recurring, ok := controller.findRecurring(writer, request, request.URL.Query().Get("id"))
+	if !ok {
+		return
+	}
+	data["recurring"] = recurring
+
+	occurrences, err := models.NewRecurringModel(controller.db).FindOccurrences(recurring.Id)
+	if err != nil {
+		data["error"] = "Gagal menampilkan jadwal, " + err.Error()
+	}
+
+	// jadwal berikutnya digabung dengan pengulangan yang dilewati/diubah
+	var upcoming []entities.RecurringOccurrence
+	yesterday := time.Now().AddDate(0, 0, -1)
+	for _, date := range helpers.NextRecurrenceDates(recurring.StartDate, recurring.Frequency, recurring.EndDate, recurring.Occurrences, yesterday, 10) {
+		occurrence, ok := occurrences[date.Format("2006-01-02")]
+		if !ok {
+			occurrence = entities.RecurringOccurrence{RecurringId: recurring.Id, Date: date, Status: "scheduled"}
+		}
+		upcoming = append(upcoming, occurrence)
+	}
+	data["upcoming"] = upcoming
+
+	// riwayat pengulangan yang sudah dibuat, terbaru di atas
+	var history []entities.RecurringOccurrence
+	for _, occurrence := range occurrences {
+		if occurrence.RecordId != nil {
+			history = append(history, occurrence)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Date.After(history[j].Date) })
+	data["history"] = history
+
+	renderRecurringTemplate(writer, templateLayout, data)
+}
+
+// SkipOccurrence melewati atau mengembalikan satu pengulangan
+func (controller *RecurringController) SkipOccurrence(writer http.ResponseWriter, request *http.Request) {
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/recurring", http.StatusSeeOther)
+		return
+	}
+
+	request.ParseForm()
+	recurring, ok := controller.findRecurring(writer, request, request.Form.Get("id"))
+	if !ok {
+		return
+	}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	redirectTo := "/recurring/occurrences?id=" + strconv.FormatInt(recurring.Id, 10)
+
+	date, err := time.ParseInLocation("2006-01-02", request.Form.Get("date"), recurring.StartDate.Location())
+	if err != nil || !isRecurrenceDate(recurring, date) {
+		session.AddFlash("Tanggal tidak sesuai jadwal", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, redirectTo, http.StatusSeeOther)
+		return
+	}
+
+	model := models.NewRecurringModel(controller.db)
+	if request.Form.Get("restore") == "true" {
+		err = model.RestoreOccurrence(recurring.Id, date)
+	} else {
+		err = model.SkipOccurrence(recurring.Id, date)
+	}
+
+	if err != nil {
+		session.AddFlash("Gagal mengubah jadwal, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Berhasil mengubah jadwal tanggal "+date.Format("02 January 2006"), "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, redirectTo, http.StatusSeeOther)
+}
+
+// EditOccurrence mengubah nominal dan keterangan satu pengulangan tanpa mengubah seri
+func (controller *RecurringController) EditOccurrence(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/recurring/occurrence.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	recurring, ok := controller.findRecurring(writer, request, request.URL.Query().Get("id"))
+	if !ok {
+		return
+	}
+	data["recurring"] = recurring
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	redirectTo := "/recurring/occurrences?id=" + strconv.FormatInt(recurring.Id, 10)
+
+	date, err := time.ParseInLocation("2006-01-02", request.URL.Query().Get("date"), recurring.StartDate.Location())
+	if err != nil || !isRecurrenceDate(recurring, date) {
+		session.AddFlash("Tanggal tidak sesuai jadwal", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, redirectTo, http.StatusSeeOther)
+		return
+	}
+
+	model := models.NewRecurringModel(controller.db)
+	occurrences, _ := model.FindOccurrences(recurring.Id)
+	occurrence, ok := occurrences[date.Format("2006-01-02")]
+	if !ok {
+		occurrence = entities.RecurringOccurrence{RecurringId: recurring.Id, Date: date, Status: "scheduled"}
+	}
+
+	// pengulangan yang sudah dibuat diubah lewat form edit catatan keuangan
+	if occurrence.RecordId != nil {
+		http.Redirect(writer, request, "/financial/edit_financial_record?id="+strconv.FormatInt(*occurrence.RecordId, 10), http.StatusSeeOther)
+		return
+	}
+
+	if occurrence.Nominal == nil {
+		occurrence.Nominal = &recurring.Nominal
+	}
+	if occurrence.Description == nil {
+		occurrence.Description = recurring.Description
+	}
+	data["occurrence"] = occurrence
+
+	if request.Method == http.MethodPost {
+
+		request.ParseForm()
+
+		nominal, validNominal := parseMoneyField(request, "nominal")
+		if !validNominal {
+			data["validation"] = map[string]interface{}{"Nominal": invalidMoneyMessage}
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+		if nominal <= 0 {
+			data["validation"] = map[string]interface{}{"Nominal": "Nominal tidak boleh kosong"}
+			renderRecurringTemplate(writer, templateLayout, data)
+			return
+		}
+
+		var description *string
+		if descriptionValue := request.Form.Get("description"); descriptionValue != "" {
+			description = &descriptionValue
+		}
+
+		occurrence.Nominal = &nominal
+		occurrence.Description = description
+
+		if err := model.OverrideOccurrence(occurrence); err != nil {
+			data["error"] = "Gagal mengubah jadwal, " + err.Error()
+		} else {
+			session.AddFlash("Berhasil mengubah jadwal tanggal "+date.Format("02 January 2006"), "success")
+			session.Save(request, writer)
+			http.Redirect(writer, request, redirectTo, http.StatusSeeOther)
+			return
+		}
+	}
+
+	renderRecurringTemplate(writer, templateLayout, data)
+}
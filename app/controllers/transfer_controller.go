@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"database/sql"
+	"financial-record/config"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/views"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TransferController struct {
+	db *sql.DB
+}
+
+func NewTransferController(db *sql.DB) *TransferController {
+	return &TransferController{
+		db: db,
+	}
+}
+
+// render template transfer yang memakai format mata uang
+func renderTransferTemplate(writer http.ResponseWriter, templateLayout string, data map[string]interface{}) {
+
+	funcMap := template.FuncMap{
+		"formatMoney": views.FormatMoney,
+		"moneyInput":  views.MoneyInput,
+	}
+
+	template, _ := template.New(filepath.Base(templateLayout)).Funcs(funcMap).ParseFiles(templateLayout)
+	template.Execute(writer, data)
+}
+
+// ambil data transfer dari form tambah/edit, false jika nominal tidak bisa dibaca
+func parseTransferForm(request *http.Request, userId string) (entities.Transfer, bool) {
+
+	request.ParseForm()
+
+	date, _ := time.Parse("2006-01-02", request.Form.Get("date"))
+	fromAccountId, _ := strconv.ParseInt(request.Form.Get("from_account_id"), 10, 64)
+	toAccountId, _ := strconv.ParseInt(request.Form.Get("to_account_id"), 10, 64)
+	nominal, validNominal := parseMoneyField(request, "nominal")
+	currency := strings.ToUpper(request.Form.Get("currency"))
+
+	var description *string
+	if descriptionValue := request.Form.Get("description"); descriptionValue != "" {
+		description = &descriptionValue
+	}
+
+	return entities.Transfer{
+		UserId:        userId,
+		Date:          date,
+		FromAccountId: fromAccountId,
+		ToAccountId:   toAccountId,
+		Nominal:       nominal,
+		Currency:      currency,
+		Description:   description,
+	}, validNominal
+}
+
+// validasi transfer, akun asal dan tujuan harus milik user dan berbeda
+func (controller *TransferController) validateTransfer(transfer entities.Transfer) interface{} {
+
+	if err := helpers.NewValidator(controller.db).Struct(transfer); err != nil {
+		return err
+	}
+
+	if transfer.FromAccountId == transfer.ToAccountId {
+		return map[string]interface{}{"ToAccountId": "Akun tujuan harus berbeda dengan akun asal"}
+	}
+
+	accountModel := models.NewAccountModel(controller.db)
+	if valid, err := accountModel.IsUserAccount(transfer.UserId, transfer.FromAccountId); err != nil || !valid {
+		return map[string]interface{}{"FromAccountId": "Akun tidak ditemukan"}
+	}
+	if valid, err := accountModel.IsUserAccount(transfer.UserId, transfer.ToAccountId); err != nil || !valid {
+		return map[string]interface{}{"ToAccountId": "Akun tidak ditemukan"}
+	}
+
+	return nil
+}
+
+func (controller *TransferController) AddTransfer(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/transfer/create.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// untuk mencegah <no value> di awal
+	data["transfer"] = entities.Transfer{Date: time.Now()}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// tampilkan pilihan akun milik user
+	accounts, err := models.NewAccountModel(controller.db).FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	}
+	data["accounts"] = accounts
+
+	// tampilkan pilihan mata uang, saldo akun dalam mata uang utama
+	data["currencies"] = helpers.Currencies
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
+	if request.Method == http.MethodPost {
+
+		transfer, validNominal := parseTransferForm(request, sessionUserId)
+
+		// nominal harus bisa dibaca sebagai angka
+		if !validNominal {
+			data["validation"] = map[string]interface{}{"Nominal": invalidMoneyMessage}
+			data["transfer"] = transfer
+			renderTransferTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error validasi
+		if err := controller.validateTransfer(transfer); err != nil {
+			data["validation"] = err
+			data["transfer"] = transfer
+			renderTransferTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// insert transfer beserta kedua catatannya
+		if err := models.NewTransferModel(controller.db).AddTransfer(transfer); err != nil {
+			data["error"] = "Gagal menyimpan transfer, " + err.Error()
+			data["transfer"] = transfer
+			renderTransferTemplate(writer, templateLayout, data)
+			return
+		}
+
+		session.AddFlash("Berhasil menyimpan transfer", "success")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	renderTransferTemplate(writer, templateLayout, data)
+}
+
+func (controller *TransferController) EditTransfer(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/transfer/edit.html"
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	// ambil id dari url
+	idStr := request.URL.Query().Get("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil transfer", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	// tampilkan transfer berdasarkan id
+	model := models.NewTransferModel(controller.db)
+	oldTransfer, err := model.FindTransferById(id, sessionUserId)
+	if err != nil {
+		session.AddFlash("Transfer tidak ditemukan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+	data["transfer"] = oldTransfer
+
+	// tampilkan pilihan akun milik user
+	accounts, err := models.NewAccountModel(controller.db).FindAllAccount(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan akun, " + err.Error()
+	}
+	data["accounts"] = accounts
+
+	// tampilkan pilihan mata uang, saldo akun dalam mata uang utama
+	data["currencies"] = helpers.Currencies
+	data["baseCurrency"] = userBaseCurrency(controller.db, sessionUserId)
+
+	if request.Method == http.MethodPost {
+
+		transfer, validNominal := parseTransferForm(request, sessionUserId)
+		transfer.Id = oldTransfer.Id
+
+		// nominal harus bisa dibaca sebagai angka
+		if !validNominal {
+			data["validation"] = map[string]interface{}{"Nominal": invalidMoneyMessage}
+			data["transfer"] = transfer
+			renderTransferTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// tampilkan error validasi
+		if err := controller.validateTransfer(transfer); err != nil {
+			data["validation"] = err
+			data["transfer"] = transfer
+			renderTransferTemplate(writer, templateLayout, data)
+			return
+		}
+
+		// update transfer beserta kedua catatannya
+		if err := model.EditTransfer(transfer); err != nil {
+			data["error"] = "Gagal mengubah transfer, " + err.Error()
+			data["transfer"] = transfer
+		} else {
+			session.AddFlash("Berhasil mengubah transfer", "success")
+			session.Save(request, writer)
+			http.Redirect(writer, request, "/home", http.StatusSeeOther)
+			return
+		}
+	}
+
+	renderTransferTemplate(writer, templateLayout, data)
+}
+
+// DeleteTransfer memindahkan kedua catatan transfer ke sampah, hanya lewat POST supaya
+// tidak terhapus dari link biasa
+func (controller *TransferController) DeleteTransfer(writer http.ResponseWriter, request *http.Request) {
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	idStr := request.FormValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if idStr == "" || err != nil {
+		session.AddFlash("Gagal mengambil transfer", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	model := models.NewTransferModel(controller.db)
+	if _, err := model.FindTransferById(id, sessionUserId); err != nil {
+		session.AddFlash("Transfer tidak ditemukan", "error")
+		session.Save(request, writer)
+		http.Redirect(writer, request, "/home", http.StatusSeeOther)
+		return
+	}
+
+	if err := model.DeleteTransfer(id, sessionUserId); err != nil {
+		session.AddFlash("Gagal menghapus transfer, "+err.Error(), "error")
+	} else {
+		session.AddFlash("Transfer dipindahkan ke sampah", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/home", http.StatusSeeOther)
+}
@@ -7,11 +7,11 @@ import (
 	"financial-record/helpers"
 	"financial-record/models"
 	"financial-record/views"
-	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -43,17 +43,15 @@ func (controller UserController) Profile(writer http.ResponseWriter, request *ht
 		data["success"] = flashes[0]
 		sessions.Save(request, writer)
 	}
+	if flashes := sessions.Flashes("error"); len(flashes) > 0 {
+		data["error"] = flashes[0]
+		sessions.Save(request, writer)
+	}
 
 	// ambil user id dari session
 	sessionUserId := sessions.Values["ID"].(string)
 
-	// tampilkan data user berdasarkan id
-	user, err := models.NewUserModel(controller.db).FindUserById(sessionUserId)
-	if err != nil {
-		data["error"] = "User tidak ditemukan, " + err.Error()
-	} else {
-		data["user"] = user
-	}
+	controller.profileData(sessionUserId, data)
 
 	if request.Method == http.MethodPost {
 
@@ -61,9 +59,10 @@ func (controller UserController) Profile(writer http.ResponseWriter, request *ht
 
 		password := request.Form.Get("password")
 		user := entities.User{
-			Id:    sessionUserId,
-			Name:  request.Form.Get("name"),
-			Email: request.Form.Get("email"),
+			Id:           sessionUserId,
+			Name:         request.Form.Get("name"),
+			Email:        request.Form.Get("email"),
+			BaseCurrency: strings.ToUpper(request.Form.Get("base_currency")),
 		}
 
 		// tampilkan error sesuai ketentuan di Struct
@@ -80,6 +79,16 @@ func (controller UserController) Profile(writer http.ResponseWriter, request *ht
 			user.Password = string(hashPassword)
 		}
 
+		// ambil foto sebelumnya jika ada
+		oldPhoto, err := models.NewUserModel(controller.db).GetUserPhotoById(sessionUserId)
+		if err != nil {
+			data["error"] = "Gagal mengambil foto, " + err.Error()
+			data["user"] = user
+			views.RenderTemplate(writer, templateLayout, data)
+			return
+		}
+
+		var newPhoto string
 		if file, handler, err := request.FormFile("photo"); err == nil {
 			defer file.Close() // jeda proses sampai dipilih filenya
 
@@ -100,64 +109,53 @@ func (controller UserController) Profile(writer http.ResponseWriter, request *ht
 				return
 			}
 
-			// ambil foto sebelumnya jika ada
-			oldPhoto, err := models.NewUserModel(controller.db).GetUserPhotoById(sessionUserId)
+			// foto diputar sesuai EXIF, diperkecil dan disimpan ulang tanpa metadata (termasuk lokasi GPS)
+			content, err := io.ReadAll(io.LimitReader(file, 5*1024*1024))
 			if err != nil {
-				data["error"] = "Gagal mengambil foto, " + err.Error()
+				data["error"] = "Gagal membaca foto, " + err.Error()
 				data["user"] = user
 				views.RenderTemplate(writer, templateLayout, data)
 				return
 			}
-
-			// atur foto baru ke folder public/user_photo
-			filename := fmt.Sprintf("profile_%s%s", time.Now().Format("2006-01-02_15-04-05"), ext)
-			path := filepath.Join("public/user_photo", filename)
-
-			// hapus foto sebelumnya jika ada
-			if oldPhoto != nil && *oldPhoto != "" {
-				oldPath := filepath.Join("public/user_photo", *oldPhoto)
-				if err := os.Remove(oldPath); err != nil {
-					data["error"] = "Gagal menghapus foto sebelumnya, " + err.Error()
-					data["user"] = user
-					views.RenderTemplate(writer, templateLayout, data)
-					return
-				}
-			}
-
-			// buat file/foto baru
-			out, err := os.Create(path)
+			photo, contentType, err := helpers.NormalizeImage(content, helpers.ProfilePhotoSize)
 			if err != nil {
-				data["error"] = "Gagal membuat foto baru, " + err.Error()
+				data["error"] = "File tidak didukung, " + err.Error()
 				data["user"] = user
 				views.RenderTemplate(writer, templateLayout, data)
 				return
 			}
-			defer out.Close()
+			ext = helpers.ImageExtension(contentType)
 
-			// simpan file baru
-			_, errCopy := io.Copy(out, file)
-			if errCopy != nil {
-				data["error"] = "Gagal menyimpan foto baru, " + errCopy.Error()
+			// simpan foto baru dengan nama acak, foto lama baru dihapus setelah profile berhasil diubah
+			filename, err := helpers.SaveProfilePhoto(photo, ext)
+			if err != nil {
+				data["error"] = "Gagal menyimpan foto baru, " + err.Error()
 				data["user"] = user
 				views.RenderTemplate(writer, templateLayout, data)
 				return
 			}
+			newPhoto = filename
 
 			// set ke struct
 			user.Photo = &filename
 		} else {
 			// kalau user tidak ganti foto, pakai foto lama
-			oldPhoto, err := models.NewUserModel(controller.db).GetUserPhotoById(sessionUserId)
-			if err == nil {
-				user.Photo = oldPhoto
-			}
+			user.Photo = oldPhoto
 		}
 
-		// update profile
-		err := models.NewUserModel(controller.db).UpdateProfile(user)
+		// update profile, foto baru dihapus lagi jika gagal
+		err = models.NewUserModel(controller.db).UpdateProfile(user)
 		if err != nil {
+			if newPhoto != "" {
+				helpers.DeleteProfilePhoto(newPhoto)
+			}
 			data["error"] = "Gagal mengubah data profile, " + err.Error()
 		} else {
+			if newPhoto != "" && oldPhoto != nil && *oldPhoto != "" {
+				if err := helpers.DeleteProfilePhoto(*oldPhoto); err != nil {
+					log.Printf("Gagal menghapus foto profil lama %s: %v\n", *oldPhoto, err)
+				}
+			}
 			sessions.AddFlash("Berhasil mengubah data profile", "success")
 			sessions.Save(request, writer)
 			http.Redirect(writer, request, "/profile", http.StatusSeeOther)
@@ -167,3 +165,134 @@ func (controller UserController) Profile(writer http.ResponseWriter, request *ht
 
 	views.RenderTemplate(writer, templateLayout, data)
 }
+
+// masa berlaku token API dalam hari, 0 berarti tanpa batas
+var apiTokenExpiryOptions = []int{30, 90, 365, 0}
+
+// data yang dibutuhkan halaman profil
+func (controller UserController) profileData(sessionUserId string, data map[string]interface{}) {
+
+	// tampilkan pilihan mata uang utama
+	data["currencies"] = helpers.Currencies
+
+	// tampilkan data user berdasarkan id
+	user, err := models.NewUserModel(controller.db).FindUserById(sessionUserId)
+	if err != nil {
+		data["error"] = "User tidak ditemukan, " + err.Error()
+	} else {
+		data["user"] = user
+	}
+
+	// tampilkan token API milik user
+	tokens, err := models.NewAPITokenModel(controller.db).FindAllAPIToken(sessionUserId)
+	if err != nil {
+		data["error"] = "Gagal menampilkan token API, " + err.Error()
+	}
+	data["tokens"] = tokens
+	data["now"] = time.Now()
+	data["tokenExpiryOptions"] = apiTokenExpiryOptions
+	if _, ok := data["tokenInput"]; !ok {
+		data["tokenInput"] = map[string]interface{}{"Scope": entities.APITokenScopeRead, "ExpiresIn": 90}
+	}
+}
+
+func (controller UserController) AddAPIToken(writer http.ResponseWriter, request *http.Request) {
+
+	templateLayout := "views/user/profile.html"
+
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	// untuk mengirim data ke html
+	var data = make(map[string]interface{})
+
+	// panggil session
+	sessions, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId := sessions.Values["ID"].(string)
+
+	request.ParseForm()
+
+	token := entities.APIToken{
+		UserId: sessionUserId,
+		Name:   strings.TrimSpace(request.Form.Get("token_name")),
+		Scope:  request.Form.Get("token_scope"),
+	}
+
+	// masa berlaku harus salah satu pilihan
+	expiresIn, err := strconv.Atoi(request.Form.Get("token_expires_in"))
+	validExpiry := false
+	for _, option := range apiTokenExpiryOptions {
+		if err == nil && expiresIn == option {
+			validExpiry = true
+		}
+	}
+	if validExpiry && expiresIn > 0 {
+		expiresAt := time.Now().AddDate(0, 0, expiresIn)
+		token.ExpiresAt = &expiresAt
+	}
+
+	data["tokenInput"] = map[string]interface{}{"Name": token.Name, "Scope": token.Scope, "ExpiresIn": expiresIn}
+
+	// tampilkan error sesuai ketentuan di Struct
+	if err := helpers.NewValidator(controller.db).Struct(token); err != nil || !validExpiry {
+		validation, _ := err.(map[string]interface{})
+		if validation == nil {
+			validation = make(map[string]interface{})
+		}
+		if !validExpiry {
+			validation["ExpiresIn"] = "Masa berlaku tidak valid"
+		}
+		data["tokenValidation"] = validation
+		controller.profileData(sessionUserId, data)
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	plain, hash, prefix, err := helpers.GenerateAPIToken()
+	if err == nil {
+		token.Prefix = prefix
+		err = models.NewAPITokenModel(controller.db).AddAPIToken(token, hash)
+	}
+	if err != nil {
+		data["error"] = "Gagal membuat token API, " + err.Error()
+		controller.profileData(sessionUserId, data)
+		views.RenderTemplate(writer, templateLayout, data)
+		return
+	}
+
+	// token asli hanya ditampilkan sekali, tidak lewat flash supaya tidak tersimpan di cookie
+	delete(data, "tokenInput")
+	data["newToken"] = plain
+	data["success"] = "Berhasil membuat token API " + token.Name
+	controller.profileData(sessionUserId, data)
+	views.RenderTemplate(writer, templateLayout, data)
+}
+
+func (controller UserController) DeleteAPIToken(writer http.ResponseWriter, request *http.Request) {
+
+	// hanya lewat form POST supaya link biasa tidak bisa mencabut token
+	if request.Method != http.MethodPost {
+		http.Redirect(writer, request, "/profile", http.StatusSeeOther)
+		return
+	}
+
+	// panggil session
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	sessionUserId, _ := session.Values["ID"].(string)
+
+	id, err := strconv.ParseInt(request.FormValue("id"), 10, 64)
+	if err == nil {
+		err = models.NewAPITokenModel(controller.db).DeleteAPIToken(id, sessionUserId)
+	}
+
+	if err != nil {
+		session.AddFlash("Token API tidak ditemukan", "error")
+	} else {
+		session.AddFlash("Berhasil mencabut token API", "success")
+	}
+	session.Save(request, writer)
+
+	http.Redirect(writer, request, "/profile", http.StatusSeeOther)
+}
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+type Account struct {
+	Id             int64
+	UserId         string
+	Name           string `validate:"required,max=50" label:"Nama"`
+	Kind           string `validate:"required,oneof=tunai bank ewallet" label:"Jenis"`
+	OpeningBalance int64  `validate:"numeric" label:"Saldo Awal"`
+	Balance        int64
+	UpdatedAt      time.Time
+	CreatedAt      time.Time
+}
@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// scope token API, read hanya boleh membaca data, write boleh membaca dan mengubah
+const (
+	APITokenScopeRead  = "read"
+	APITokenScopeWrite = "write"
+)
+
+type APIToken struct {
+	Id         int64
+	UserId     string
+	Name       string `validate:"required,max=50" label:"Nama"`
+	Scope      string `validate:"required,oneof=read write" label:"Akses"`
+	Prefix     string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// Expired bernilai true jika token sudah melewati masa berlaku
+func (token APIToken) Expired(now time.Time) bool {
+	return token.ExpiresAt != nil && !now.Before(*token.ExpiresAt)
+}
+
+// AllowsWrite bernilai true jika token boleh mengubah data
+func (token APIToken) AllowsWrite() bool {
+	return token.Scope == APITokenScopeWrite
+}
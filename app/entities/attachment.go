@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// Attachment adalah file lampiran catatan keuangan (foto struk, invoice PDF) yang disimpan di storage,
+// database hanya menyimpan key file dan tipe yang dibaca dari isinya. Lampiran gambar punya
+// thumbnail JPEG kecil untuk list dan laporan PDF.
+type Attachment struct {
+	Id           int64
+	UserId       string
+	RecordId     int64
+	StorageKey   string
+	ThumbnailKey *string
+	Filename     string
+	ContentType  string
+	Size         int64
+	CreatedAt    time.Time
+}
+
+// IsImage bernilai true jika lampiran bisa ditampilkan sebagai gambar
+func (attachment Attachment) IsImage() bool {
+	return attachment.ContentType != "application/pdf"
+}
+
+// LegacyAttachment adalah lampiran lama yang masih tersimpan sebagai base64 di kolom record.attachment
+type LegacyAttachment struct {
+	Id         int64
+	UserId     string
+	Attachment string
+}
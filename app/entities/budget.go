@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+type Budget struct {
+	Id         int64
+	UserId     string
+	CategoryId int64 `validate:"required" label:"Kategori"`
+	Year       int   `validate:"required"`
+	Month      int   `validate:"required"`
+	Amount     int64 `validate:"required,gt=0" label:"Nominal"`
+	UpdatedAt  time.Time
+	CreatedAt  time.Time
+}
+
+type BudgetUsage struct {
+	Id            int64
+	CategoryId    int64
+	CategoryName  string
+	CategoryColor string
+	CategoryIcon  string
+	Amount        int64
+	Used          int64
+	Percent       int
+	Over          bool
+}
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+type Category struct {
+	Id        int64
+	UserId    string
+	Type      string `validate:"required,oneof=pemasukan pengeluaran" label:"Tipe"`
+	Name      string `validate:"required,max=20" label:"Nama"`
+	Color     string `validate:"required,hexcolor" label:"Warna"`
+	Icon      string `label:"Ikon"`
+	UpdatedAt time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+type ExchangeRate struct {
+	Id           int64
+	UserId       string
+	Currency     string    `validate:"required,iso4217" label:"Mata Uang"`
+	BaseCurrency string    `validate:"required,iso4217" label:"Mata Uang Utama"`
+	Date         time.Time `validate:"required" label:"Tanggal"`
+	Rate         float64   `validate:"required,gt=0" label:"Kurs"`
+	CreatedAt    time.Time
+}
@@ -3,25 +3,76 @@ package entities
 import "time"
 
 type AddFinancial struct {
-	Id          int16
+	Id          int64
 	UserId      string
 	Date        time.Time `validate:"required" label:"Tanggal"`
 	Type        string    `validate:"required"`
-	Nominal     int64     `validate:"required,numeric"`
+	Nominal     int64     `validate:"required,gt=0"`
+	Currency    string    `validate:"required,currency" label:"Mata Uang"`
 	Category    string    `validate:"required" label:"Kategori"`
+	AccountId   int64     `validate:"required" label:"Akun"`
 	Description *string
-	Attachment  *string
+	ExternalId  *string
 }
 
 type Financial struct {
-	Id          int16
-	UserId      string
-	Date        time.Time
-	Type        string
-	Nominal     int64
-	Category    string
-	Description *string
-	Attachment  *string
-	UpdatedAt   time.Time
-	CreatedAt   time.Time
+	Id             int64
+	UserId         string
+	Date           time.Time
+	Type           string
+	Nominal        int64
+	Currency       string
+	BaseNominal    *int64
+	Category       string
+	CategoryColor  string
+	CategoryIcon   string
+	AccountId      int64
+	AccountName    string
+	TransferId     *int64
+	Description    *string
+	Attachments    []Attachment
+	UpdatedAt      time.Time
+	CreatedAt      time.Time
+	DeletedAt      *time.Time
+	RunningBalance *int64
+}
+
+// CategoryTotal berisi jumlah catatan dan total nominal per kategori dalam mata uang utama
+type CategoryTotal struct {
+	Type     string
+	Category string
+	Count    int
+	Total    int64
+}
+
+// FinancialFilter berisi filter yang dipakai di list dan total keuangan
+type FinancialFilter struct {
+	UserId          string
+	StartDate       time.Time
+	EndDate         time.Time
+	PemasukanOnly   bool
+	PengeluaranOnly bool
+	AccountId       int64
+}
+
+// FinancialPage berisi urutan dan posisi halaman list keuangan (keyset pagination)
+type FinancialPage struct {
+	Sort     string
+	Desc     bool
+	Limit    int
+	Cursor   string
+	Backward bool
+}
+
+// FinancialSearch berisi kriteria pencarian catatan keuangan di semua bulan,
+// kriteria yang kosong tidak dipakai
+type FinancialSearch struct {
+	UserId     string
+	Terms      []string
+	Nominals   []int64
+	NominalMin *int64
+	NominalMax *int64
+	StartDate  *time.Time
+	EndDate    *time.Time
+	Limit      int
 }
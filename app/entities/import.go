@@ -0,0 +1,27 @@
+package entities
+
+// ImportMapping berisi nomor kolom CSV (mulai dari 0) untuk setiap data catatan keuangan,
+// -1 berarti kolom tidak dipakai
+type ImportMapping struct {
+	Date        int
+	Amount      int
+	Description int
+	Type        int
+	Category    int
+	DateFormat  string
+	HasHeader   bool
+}
+
+// ImportRow adalah satu baris CSV yang sudah dibaca menjadi catatan keuangan
+type ImportRow struct {
+	Line      int
+	Columns   []string
+	Financial AddFinancial
+	Errors    []string
+	Duplicate bool
+}
+
+// Valid bernilai true jika baris bisa diimport
+func (row ImportRow) Valid() bool {
+	return len(row.Errors) == 0
+}
@@ -0,0 +1,170 @@
+package entities
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMoney dikembalikan ketika input nominal tidak bisa dibaca
+var ErrInvalidMoney = errors.New("format nominal tidak valid")
+
+// semua mata uang yang didukung (helpers.Currencies) memakai 2 angka desimal, nominal disimpan dalam sen
+const minorUnitScale = 100
+
+// Money menyimpan nominal dalam satuan terkecil (sen) beserta mata uangnya supaya
+// perhitungan tidak memakai float. Kolom nominal di database juga disimpan dalam sen.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// ParseMoney membaca input nominal dari form. Format Indonesia dipakai sebagai acuan
+// ("1.500.000,50"), tetapi "1500000", "1500000.5" dan "1,500,000.50" juga diterima.
+func ParseMoney(input string, currency string) (Money, error) {
+
+	value := strings.TrimSpace(input)
+	value = strings.TrimPrefix(value, "Rp.")
+	value = strings.TrimPrefix(value, "Rp")
+	value = strings.ReplaceAll(value, " ", "")
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	if value == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	// tentukan pemisah desimal
+	decimalSeparator := ""
+	lastDot := strings.LastIndex(value, ".")
+	lastComma := strings.LastIndex(value, ",")
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		// pemisah yang muncul terakhir adalah desimal
+		if lastComma > lastDot {
+			decimalSeparator = ","
+		} else {
+			decimalSeparator = "."
+		}
+	case lastComma >= 0:
+		if strings.Count(value, ",") == 1 && len(value)-lastComma-1 != 3 {
+			decimalSeparator = ","
+		}
+	case lastDot >= 0:
+		if strings.Count(value, ".") == 1 && len(value)-lastDot-1 != 3 {
+			decimalSeparator = "."
+		}
+	}
+
+	whole, fraction := value, ""
+	if decimalSeparator != "" {
+		index := strings.LastIndex(value, decimalSeparator)
+		whole, fraction = value[:index], value[index+1:]
+	}
+	whole = strings.NewReplacer(".", "", ",", "").Replace(whole)
+
+	return newMoney(whole, fraction, negative, currency)
+}
+
+// ParseDecimalMoney membaca nominal dengan titik sebagai pemisah desimal dan tanpa pemisah
+// ribuan, contoh "1500000.50". Dipakai untuk angka dari JSON yang tidak ambigu.
+func ParseDecimalMoney(input string, currency string) (Money, error) {
+
+	value := strings.TrimSpace(input)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	if value == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	return newMoney(whole, fraction, negative, currency)
+}
+
+func newMoney(whole, fraction string, negative bool, currency string) (Money, error) {
+
+	if whole == "" {
+		whole = "0"
+	}
+	if len(fraction) > 2 || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, ErrInvalidMoney
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (math.MaxInt64-99)/minorUnitScale {
+		return Money{}, ErrInvalidMoney
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+
+	amount := units*minorUnitScale + cents
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func isDigits(value string) bool {
+	for _, char := range value {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Input menampilkan nominal tanpa simbol mata uang, dipakai sebagai value di form
+func (money Money) Input() string {
+
+	amount := money.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	str := strconv.FormatInt(amount/minorUnitScale, 10)
+	var result []string
+	for len(str) > 3 {
+		result = append([]string{str[len(str)-3:]}, result...)
+		str = str[:len(str)-3]
+	}
+	result = append([]string{str}, result...)
+
+	return sign + strings.Join(result, ".") + "," + strconv.FormatInt(amount%minorUnitScale+minorUnitScale, 10)[1:]
+}
+
+// Format menampilkan nominal lengkap dengan mata uang, contoh "Rp. 1.500.000,50" atau "-USD 12,00"
+func (money Money) Format() string {
+
+	symbol := money.Currency + " "
+	if money.Currency == "" || money.Currency == "IDR" {
+		symbol = "Rp. "
+	}
+
+	if money.Amount < 0 {
+		return "-" + symbol + Money{Amount: -money.Amount}.Input()
+	}
+	return symbol + money.Input()
+}
+
+// Decimal menampilkan nominal dengan titik desimal tanpa pemisah ribuan, contoh "1500000.50"
+func (money Money) Decimal() string {
+
+	amount := money.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return sign + strconv.FormatInt(amount/minorUnitScale, 10) + "." + strconv.FormatInt(amount%minorUnitScale+minorUnitScale, 10)[1:]
+}
+
+func (money Money) String() string {
+	return money.Format()
+}
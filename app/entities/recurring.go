@@ -0,0 +1,31 @@
+package entities
+
+import "time"
+
+type Recurring struct {
+	Id          int64
+	UserId      string
+	Type        string `validate:"required"`
+	Category    string `validate:"required" label:"Kategori"`
+	AccountId   int64  `validate:"required" label:"Akun"`
+	Nominal     int64  `validate:"required,gt=0"`
+	Currency    string `validate:"required,currency" label:"Mata Uang"`
+	Description *string
+	Frequency   string    `validate:"required,oneof=daily weekly monthly yearly" label:"Frekuensi"`
+	StartDate   time.Time `validate:"required" label:"Tanggal Mulai"`
+	EndDate     *time.Time
+	Occurrences *int `validate:"omitempty,gt=0" label:"Jumlah Pengulangan"`
+	Paused      bool
+	NextDate    *time.Time
+	UpdatedAt   time.Time
+	CreatedAt   time.Time
+}
+
+type RecurringOccurrence struct {
+	RecurringId int64
+	Date        time.Time
+	Status      string
+	RecordId    *int64
+	Nominal     *int64
+	Description *string
+}
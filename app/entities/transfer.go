@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+type Transfer struct {
+	Id              int64
+	UserId          string
+	Date            time.Time `validate:"required" label:"Tanggal"`
+	FromAccountId   int64     `validate:"required" label:"Dari Akun"`
+	ToAccountId     int64     `validate:"required" label:"Ke Akun"`
+	Nominal         int64     `validate:"required,gt=0" label:"Nominal"`
+	Currency        string    `validate:"required,currency" label:"Mata Uang"`
+	Description     *string
+	FromAccountName string
+	ToAccountName   string
+	UpdatedAt       time.Time
+	CreatedAt       time.Time
+}
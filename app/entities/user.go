@@ -1,9 +1,10 @@
 package entities
 
 type User struct {
-	Id       string
-	Name     string `validate:"required" label:"Nama"`
-	Email    string `validate:"required,email"`
-	Password string `validate:"omitempty,min=6"`
-	Photo    *string
+	Id           string
+	Name         string `validate:"required" label:"Nama"`
+	Email        string `validate:"required,email"`
+	Password     string `validate:"omitempty,min=6"`
+	Photo        *string
+	BaseCurrency string `validate:"required,currency" label:"Mata Uang Utama"`
 }
@@ -23,19 +23,188 @@ SET time_zone = "+00:00";
 
 -- --------------------------------------------------------
 
+--
+-- Struktur dari tabel `api_tokens`
+--
+
+CREATE TABLE `api_tokens` (
+  `id` bigint NOT NULL,
+  `user_id` varchar(36) NOT NULL,
+  `name` varchar(50) NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `token_prefix` varchar(16) NOT NULL,
+  `scope` varchar(10) NOT NULL DEFAULT 'read',
+  `expires_at` datetime DEFAULT NULL,
+  `last_used_at` datetime DEFAULT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `accounts`
+--
+
+CREATE TABLE `accounts` (
+  `id` bigint NOT NULL,
+  `user_id` varchar(36) NOT NULL,
+  `name` varchar(50) NOT NULL,
+  `kind` varchar(20) NOT NULL,
+  `opening_balance` bigint NOT NULL DEFAULT '0',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `attachments`
+--
+
+CREATE TABLE `attachments` (
+  `id` bigint NOT NULL,
+  `user_id` varchar(36) NOT NULL,
+  `record_id` bigint NOT NULL,
+  `storage_key` varchar(255) NOT NULL,
+  `thumbnail_key` varchar(255) DEFAULT NULL,
+  `filename` varchar(255) NOT NULL,
+  `content_type` varchar(100) NOT NULL,
+  `size` bigint NOT NULL DEFAULT '0',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `budgets`
+--
+
+CREATE TABLE `budgets` (
+  `id` bigint NOT NULL,
+  `user_id` varchar(36) NOT NULL,
+  `category_id` bigint NOT NULL,
+  `year` smallint NOT NULL,
+  `month` tinyint NOT NULL,
+  `amount` bigint NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `categories`
+--
+
+CREATE TABLE `categories` (
+  `id` bigint NOT NULL,
+  `user_id` varchar(36) NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `name` varchar(20) NOT NULL,
+  `color` varchar(7) NOT NULL DEFAULT '#6c757d',
+  `icon` varchar(50) NOT NULL DEFAULT '',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `exchange_rates`
+--
+
+CREATE TABLE `exchange_rates` (
+  `id` bigint NOT NULL,
+  `user_id` varchar(36) NOT NULL,
+  `currency` char(3) NOT NULL,
+  `base_currency` char(3) NOT NULL,
+  `rate_date` date NOT NULL,
+  `rate` decimal(20,8) NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
 --
 -- Struktur dari tabel `record`
 --
 
 CREATE TABLE `record` (
-  `id` int NOT NULL,
+  `id` bigint NOT NULL,
   `user_id` varchar(36) NOT NULL,
   `date` date NOT NULL,
   `type` varchar(20) NOT NULL,
   `category` varchar(20) NOT NULL,
-  `nominal` int NOT NULL,
+  `account_id` bigint DEFAULT NULL,
+  `transfer_id` bigint DEFAULT NULL,
+  `nominal` bigint NOT NULL,
+  `currency` char(3) NOT NULL DEFAULT 'IDR',
+  `description` text,
+  `external_id` varchar(255) DEFAULT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` timestamp NULL DEFAULT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `recurring`
+--
+
+CREATE TABLE `recurring` (
+  `id` bigint NOT NULL,
+  `user_id` varchar(36) NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `category` varchar(20) NOT NULL,
+  `account_id` bigint DEFAULT NULL,
+  `nominal` bigint NOT NULL,
+  `currency` char(3) NOT NULL DEFAULT 'IDR',
+  `description` text,
+  `frequency` varchar(10) NOT NULL,
+  `start_date` date NOT NULL,
+  `end_date` date DEFAULT NULL,
+  `occurrences` int DEFAULT NULL,
+  `paused` tinyint(1) NOT NULL DEFAULT '0',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `recurring_occurrences`
+--
+
+CREATE TABLE `recurring_occurrences` (
+  `id` bigint NOT NULL,
+  `recurring_id` bigint NOT NULL,
+  `occurrence_date` date NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `record_id` bigint DEFAULT NULL,
+  `nominal` bigint DEFAULT NULL,
+  `description` text,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- --------------------------------------------------------
+
+--
+-- Struktur dari tabel `transfers`
+--
+
+CREATE TABLE `transfers` (
+  `id` bigint NOT NULL,
+  `user_id` varchar(36) NOT NULL,
+  `from_account_id` bigint NOT NULL,
+  `to_account_id` bigint NOT NULL,
+  `date` date NOT NULL,
+  `nominal` bigint NOT NULL,
+  `currency` char(3) NOT NULL DEFAULT 'IDR',
   `description` text,
-  `attachment` longtext,
   `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -52,6 +221,7 @@ CREATE TABLE `users` (
   `email` varchar(255) NOT NULL,
   `password` text NOT NULL,
   `photo` text,
+  `base_currency` char(3) NOT NULL DEFAULT 'IDR',
   `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -60,11 +230,83 @@ CREATE TABLE `users` (
 -- Indexes for dumped tables
 --
 
+--
+-- Indeks untuk tabel `api_tokens`
+--
+ALTER TABLE `api_tokens`
+  ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `api_tokens_token_hash` (`token_hash`),
+  ADD KEY `api_tokens_user_id` (`user_id`);
+
+--
+-- Indeks untuk tabel `accounts`
+--
+ALTER TABLE `accounts`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `accounts_user_id` (`user_id`);
+
+--
+-- Indeks untuk tabel `attachments`
+--
+ALTER TABLE `attachments`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `attachments_record_id` (`record_id`),
+  ADD KEY `attachments_user_id` (`user_id`);
+
+--
+-- Indeks untuk tabel `budgets`
+--
+ALTER TABLE `budgets`
+  ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `budgets_category_period` (`category_id`,`year`,`month`),
+  ADD KEY `budgets_user_period` (`user_id`,`year`,`month`);
+
+--
+-- Indeks untuk tabel `categories`
+--
+ALTER TABLE `categories`
+  ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `categories_user_type_name` (`user_id`,`type`,`name`);
+
+--
+-- Indeks untuk tabel `exchange_rates`
+--
+ALTER TABLE `exchange_rates`
+  ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `exchange_rates_user_pair_date` (`user_id`,`currency`,`base_currency`,`rate_date`);
+
 --
 -- Indeks untuk tabel `record`
 --
 ALTER TABLE `record`
-  ADD PRIMARY KEY (`id`);
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `record_user_date` (`user_id`,`date`),
+  ADD KEY `record_account_date` (`account_id`,`date`),
+  ADD KEY `record_transfer_id` (`transfer_id`),
+  ADD KEY `record_account_external_id` (`account_id`,`external_id`),
+  ADD KEY `record_deleted_at` (`deleted_at`),
+  ADD FULLTEXT KEY `record_description` (`description`);
+
+--
+-- Indeks untuk tabel `recurring`
+--
+ALTER TABLE `recurring`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `recurring_user_id` (`user_id`);
+
+--
+-- Indeks untuk tabel `recurring_occurrences`
+--
+ALTER TABLE `recurring_occurrences`
+  ADD PRIMARY KEY (`id`),
+  ADD UNIQUE KEY `recurring_occurrences_date` (`recurring_id`,`occurrence_date`);
+
+--
+-- Indeks untuk tabel `transfers`
+--
+ALTER TABLE `transfers`
+  ADD PRIMARY KEY (`id`),
+  ADD KEY `transfers_user_id` (`user_id`);
 
 --
 -- Indeks untuk tabel `users`
@@ -77,11 +319,65 @@ ALTER TABLE `users`
 -- AUTO_INCREMENT untuk tabel yang dibuang
 --
 
+--
+-- AUTO_INCREMENT untuk tabel `api_tokens`
+--
+ALTER TABLE `api_tokens`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `accounts`
+--
+ALTER TABLE `accounts`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `attachments`
+--
+ALTER TABLE `attachments`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `budgets`
+--
+ALTER TABLE `budgets`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `categories`
+--
+ALTER TABLE `categories`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `exchange_rates`
+--
+ALTER TABLE `exchange_rates`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
 --
 -- AUTO_INCREMENT untuk tabel `record`
 --
 ALTER TABLE `record`
-  MODIFY `id` int NOT NULL AUTO_INCREMENT;
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `recurring`
+--
+ALTER TABLE `recurring`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `recurring_occurrences`
+--
+ALTER TABLE `recurring_occurrences`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+--
+-- AUTO_INCREMENT untuk tabel `transfers`
+--
+ALTER TABLE `transfers`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
 COMMIT;
 
 /*!40101 SET CHARACTER_SET_CLIENT=@OLD_CHARACTER_SET_CLIENT */;
@@ -5,15 +5,17 @@ go 1.24.0
 toolchain go1.24.9
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/go-playground/locales v0.14.1
 	github.com/go-playground/universal-translator v0.18.1
 	github.com/go-playground/validator/v10 v10.28.0
 	github.com/go-sql-driver/mysql v1.9.3
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/sessions v1.4.0
+	github.com/joho/godotenv v1.5.1
 	github.com/spf13/viper v1.21.0
 	golang.org/x/crypto v0.43.0
-	github.com/joho/godotenv v1.5.1
+	golang.org/x/image v0.32.0
 )
 
 require (
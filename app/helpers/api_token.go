@@ -0,0 +1,31 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// awalan token API, memudahkan token dikenali kalau tidak sengaja ter-commit
+const APITokenPrefix = "frt_"
+
+// GenerateAPIToken membuat token acak 256 bit. Yang dikembalikan adalah token asli
+// (ditampilkan sekali ke user), hash untuk disimpan dan awalan untuk ditampilkan.
+func GenerateAPIToken() (token string, hash string, prefix string, err error) {
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", "", err
+	}
+
+	token = APITokenPrefix + base64.RawURLEncoding.EncodeToString(random)
+	return token, HashAPIToken(token), token[:len(APITokenPrefix)+6], nil
+}
+
+// HashAPIToken menghitung hash SHA-256 token. Token sudah acak penuh sehingga
+// tidak butuh bcrypt, dan hash yang sama bisa dipakai untuk mencari token.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"financial-record/entities"
+	"financial-record/storage"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// ukuran satu lampiran maksimal, sama dengan foto profil
+const MaxAttachmentSize = 5 * 1024 * 1024
+
+// jumlah lampiran maksimal yang diupload sekali simpan
+const MaxAttachmentFiles = 10
+
+// ErrAttachmentType dikembalikan jika isi file bukan gambar atau PDF yang didukung
+var ErrAttachmentType = errors.New("Lampiran harus berupa gambar JPG, PNG, WEBP atau file PDF")
+
+// tipe file yang boleh jadi lampiran dan ekstensi filenya
+var attachmentExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// AttachmentContentType menebak tipe lampiran dari isi file, bukan dari nama file atau
+// content type yang dikirim browser
+func AttachmentContentType(content []byte) (string, bool) {
+
+	contentType := http.DetectContentType(content)
+	_, ok := attachmentExtensions[contentType]
+	return contentType, ok
+}
+
+// AttachmentFilename merapikan nama file asli untuk ditampilkan: tanpa folder, tanpa karakter
+// kontrol dan maksimal 255 byte. Nama kosong diganti "lampiran" dengan ekstensi sesuai isi.
+func AttachmentFilename(name string, contentType string) string {
+
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(strings.Map(func(char rune) rune {
+		if unicode.IsControl(char) || char == '"' {
+			return -1
+		}
+		return char
+	}, name))
+
+	if name == "" || name == "." || name == "/" {
+		name = "lampiran" + attachmentExtensions[contentType]
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// SaveAttachment menyimpan lampiran ke storage dengan key seperti "attachments/<user id>/<uuid>.pdf"
+// dan mengembalikan data lampiran yang disimpan di database
+func SaveAttachment(store storage.Storage, userId string, filename string, content []byte) (entities.Attachment, error) {
+
+	contentType, ok := AttachmentContentType(content)
+	if !ok {
+		return entities.Attachment{}, ErrAttachmentType
+	}
+
+	attachment := entities.Attachment{
+		UserId:   userId,
+		Filename: AttachmentFilename(filename, contentType),
+	}
+	if err := PutAttachmentFile(store, &attachment, content); err != nil {
+		return entities.Attachment{}, err
+	}
+	return attachment, nil
+}
+
+// PutAttachmentFile menyimpan isi lampiran ke storage dengan key baru. Gambar dinormalisasi
+// dulu (diputar, diperkecil, tanpa EXIF) dan dibuatkan thumbnail JPEG "<uuid>_thumb.jpg".
+func PutAttachmentFile(store storage.Storage, attachment *entities.Attachment, content []byte) error {
+
+	contentType := http.DetectContentType(content)
+	var thumbnail []byte
+	if strings.HasPrefix(contentType, "image/") {
+		var err error
+		if content, contentType, err = NormalizeImage(content, AttachmentImageSize); err != nil {
+			return err
+		}
+		if thumbnail, err = ImageThumbnail(content, AttachmentThumbnailSize); err != nil {
+			return err
+		}
+	}
+
+	base := "attachments/" + attachment.UserId + "/" + uuid.New().String()
+	key := base + attachmentExtensions[contentType]
+	if err := store.Put(key, bytes.NewReader(content), contentType); err != nil {
+		return err
+	}
+
+	var thumbnailKey *string
+	if thumbnail != nil {
+		value := base + "_thumb.jpg"
+		if err := store.Put(value, bytes.NewReader(thumbnail), "image/jpeg"); err != nil {
+			store.Delete(key)
+			return err
+		}
+		thumbnailKey = &value
+	}
+
+	attachment.StorageKey = key
+	attachment.ThumbnailKey = thumbnailKey
+	attachment.ContentType = contentType
+	attachment.Size = int64(len(content))
+	return nil
+}
+
+// AttachmentFileKeys mengembalikan key file lampiran di storage beserta thumbnail-nya jika ada
+func AttachmentFileKeys(attachment entities.Attachment) []string {
+
+	keys := []string{attachment.StorageKey}
+	if attachment.ThumbnailKey != nil && *attachment.ThumbnailKey != attachment.StorageKey {
+		keys = append(keys, *attachment.ThumbnailKey)
+	}
+	return keys
+}
+
+// DecodeLegacyAttachment membaca lampiran lama yang disimpan sebagai base64 di kolom
+// record.attachment, dengan atau tanpa awalan "data:image/...;base64,"
+func DecodeLegacyAttachment(value string) ([]byte, error) {
+
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "data:") {
+		if _, data, found := strings.Cut(value, ","); found {
+			value = data
+		}
+	}
+	value = strings.Join(strings.Fields(value), "")
+	return base64.StdEncoding.DecodeString(value)
+}
@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"financial-record/entities"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mata uang yang bisa dipilih di form dan dipakai catatan. Nominal disimpan dalam sen, jadi
+// hanya mata uang dengan 2 angka desimal (ISO 4217) yang boleh ditambahkan, bukan JPY atau KWD.
+var Currencies = []string{"IDR", "USD", "SGD", "MYR", "EUR", "AUD"}
+
+// ParseExchangeRateCSV membaca kurs dari file CSV dengan kolom tanggal (2006-01-02), mata uang,
+// kurs dan mata uang utama (optional, default baseCurrency). Baris header boleh ada, pemisah
+// boleh koma atau titik koma. Error dikembalikan per baris supaya bisa ditampilkan semua.
+func ParseExchangeRateCSV(reader io.Reader, userId, baseCurrency string) ([]entities.ExchangeRate, []string) {
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, []string{"Gagal membaca file, " + err.Error()}
+	}
+
+	csvReader := csv.NewReader(strings.NewReader(string(content)))
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	if firstLine, _, _ := strings.Cut(string(content), "\n"); strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		csvReader.Comma = ';'
+	}
+
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, []string{"Format CSV tidak valid, " + err.Error()}
+	}
+
+	var rates []entities.ExchangeRate
+	var errors []string
+	for i, row := range rows {
+		line := i + 1
+
+		// lewati baris kosong dan header
+		if len(row) == 0 || (len(row) == 1 && strings.TrimSpace(row[0]) == "") {
+			continue
+		}
+		if i == 0 {
+			if _, err := time.Parse("2006-01-02", strings.TrimSpace(row[0])); err != nil {
+				continue
+			}
+		}
+
+		if len(row) < 3 {
+			errors = append(errors, fmt.Sprintf("Baris %d: minimal 3 kolom (tanggal, mata uang, kurs)", line))
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(row[0]))
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("Baris %d: tanggal harus berformat YYYY-MM-DD", line))
+			continue
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(row[1]))
+
+		rateStr := strings.TrimSpace(row[2])
+		if !strings.Contains(rateStr, ".") {
+			rateStr = strings.Replace(rateStr, ",", ".", 1)
+		}
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil || rate <= 0 {
+			errors = append(errors, fmt.Sprintf("Baris %d: kurs harus berupa angka lebih dari 0", line))
+			continue
+		}
+
+		base := baseCurrency
+		if len(row) > 3 && strings.TrimSpace(row[3]) != "" {
+			base = strings.ToUpper(strings.TrimSpace(row[3]))
+		}
+
+		if len(currency) != 3 || len(base) != 3 || currency == base {
+			errors = append(errors, fmt.Sprintf("Baris %d: mata uang tidak valid", line))
+			continue
+		}
+
+		rates = append(rates, entities.ExchangeRate{
+			UserId:       userId,
+			Currency:     currency,
+			BaseCurrency: base,
+			Date:         date,
+			Rate:         rate,
+		})
+	}
+
+	if len(rates) == 0 && len(errors) == 0 {
+		errors = append(errors, "File tidak berisi data kurs")
+	}
+
+	return rates, errors
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import "time"
+
+// DateRangePreset adalah pilihan periode cepat di filter tanggal
+type DateRangePreset struct {
+	Value string
+	Label string
+}
+
+// pilihan periode, "custom" memakai tanggal awal dan akhir dari form
+var DateRangePresets = []DateRangePreset{
+	{"this_month", "Bulan ini"},
+	{"this_week", "Minggu ini"},
+	{"last_30_days", "30 hari terakhir"},
+	{"this_quarter", "Kuartal ini"},
+	{"this_year", "Tahun ini"},
+	{"custom", "Pilih tanggal"},
+}
+
+// ResolveDateRange menentukan tanggal awal dan akhir (inklusif) dari preset atau input custom.
+// Preset yang tidak dikenal dianggap bulan ini, custom yang tidak valid juga kembali ke bulan ini.
+func ResolveDateRange(preset, startStr, endStr string, now time.Time) (start time.Time, end time.Time, resolved string) {
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch preset {
+	case "this_week":
+		// minggu dimulai hari Senin
+		offset := (int(today.Weekday()) + 6) % 7
+		start = today.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6), preset
+	case "last_30_days":
+		return today.AddDate(0, 0, -29), today, preset
+	case "this_quarter":
+		start = time.Date(today.Year(), (today.Month()-1)/3*3+1, 1, 0, 0, 0, 0, today.Location())
+		return start, start.AddDate(0, 3, -1), preset
+	case "this_year":
+		start = time.Date(today.Year(), 1, 1, 0, 0, 0, 0, today.Location())
+		return start, start.AddDate(1, 0, -1), preset
+	case "custom":
+		start, errStart := time.ParseInLocation("2006-01-02", startStr, today.Location())
+		end, errEnd := time.ParseInLocation("2006-01-02", endStr, today.Location())
+		if errStart == nil && errEnd == nil {
+			// tanggal terbalik ditukar supaya tetap menampilkan data
+			if end.Before(start) {
+				start, end = end, start
+			}
+			return start, end, preset
+		}
+	}
+
+	start = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	return start, start.AddDate(0, 1, -1), "this_month"
+}
@@ -0,0 +1,313 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"financial-record/entities"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jenis isi sel export, menentukan format angka dan tanggal di XLSX
+const (
+	cellText = iota
+	cellHeader
+	cellDate
+	cellAmount
+	cellTotal
+)
+
+type exportCell struct {
+	kind   int
+	text   string
+	date   time.Time
+	amount int64
+}
+
+func textCell(value string) exportCell {
+	return exportCell{kind: cellText, text: value}
+}
+
+// tabel export ditulis baris per baris, CSV atau XLSX
+type exportTable interface {
+	writeRow(cells []exportCell) error
+	close() error
+}
+
+// FinancialExport menulis catatan keuangan sebagai file CSV atau XLSX. Setiap catatan
+// langsung ditulis ke writer sehingga export satu tahun tidak perlu disimpan di memory.
+type FinancialExport struct {
+	table        exportTable
+	baseCurrency string
+	count        int
+}
+
+// NewFinancialExport membuat export dengan format "csv" atau "xlsx" dan menulis baris header
+func NewFinancialExport(writer io.Writer, format string, baseCurrency string) (*FinancialExport, error) {
+
+	export := &FinancialExport{baseCurrency: baseCurrency}
+	switch format {
+	case "csv":
+		// BOM supaya Excel membaca file sebagai UTF-8
+		if _, err := io.WriteString(writer, "\xef\xbb\xbf"); err != nil {
+			return nil, err
+		}
+		export.table = &csvTable{writer: csv.NewWriter(writer)}
+	case "xlsx":
+		table, err := newXLSXTable(writer, "Catatan Keuangan")
+		if err != nil {
+			return nil, err
+		}
+		export.table = table
+	default:
+		return nil, fmt.Errorf("format export %q tidak dikenal", format)
+	}
+
+	header := []exportCell{}
+	for _, name := range []string{"No", "Tanggal", "Tipe", "Kategori", "Akun", "Keterangan", "Mata Uang", "Nominal", "Nominal " + baseCurrency} {
+		header = append(header, exportCell{kind: cellHeader, text: name})
+	}
+	return export, export.table.writeRow(header)
+}
+
+// WriteFinancial menulis satu catatan. Nominal mata uang utama kosong jika kursnya belum ada.
+func (export *FinancialExport) WriteFinancial(financial entities.Financial) error {
+
+	export.count++
+
+	description := ""
+	if financial.Description != nil {
+		description = *financial.Description
+	}
+
+	baseAmount := textCell("")
+	if financial.Currency == export.baseCurrency {
+		baseAmount = exportCell{kind: cellAmount, amount: financial.Nominal}
+	} else if financial.BaseNominal != nil {
+		baseAmount = exportCell{kind: cellAmount, amount: *financial.BaseNominal}
+	}
+
+	return export.table.writeRow([]exportCell{
+		textCell(strconv.Itoa(export.count)),
+		{kind: cellDate, date: financial.Date},
+		textCell(financial.Type),
+		textCell(financial.Category),
+		textCell(financial.AccountName),
+		textCell(description),
+		textCell(financial.Currency),
+		{kind: cellAmount, amount: financial.Nominal},
+		baseAmount,
+	})
+}
+
+// WriteTotals menulis total pemasukan, pengeluaran dan selisihnya dalam mata uang utama
+// setelah semua catatan
+func (export *FinancialExport) WriteTotals(totalPemasukan, totalPengeluaran int64) error {
+
+	if err := export.table.writeRow(nil); err != nil {
+		return err
+	}
+
+	totals := []struct {
+		label  string
+		amount int64
+	}{
+		{"Total Pemasukan", totalPemasukan},
+		{"Total Pengeluaran", totalPengeluaran},
+		{"Selisih", totalPemasukan - totalPengeluaran},
+	}
+	for _, total := range totals {
+		row := []exportCell{textCell(""), textCell(""), textCell(""), textCell(""), textCell(""),
+			{kind: cellHeader, text: total.label}, textCell(export.baseCurrency), textCell(""),
+			{kind: cellTotal, amount: total.amount}}
+		if err := export.table.writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close menyelesaikan file, wajib dipanggil setelah baris terakhir
+func (export *FinancialExport) Close() error {
+	return export.table.close()
+}
+
+type csvTable struct {
+	writer *csv.Writer
+}
+
+func (table *csvTable) writeRow(cells []exportCell) error {
+
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch cell.kind {
+		case cellDate:
+			record[i] = cell.date.Format("2006-01-02")
+		case cellAmount, cellTotal:
+			record[i] = entities.Money{Amount: cell.amount}.Decimal()
+		default:
+			record[i] = csvEscapeFormula(cell.text)
+		}
+	}
+	return table.writer.Write(record)
+}
+
+// teks yang diawali karakter formula diberi awalan ' supaya tidak dijalankan spreadsheet
+func csvEscapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (table *csvTable) close() error {
+	table.writer.Flush()
+	return table.writer.Error()
+}
+
+// file XLSX adalah zip berisi beberapa file XML. Sheet ditulis langsung ke zip memakai
+// inline string supaya tidak perlu tabel shared string yang harus dibuat di akhir.
+type xlsxTable struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+// style sel di styles.xml: 0 biasa, 1 tebal, 2 tanggal, 3 angka, 4 angka tebal
+var xlsxCellStyles = map[int]int{cellText: 0, cellHeader: 1, cellDate: 2, cellAmount: 3, cellTotal: 4}
+
+var xlsxStaticFiles = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="5">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="4" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>
+</cellXfs>
+</styleSheet>`},
+}
+
+func newXLSXTable(writer io.Writer, sheetName string) (*xlsxTable, error) {
+
+	table := &xlsxTable{zip: zip.NewWriter(writer)}
+
+	workbook := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="` + html.EscapeString(sheetName) + `" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+	for _, file := range xlsxStaticFiles {
+		if err := table.writeFile(file.name, file.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := table.writeFile("xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	// sheet harus file terakhir karena ditulis sampai close
+	sheetWriter, err := table.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	table.sheet = bufio.NewWriter(sheetWriter)
+	table.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<cols><col min="2" max="2" width="12" customWidth="1"/><col min="6" max="6" width="40" customWidth="1"/>` +
+		`<col min="8" max="9" width="18" customWidth="1"/></cols><sheetData>`)
+
+	return table, nil
+}
+
+func (table *xlsxTable) writeFile(name, content string) error {
+
+	fileWriter, err := table.zip.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(fileWriter, content)
+	return err
+}
+
+// tanggal di XLSX adalah jumlah hari sejak 30 Desember 1899
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func (table *xlsxTable) writeRow(cells []exportCell) error {
+
+	table.row++
+	fmt.Fprintf(table.sheet, `<row r="%d">`, table.row)
+
+	for i, cell := range cells {
+		reference := string(rune('A'+i)) + strconv.Itoa(table.row)
+		style := xlsxCellStyles[cell.kind]
+
+		switch cell.kind {
+		case cellDate:
+			days := int64(time.Date(cell.date.Year(), cell.date.Month(), cell.date.Day(), 0, 0, 0, 0, time.UTC).Sub(xlsxEpoch).Hours() / 24)
+			fmt.Fprintf(table.sheet, `<c r="%s" s="%d"><v>%d</v></c>`, reference, style, days)
+		case cellAmount, cellTotal:
+			fmt.Fprintf(table.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, reference, style, entities.Money{Amount: cell.amount}.Decimal())
+		default:
+			if cell.text == "" {
+				continue
+			}
+			fmt.Fprintf(table.sheet, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, reference, style, xlsxEscape(cell.text))
+		}
+	}
+
+	_, err := table.sheet.WriteString("</row>")
+	return err
+}
+
+func (table *xlsxTable) close() error {
+
+	table.sheet.WriteString("</sheetData></worksheet>")
+	if err := table.sheet.Flush(); err != nil {
+		return err
+	}
+	return table.zip.Close()
+}
+
+// escape teks XML dan buang karakter kontrol yang tidak boleh ada di XML
+func xlsxEscape(value string) string {
+
+	cleaned := []rune{}
+	for _, char := range value {
+		if char >= 0x20 || char == '\t' || char == '\n' || char == '\r' {
+			cleaned = append(cleaned, char)
+		}
+	}
+	return html.EscapeString(string(cleaned))
+}
@@ -0,0 +1,240 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ukuran sisi terpanjang gambar lampiran setelah diperkecil untuk web
+const AttachmentImageSize = 2048
+
+// ukuran sisi terpanjang thumbnail lampiran di list dan laporan PDF
+const AttachmentThumbnailSize = 240
+
+// ukuran sisi terpanjang foto profil
+const ProfilePhotoSize = 512
+
+// jumlah pixel maksimal gambar yang dibaca (40 megapixel), gambar kecil yang mengaku
+// berukuran sangat besar bisa menghabiskan memory server saat di-decode
+const MaxImagePixels = 40 * 1000 * 1000
+
+// ErrImageDecode dikembalikan jika isi gambar rusak, terlalu besar atau formatnya tidak bisa dibaca
+var ErrImageDecode = errors.New("Gambar rusak atau formatnya tidak didukung")
+
+// NormalizeImage menyiapkan gambar upload untuk web: diputar sesuai orientasi EXIF, diperkecil
+// sampai sisi terpanjangnya maxSize dan disimpan ulang tanpa metadata EXIF (termasuk lokasi GPS).
+// JPEG tetap JPEG dan PNG tetap PNG supaya transparansi tidak hilang. WEBP disimpan ulang
+// sebagai JPEG, atau PNG jika ada bagian transparan, karena Go hanya punya decoder WEBP.
+func NormalizeImage(content []byte, maxSize int) (normalized []byte, contentType string, err error) {
+
+	contentType = http.DetectContentType(content)
+	source, err := DecodeImage(content)
+	if err != nil {
+		return nil, "", err
+	}
+	result := ResizeImage(source, maxSize)
+
+	var buffer bytes.Buffer
+	switch {
+	case contentType == "image/png", contentType == "image/webp" && !result.Opaque():
+		contentType = "image/png"
+		err = png.Encode(&buffer, result)
+	default:
+		contentType = "image/jpeg"
+		err = jpeg.Encode(&buffer, flattenImage(result), &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return buffer.Bytes(), contentType, nil
+}
+
+// ImageExtension mengembalikan ekstensi file untuk tipe gambar hasil NormalizeImage
+func ImageExtension(contentType string) string {
+	return attachmentExtensions[contentType]
+}
+
+// ImageThumbnail membuat thumbnail JPEG dengan latar putih dari gambar JPEG, PNG atau WEBP
+func ImageThumbnail(content []byte, size int) ([]byte, error) {
+
+	source, err := DecodeImage(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, flattenImage(ResizeImage(source, size)), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// DecodeImage membaca gambar JPEG, PNG, WEBP atau GIF dan memutarnya sesuai orientasi EXIF.
+// GIF tidak bisa diupload lagi, tapi lampiran GIF lama masih perlu diubah jadi JPEG.
+// Ukuran gambar dicek dari header dulu, gambar di atas MaxImagePixels ditolak.
+func DecodeImage(content []byte) (image.Image, error) {
+
+	header, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil || header.Width <= 0 || header.Height <= 0 || int64(header.Width)*int64(header.Height) > MaxImagePixels {
+		return nil, ErrImageDecode
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil || source.Bounds().Empty() {
+		return nil, ErrImageDecode
+	}
+	return orientImage(source, imageOrientation(content)), nil
+}
+
+// ResizeImage memperkecil gambar sampai sisi terpanjangnya maxSize dengan filter Catmull-Rom,
+// gambar yang sudah kecil tidak diperbesar
+func ResizeImage(source image.Image, maxSize int) *image.RGBA {
+
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			width, height = maxSize, max(1, height*maxSize/bounds.Dx())
+		} else {
+			width, height = max(1, width*maxSize/bounds.Dy()), maxSize
+		}
+	}
+
+	// x/image/draw menghitung dalam bentuk premultiplied, pixel transparan tidak menggelapkan tepi
+	result := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(result, result.Bounds(), source, bounds, draw.Src, nil)
+	return result
+}
+
+// gambar dengan bagian transparan diberi latar putih, JPEG tidak punya transparansi
+func flattenImage(source image.Image) *image.RGBA {
+
+	result := image.NewRGBA(source.Bounds())
+	draw.Draw(result, result.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(result, result.Bounds(), source, source.Bounds().Min, draw.Over)
+	return result
+}
+
+// orientImage memutar atau membalik gambar sesuai nilai orientasi EXIF 1-8
+func orientImage(source image.Image, orientation int) image.Image {
+
+	if orientation < 2 || orientation > 8 {
+		return source
+	}
+
+	// disalin ke RGBA dulu supaya pixel bisa dipindah langsung dari slice Pix
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	original := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(original, original.Bounds(), source, bounds.Min, draw.Src)
+
+	resultWidth, resultHeight := width, height
+	if orientation >= 5 {
+		resultWidth, resultHeight = height, width
+	}
+
+	result := image.NewRGBA(image.Rect(0, 0, resultWidth, resultHeight))
+	for y := 0; y < resultHeight; y++ {
+		for x := 0; x < resultWidth; x++ {
+			// posisi pixel di gambar asli untuk pixel (x, y) di hasil
+			var sourceX, sourceY int
+			switch orientation {
+			case 2: // dibalik horizontal
+				sourceX, sourceY = width-1-x, y
+			case 3: // diputar 180°
+				sourceX, sourceY = width-1-x, height-1-y
+			case 4: // dibalik vertikal
+				sourceX, sourceY = x, height-1-y
+			case 5: // transpose
+				sourceX, sourceY = y, x
+			case 6: // diputar 90° searah jarum jam
+				sourceX, sourceY = y, height-1-x
+			case 7: // transverse
+				sourceX, sourceY = width-1-y, height-1-x
+			case 8: // diputar 90° berlawanan arah jarum jam
+				sourceX, sourceY = width-1-y, x
+			}
+			from := original.PixOffset(sourceX, sourceY)
+			to := result.PixOffset(x, y)
+			copy(result.Pix[to:to+4], original.Pix[from:from+4])
+		}
+	}
+	return result
+}
+
+// imageOrientation membaca tag Orientation (0x0112) dari EXIF di segment APP1 JPEG
+// atau chunk eXIf PNG, 1 (normal) jika tidak ada
+func imageOrientation(content []byte) int {
+
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xD8}):
+		for offset := 2; offset+4 <= len(content) && content[offset] == 0xFF; {
+			marker := content[offset+1]
+			length := int(binary.BigEndian.Uint16(content[offset+2:]))
+			// SOS berarti data gambar sudah dimulai, EXIF selalu sebelumnya
+			if marker == 0xDA || length < 2 || offset+2+length > len(content) {
+				break
+			}
+			segment := content[offset+4 : offset+2+length]
+			if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+				return tiffOrientation(segment[6:])
+			}
+			offset += 2 + length
+		}
+	case bytes.HasPrefix(content, []byte("\x89PNG\r\n\x1a\n")):
+		for offset := 8; offset+8 <= len(content); {
+			length := int(binary.BigEndian.Uint32(content[offset:]))
+			if offset+12+length > len(content) {
+				break
+			}
+			if string(content[offset+4:offset+8]) == "eXIf" {
+				return tiffOrientation(content[offset+8 : offset+8+length])
+			}
+			offset += 12 + length
+		}
+	}
+	return 1
+}
+
+// cari tag Orientation di IFD pertama struktur TIFF milik EXIF
+func tiffOrientation(tiff []byte) int {
+
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		// tipe SHORT (3), nilainya ada di 2 byte pertama kolom value
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
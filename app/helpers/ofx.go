@@ -0,0 +1,217 @@
+package helpers
+
+import (
+	"bufio"
+	"financial-record/entities"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tag OFX beserta isinya sampai tag berikutnya. OFX 1 (SGML) tidak menutup tag nilai,
+// OFX 2 (XML) menutupnya, keduanya dibaca dengan cara yang sama.
+var ofxTagPattern = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)[^>]*>([^<]*)`)
+
+// ParseOFX mengubah transaksi (STMTTRN) di file OFX menjadi catatan keuangan. FITID disimpan
+// sebagai ExternalId untuk mendeteksi transaksi yang sudah pernah diimport. Mata uang memakai
+// CURDEF di file jika ada, selain itu mata uang dari base.
+func ParseOFX(content []byte, base entities.AddFinancial, defaultCategories map[string]string) ([]entities.ImportRow, error) {
+
+	var result []entities.ImportRow
+	var transaction map[string]string
+	currency := base.Currency
+	isOFX := false
+
+	// simpan transaksi yang sedang dibaca
+	flush := func() {
+		if transaction == nil {
+			return
+		}
+
+		row := entities.ImportRow{
+			Line:      len(result) + 1,
+			Columns:   []string{transaction["DTPOSTED"], transaction["TRNAMT"], transaction["FITID"], transaction["NAME"], transaction["MEMO"]},
+			Financial: base,
+		}
+		row.Financial.Currency = currency
+
+		// tanggal YYYYMMDD, jam dan zona waktu setelahnya tidak dipakai
+		posted := transaction["DTPOSTED"]
+		if date, err := time.Parse("20060102", posted[:min(len(posted), 8)]); err == nil {
+			row.Financial.Date = date
+		} else {
+			row.Errors = append(row.Errors, "Tanggal transaksi tidak valid")
+		}
+
+		// nominal negatif adalah pengeluaran
+		nominal, ok := parseStatementDecimal(transaction["TRNAMT"])
+		financialType := "pemasukan"
+		if nominal < 0 {
+			nominal, financialType = -nominal, "pengeluaran"
+		}
+		if ok && nominal > 0 {
+			row.Financial.Nominal = nominal
+			row.Financial.Type = financialType
+		} else {
+			row.Errors = append(row.Errors, "Nominal tidak valid")
+		}
+		row.Financial.Category = defaultCategories[financialType]
+
+		// MEMO yang diawali NAME adalah keterangan lengkap dari NAME yang dipotong
+		name, memo := transaction["NAME"], transaction["MEMO"]
+		description := strings.TrimSpace(name + " " + memo)
+		if name != "" && strings.HasPrefix(memo, name) {
+			description = memo
+		}
+		if description != "" {
+			row.Financial.Description = &description
+		}
+
+		if fitId := transaction["FITID"]; fitId != "" {
+			row.Financial.ExternalId = &fitId
+		}
+
+		result = append(result, row)
+		transaction = nil
+	}
+
+	for _, match := range ofxTagPattern.FindAllStringSubmatch(string(content), -1) {
+		closing, name := match[1] == "/", strings.ToUpper(match[2])
+		value := strings.TrimSpace(html.UnescapeString(match[3]))
+
+		switch {
+		case name == "OFX":
+			isOFX = true
+		case name == "STMTTRN":
+			flush()
+			if !closing {
+				transaction = make(map[string]string)
+			}
+		case name == "CURDEF" && !closing && len(value) == 3:
+			currency = strings.ToUpper(value)
+		case transaction != nil && !closing && value != "":
+			// nilai pertama yang dipakai, tag yang sama di dalam sub tag diabaikan
+			if _, exists := transaction[name]; !exists {
+				transaction[name] = value
+			}
+		}
+	}
+	flush()
+
+	if !isOFX {
+		return nil, fmt.Errorf("file bukan OFX yang valid")
+	}
+
+	return result, nil
+}
+
+// WriteOFX menulis catatan keuangan sebagai file OFX 2 (XML) dengan satu statement per akun.
+// Nominal memakai mata uang utama, catatan mata uang asing menyertakan mata uang dan kurs
+// aslinya. FITID memakai id catatan supaya file bisa diimport ulang tanpa duplikat.
+func WriteOFX(writer io.Writer, statements []ExportStatement, currency string, startDate, endDate, now time.Time) error {
+
+	buffer := bufio.NewWriter(writer)
+
+	// tulis tag beserta nilainya dalam satu baris
+	tag := func(indent int, name, value string) {
+		fmt.Fprintf(buffer, "%s<%s>%s</%s>\n", strings.Repeat("  ", indent), name, html.EscapeString(value), name)
+	}
+	open := func(indent int, name string) {
+		fmt.Fprintf(buffer, "%s<%s>\n", strings.Repeat("  ", indent), name)
+	}
+	closeTag := func(indent int, name string) {
+		fmt.Fprintf(buffer, "%s</%s>\n", strings.Repeat("  ", indent), name)
+	}
+	status := func(indent int) {
+		open(indent, "STATUS")
+		tag(indent+1, "CODE", "0")
+		tag(indent+1, "SEVERITY", "INFO")
+		closeTag(indent, "STATUS")
+	}
+
+	buffer.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	buffer.WriteString(`<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+
+	open(0, "OFX")
+	open(1, "SIGNONMSGSRSV1")
+	open(2, "SONRS")
+	status(3)
+	tag(3, "DTSERVER", now.Format("20060102150405"))
+	tag(3, "LANGUAGE", "IND")
+	closeTag(2, "SONRS")
+	closeTag(1, "SIGNONMSGSRSV1")
+
+	open(1, "BANKMSGSRSV1")
+	for i, statement := range statements {
+		open(2, "STMTTRNRS")
+		tag(3, "TRNUID", strconv.Itoa(i+1))
+		status(3)
+		open(3, "STMTRS")
+		tag(4, "CURDEF", currency)
+
+		open(4, "BANKACCTFROM")
+		tag(5, "BANKID", "FINANCIAL-RECORD")
+		tag(5, "ACCTID", strconv.FormatInt(statement.Account.Id, 10))
+		tag(5, "ACCTTYPE", "CHECKING")
+		closeTag(4, "BANKACCTFROM")
+
+		open(4, "BANKTRANLIST")
+		tag(5, "DTSTART", startDate.Format("20060102"))
+		tag(5, "DTEND", endDate.Format("20060102"))
+		for _, financial := range statement.Financials {
+			amount, converted := exportAmount(financial, currency)
+
+			transactionType := "CREDIT"
+			switch {
+			case financial.TransferId != nil:
+				transactionType = "XFER"
+			case amount < 0:
+				transactionType = "DEBIT"
+			}
+
+			// NAME maksimal 32 karakter, keterangan lengkap di MEMO
+			name, memo := financial.Category, ""
+			if financial.Description != nil && strings.TrimSpace(*financial.Description) != "" {
+				description := strings.Join(strings.Fields(*financial.Description), " ")
+				name = description
+				if runes := []rune(description); len(runes) > 32 {
+					name, memo = string(runes[:32]), description
+				}
+			}
+
+			open(5, "STMTTRN")
+			tag(6, "TRNTYPE", transactionType)
+			tag(6, "DTPOSTED", financial.Date.Format("20060102"))
+			tag(6, "TRNAMT", entities.Money{Amount: amount}.Decimal())
+			tag(6, "FITID", strconv.FormatInt(financial.Id, 10))
+			tag(6, "NAME", name)
+			if memo != "" {
+				tag(6, "MEMO", memo)
+			}
+			if converted {
+				open(6, "ORIGCURRENCY")
+				tag(7, "CURRATE", strconv.FormatFloat(float64(*financial.BaseNominal)/float64(financial.Nominal), 'f', 6, 64))
+				tag(7, "CURSYM", financial.Currency)
+				closeTag(6, "ORIGCURRENCY")
+			}
+			closeTag(5, "STMTTRN")
+		}
+		closeTag(4, "BANKTRANLIST")
+
+		open(4, "LEDGERBAL")
+		tag(5, "BALAMT", entities.Money{Amount: statement.Balance}.Decimal())
+		tag(5, "DTASOF", endDate.Format("20060102"))
+		closeTag(4, "LEDGERBAL")
+
+		closeTag(3, "STMTRS")
+		closeTag(2, "STMTTRNRS")
+	}
+	closeTag(1, "BANKMSGSRSV1")
+	closeTag(0, "OFX")
+
+	return buffer.Flush()
+}
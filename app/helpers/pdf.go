@@ -0,0 +1,239 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ukuran kertas A4 dalam point (1/72 inch)
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// lebar karakter font Helvetica dan Helvetica-Bold (per 1000 unit) untuk karakter 32-126,
+// dipakai untuk rata kanan dan memotong teks yang terlalu panjang
+var pdfFontWidths = map[bool][95]int{
+	false: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	true: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+type pdfImage struct {
+	data          []byte
+	width, height int
+}
+
+// PDFDocument adalah penulis PDF sederhana untuk laporan: teks Helvetica, garis, kotak dan
+// gambar JPEG. Koordinat dihitung dari kiri atas halaman dalam point.
+type PDFDocument struct {
+	pages   []*bytes.Buffer
+	current int
+	images  []pdfImage
+}
+
+func NewPDFDocument() *PDFDocument {
+	return &PDFDocument{}
+}
+
+// AddPage menambah halaman baru, teks dan gambar berikutnya ditulis ke halaman ini
+func (document *PDFDocument) AddPage() {
+	document.pages = append(document.pages, &bytes.Buffer{})
+	document.current = len(document.pages) - 1
+}
+
+// PageCount mengembalikan jumlah halaman
+func (document *PDFDocument) PageCount() int {
+	return len(document.pages)
+}
+
+// SetPage memilih halaman (mulai dari 1) yang ditulis, dipakai untuk nomor halaman di akhir
+func (document *PDFDocument) SetPage(page int) {
+	document.current = page - 1
+}
+
+func (document *PDFDocument) content() *bytes.Buffer {
+	if len(document.pages) == 0 {
+		document.AddPage()
+	}
+	return document.pages[document.current]
+}
+
+// TextWidth menghitung lebar teks dalam point
+func (document *PDFDocument) TextWidth(text string, size float64, bold bool) float64 {
+
+	widths := pdfFontWidths[bold]
+	total := 0
+	for _, char := range pdfLatin1(text) {
+		if char >= 32 && char <= 126 {
+			total += widths[char-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// FitText memotong teks dengan "..." supaya tidak lebih lebar dari width
+func (document *PDFDocument) FitText(text string, size float64, bold bool, width float64) string {
+
+	if document.TextWidth(text, size, bold) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && document.TextWidth(string(runes)+"...", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
+
+// Text menulis teks dengan baseline di y
+func (document *PDFDocument) Text(x, y float64, size float64, bold bool, text string) {
+
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(document.content(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font, pdfNumber(size), pdfNumber(x), pdfNumber(PDFPageHeight-y), pdfEscape(text))
+}
+
+// TextRight menulis teks rata kanan di x
+func (document *PDFDocument) TextRight(x, y float64, size float64, bold bool, text string) {
+	document.Text(x-document.TextWidth(text, size, bold), y, size, bold, text)
+}
+
+// Line menggambar garis dengan ketebalan 0.5 point
+func (document *PDFDocument) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(document.content(), "0.5 w %s %s m %s %s l S\n",
+		pdfNumber(x1), pdfNumber(PDFPageHeight-y1), pdfNumber(x2), pdfNumber(PDFPageHeight-y2))
+}
+
+// Rect menggambar kotak dengan isi abu-abu (0 hitam, 1 putih) dan garis tepi jika border true
+func (document *PDFDocument) Rect(x, y, width, height float64, gray float64, border bool) {
+
+	operator := "f"
+	if border {
+		operator = "B"
+	}
+	fmt.Fprintf(document.content(), "q %s g 0.5 w %s %s %s %s re %s Q\n", pdfNumber(gray),
+		pdfNumber(x), pdfNumber(PDFPageHeight-y-height), pdfNumber(width), pdfNumber(height), operator)
+}
+
+// AddJPEG menyimpan gambar JPEG untuk dipakai di Image, gambar yang sama cukup ditambah sekali
+func (document *PDFDocument) AddJPEG(data []byte, width, height int) int {
+	document.images = append(document.images, pdfImage{data: data, width: width, height: height})
+	return len(document.images) - 1
+}
+
+// Image menampilkan gambar dari AddJPEG di kotak x, y, width, height
+func (document *PDFDocument) Image(image int, x, y, width, height float64) {
+	fmt.Fprintf(document.content(), "q %s 0 0 %s %s %s cm /Im%d Do Q\n",
+		pdfNumber(width), pdfNumber(height), pdfNumber(x), pdfNumber(PDFPageHeight-y-height), image)
+}
+
+// WriteTo menulis file PDF lengkap
+func (document *PDFDocument) WriteTo(writer io.Writer) (int64, error) {
+
+	var output bytes.Buffer
+	var offsets []int
+
+	// objek 1 catalog, 2 pages, 3-4 font, lalu gambar, lalu halaman dan isinya
+	object := func(body string, stream []byte) {
+		offsets = append(offsets, output.Len())
+		fmt.Fprintf(&output, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			output.WriteString("stream\n")
+			output.Write(stream)
+			output.WriteString("\nendstream\n")
+		}
+		output.WriteString("endobj\n")
+	}
+
+	if len(document.pages) == 0 {
+		document.AddPage()
+	}
+
+	imageStart := 5
+	pageStart := imageStart + len(document.images)
+
+	var kids, xObjects []string
+	for i := range document.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageStart+i*2))
+	}
+	for i := range document.images {
+		xObjects = append(xObjects, fmt.Sprintf("/Im%d %d 0 R", i, imageStart+i))
+	}
+
+	output.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(document.pages)), nil)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+
+	for _, image := range document.images {
+		object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>",
+			image.width, image.height, len(image.data)), image.data)
+	}
+
+	resources := "/Font << /F1 3 0 R /F2 4 0 R >>"
+	if len(xObjects) > 0 {
+		resources += " /XObject << " + strings.Join(xObjects, " ") + " >>"
+	}
+	for i, page := range document.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << %s >> /Contents %d 0 R >>",
+			pdfNumber(PDFPageWidth), pdfNumber(PDFPageHeight), resources, pageStart+i*2+1), nil)
+		object(fmt.Sprintf("<< /Length %d >>", page.Len()), page.Bytes())
+	}
+
+	xref := output.Len()
+	fmt.Fprintf(&output, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&output, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&output, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return output.WriteTo(writer)
+}
+
+func pdfNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// ubah teks ke Latin-1 (WinAnsiEncoding), karakter lain diganti "?"
+func pdfLatin1(text string) []byte {
+
+	result := make([]byte, 0, len(text))
+	for _, char := range text {
+		switch {
+		case char == '\t' || char == '\n' || char == '\r':
+			result = append(result, ' ')
+		case (char >= 32 && char <= 126) || (char >= 160 && char <= 255):
+			result = append(result, byte(char))
+		default:
+			result = append(result, '?')
+		}
+	}
+	return result
+}
+
+// escape karakter khusus string PDF
+func pdfEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(string(pdfLatin1(text)))
+}
@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// folder foto profil, disajikan tanpa login di /user_photo/
+var ProfilePhotoDir = "public/user_photo"
+
+// SaveProfilePhoto menyimpan foto profil dengan nama acak 128 bit seperti
+// "profile_<acak>.jpg" supaya tidak bentrok antar user dan tidak bisa ditebak.
+// File ditulis ke file sementara di folder yang sama lalu di-rename, sehingga
+// foto yang setengah tertulis tidak pernah bisa diakses.
+func SaveProfilePhoto(content []byte, ext string) (string, error) {
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	filename := "profile_" + base64.RawURLEncoding.EncodeToString(random) + ext
+
+	if err := os.MkdirAll(ProfilePhotoDir, 0755); err != nil {
+		return "", err
+	}
+	temp, err := os.CreateTemp(ProfilePhotoDir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name()) // tidak berpengaruh setelah berhasil di-rename
+
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return "", err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return "", err
+	}
+	if err := temp.Close(); err != nil {
+		return "", err
+	}
+	// CreateTemp membuat file 0600, foto harus bisa dibaca file server
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(temp.Name(), filepath.Join(ProfilePhotoDir, filename)); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// DeleteProfilePhoto menghapus foto profil, nama yang berisi path diabaikan
+// dan foto yang sudah tidak ada tidak dianggap error
+func DeleteProfilePhoto(filename string) error {
+
+	if !validProfilePhotoName(filename) {
+		return nil
+	}
+	err := os.Remove(filepath.Join(ProfilePhotoDir, filename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func validProfilePhotoName(filename string) bool {
+	return filename != "" && filename == filepath.Base(filename) && !strings.HasPrefix(filename, ".")
+}
+
+// ProfilePhotoHandler menyajikan foto profil tanpa daftar isi folder dan tanpa file
+// sementara yang sedang ditulis, nama foto yang acak hanya diketahui pemiliknya
+func ProfilePhotoHandler() http.Handler {
+
+	fileServer := http.FileServer(http.Dir(ProfilePhotoDir))
+
+	return http.StripPrefix("/user_photo/", http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !validProfilePhotoName(request.URL.Path) {
+			http.NotFound(writer, request)
+			return
+		}
+		writer.Header().Set("X-Content-Type-Options", "nosniff")
+		fileServer.ServeHTTP(writer, request)
+	}))
+}
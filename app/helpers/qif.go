@@ -0,0 +1,228 @@
+package helpers
+
+import (
+	"bufio"
+	"financial-record/entities"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tipe bagian QIF yang berisi transaksi, bagian lain (investasi, daftar kategori) dilewati
+var qifTransactionTypes = []string{"bank", "cash", "ccard", "oth a", "oth l"}
+
+// ParseQIF mengubah transaksi di file QIF menjadi catatan keuangan. Kategori (L) dipakai jika
+// sama dengan kategori user, selain itu memakai defaultCategories. Tanggal dibaca sebagai
+// MM/DD/YYYY kecuali ada tanggal yang hanya cocok dengan DD/MM/YYYY di file yang sama.
+func ParseQIF(content []byte, base entities.AddFinancial, defaultCategories map[string]string, categories []entities.Category) ([]entities.ImportRow, error) {
+
+	type qifEntry struct {
+		line   int
+		fields map[byte]string
+		lines  []string
+	}
+
+	var entries []qifEntry
+	var entry *qifEntry
+	inTransactions, hasTransactions := false, false
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(string(content), "\xef\xbb\xbf")))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// header bagian, contoh "!Type:Bank" atau "!Account"
+		if strings.HasPrefix(line, "!") {
+			header := strings.ToLower(line)
+			if strings.HasPrefix(header, "!type:") {
+				inTransactions = containsFold(qifTransactionTypes, strings.TrimPrefix(header, "!type:"))
+				hasTransactions = hasTransactions || inTransactions
+			} else if strings.HasPrefix(header, "!account") {
+				inTransactions = false
+			}
+			continue
+		}
+		if !inTransactions {
+			continue
+		}
+
+		if line == "^" {
+			if entry != nil {
+				entries = append(entries, *entry)
+			}
+			entry = nil
+			continue
+		}
+
+		if entry == nil {
+			entry = &qifEntry{line: number, fields: make(map[byte]string)}
+		}
+		entry.lines = append(entry.lines, line)
+
+		// field split (S, E, $) tidak dipakai, nominal memakai total di T
+		if _, exists := entry.fields[line[0]]; !exists {
+			entry.fields[line[0]] = strings.TrimSpace(line[1:])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		entries = append(entries, *entry)
+	}
+	if !hasTransactions {
+		return nil, fmt.Errorf("file QIF tidak berisi bagian transaksi (!Type:Bank)")
+	}
+
+	// tanggal dengan angka pertama lebih dari 12 berarti file memakai DD/MM
+	dayFirst := false
+	for _, entry := range entries {
+		if parts := qifDateParts(entry.fields['D']); parts != nil && parts[0] > 12 && parts[0] <= 31 {
+			dayFirst = true
+		}
+	}
+
+	var result []entities.ImportRow
+	for _, entry := range entries {
+		row := entities.ImportRow{Line: entry.line, Columns: entry.lines, Financial: base}
+
+		if date, ok := qifDate(qifDateParts(entry.fields['D']), dayFirst); ok {
+			row.Financial.Date = date
+		} else {
+			row.Errors = append(row.Errors, "Tanggal tidak valid")
+		}
+
+		// nominal negatif adalah pengeluaran
+		amount := entry.fields['T']
+		if amount == "" {
+			amount = entry.fields['U']
+		}
+		nominal, ok := parseStatementDecimal(amount)
+		financialType := "pemasukan"
+		if nominal < 0 {
+			nominal, financialType = -nominal, "pengeluaran"
+		}
+		if ok && nominal > 0 {
+			row.Financial.Nominal = nominal
+			row.Financial.Type = financialType
+		} else {
+			row.Errors = append(row.Errors, "Nominal tidak valid")
+		}
+
+		// kategori "Induk:Sub" dicocokkan utuh lalu induknya, transfer "[Akun]" memakai default
+		row.Financial.Category = defaultCategories[financialType]
+		if category := entry.fields['L']; category != "" && !strings.HasPrefix(category, "[") {
+			parent, _, _ := strings.Cut(category, ":")
+			for _, name := range []string{category, parent} {
+				if match := findCategoryName(categories, financialType, name); match != "" {
+					row.Financial.Category = match
+					break
+				}
+			}
+		}
+
+		// payee dan memo sebagai keterangan
+		description := strings.TrimSpace(entry.fields['P'] + " " + entry.fields['M'])
+		if description != "" {
+			row.Financial.Description = &description
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// pecah tanggal QIF menjadi tiga angka, contoh "1/ 2'24", "01/02/2024" atau "2024-01-02"
+func qifDateParts(value string) []int {
+
+	value = strings.NewReplacer("'", "/", "-", "/", ".", "/", " ", "").Replace(value)
+	parts := strings.Split(value, "/")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil
+		}
+		numbers[i] = number
+	}
+	return numbers
+}
+
+// susun tanggal dari qifDateParts, tahun dua angka dianggap 20xx
+func qifDate(parts []int, dayFirst bool) (time.Time, bool) {
+
+	if parts == nil {
+		return time.Time{}, false
+	}
+
+	year, month, day := parts[2], parts[0], parts[1]
+	switch {
+	case parts[0] > 31:
+		year, month, day = parts[0], parts[1], parts[2]
+	case dayFirst:
+		month, day = parts[1], parts[0]
+	}
+	if year < 100 {
+		year += 2000
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// cari nama kategori user dengan tipe yang sama tanpa membedakan huruf besar/kecil
+func findCategoryName(categories []entities.Category, financialType string, name string) string {
+	for _, category := range categories {
+		if category.Type == financialType && strings.EqualFold(category.Name, strings.TrimSpace(name)) {
+			return category.Name
+		}
+	}
+	return ""
+}
+
+// WriteQIF menulis catatan keuangan sebagai file QIF dengan satu bagian per akun. Tanggal
+// memakai MM/DD/YYYY dan nominal memakai mata uang utama seperti WriteOFX.
+func WriteQIF(writer io.Writer, statements []ExportStatement, currency string) error {
+
+	buffer := bufio.NewWriter(writer)
+
+	// QIF satu baris per field
+	clean := func(value string) string {
+		return strings.Join(strings.Fields(value), " ")
+	}
+
+	for _, statement := range statements {
+		accountType := "Bank"
+		if statement.Account.Kind == "tunai" {
+			accountType = "Cash"
+		}
+
+		fmt.Fprintf(buffer, "!Account\nN%s\nT%s\n^\n!Type:%s\n", clean(statement.Account.Name), accountType, accountType)
+
+		for _, financial := range statement.Financials {
+			amount, _ := exportAmount(financial, currency)
+
+			fmt.Fprintf(buffer, "D%s\n", financial.Date.Format("01/02/2006"))
+			fmt.Fprintf(buffer, "T%s\n", entities.Money{Amount: amount}.Decimal())
+			if financial.Description != nil && clean(*financial.Description) != "" {
+				fmt.Fprintf(buffer, "P%s\n", clean(*financial.Description))
+			}
+			fmt.Fprintf(buffer, "L%s\n", clean(financial.Category))
+			fmt.Fprintf(buffer, "^\n")
+		}
+	}
+
+	return buffer.Flush()
+}
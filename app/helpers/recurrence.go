@@ -0,0 +1,68 @@
+package helpers
+
+import "time"
+
+// RecurrenceDate menghitung tanggal pengulangan ke-n (dimulai dari 0) dari tanggal mulai.
+// Untuk bulanan dan tahunan, tanggal yang tidak ada di bulan tujuan (misal 31 Februari)
+// dibulatkan ke hari terakhir bulan tersebut.
+func RecurrenceDate(start time.Time, frequency string, n int) time.Time {
+	switch frequency {
+	case "daily":
+		return start.AddDate(0, 0, n)
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "monthly":
+		return addMonthsClamped(start, n)
+	case "yearly":
+		return addMonthsClamped(start, 12*n)
+	}
+	return start
+}
+
+func addMonthsClamped(start time.Time, months int) time.Time {
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(months), 1, 0, 0, 0, 0, start.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+
+	day := start.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, start.Location())
+}
+
+// RecurrenceDates menampilkan semua tanggal pengulangan sampai dengan tanggal until,
+// dibatasi oleh tanggal akhir (end) dan jumlah pengulangan (count) jika diisi
+func RecurrenceDates(start time.Time, frequency string, end *time.Time, count *int, until time.Time) []time.Time {
+	var dates []time.Time
+	for n := 0; ; n++ {
+		if count != nil && n >= *count {
+			break
+		}
+
+		date := RecurrenceDate(start, frequency, n)
+		if date.After(until) || (end != nil && date.After(*end)) {
+			break
+		}
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+// NextRecurrenceDates menampilkan maksimal limit tanggal pengulangan setelah tanggal after
+func NextRecurrenceDates(start time.Time, frequency string, end *time.Time, count *int, after time.Time, limit int) []time.Time {
+	var dates []time.Time
+	for n := 0; len(dates) < limit; n++ {
+		if count != nil && n >= *count {
+			break
+		}
+
+		date := RecurrenceDate(start, frequency, n)
+		if end != nil && date.After(*end) {
+			break
+		}
+		if date.After(after) {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"financial-record/entities"
+	"strings"
+	"unicode"
+)
+
+// ParseSearchQuery memisahkan kata kunci pencarian menjadi kata (dicari di keterangan dan
+// kategori) dan nominal. Kata yang bisa dibaca sebagai nominal, seperti "5000" atau
+// "1.500.000,50", dicari sebagai nominal yang sama persis.
+func ParseSearchQuery(query string) (terms []string, nominals []int64) {
+
+	for _, word := range strings.Fields(query) {
+
+		if startsWithDigit(word) {
+			if money, err := entities.ParseMoney(word, ""); err == nil {
+				nominals = append(nominals, money.Amount)
+				continue
+			}
+		}
+
+		// buang operator boolean FULLTEXT supaya input user tidak mengubah arti query
+		term := strings.TrimFunc(strings.Map(func(char rune) rune {
+			if strings.ContainsRune(`+-<>()~*"@`, char) {
+				return -1
+			}
+			return char
+		}, word), unicode.IsPunct)
+		if term != "" {
+			terms = append(terms, strings.ToLower(term))
+		}
+	}
+
+	return terms, nominals
+}
+
+func startsWithDigit(word string) bool {
+	word = strings.TrimPrefix(strings.TrimPrefix(word, "Rp."), "Rp")
+	return word != "" && word[0] >= '0' && word[0] <= '9'
+}
@@ -0,0 +1,26 @@
+package helpers
+
+import "financial-record/entities"
+
+// ExportStatement berisi catatan keuangan satu akun untuk file OFX dan QIF, Balance adalah
+// saldo akun di akhir periode dalam mata uang utama
+type ExportStatement struct {
+	Account    entities.Account
+	Balance    int64
+	Financials []entities.Financial
+}
+
+// nominal bertanda (pengeluaran negatif) dalam mata uang utama. Catatan mata uang asing
+// yang belum punya kurs tetap memakai nominal aslinya, converted bernilai false.
+func exportAmount(financial entities.Financial, currency string) (amount int64, converted bool) {
+
+	amount = financial.Nominal
+	if financial.Currency != currency && financial.BaseNominal != nil && financial.Nominal != 0 {
+		amount, converted = *financial.BaseNominal, true
+	}
+
+	if financial.Type == "pengeluaran" || financial.Type == "transfer_keluar" {
+		amount = -amount
+	}
+	return amount, converted
+}
@@ -0,0 +1,205 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"financial-record/entities"
+	"strings"
+	"time"
+)
+
+// ImportDateFormat adalah pilihan format tanggal di file mutasi
+type ImportDateFormat struct {
+	Layout string
+	Label  string
+}
+
+var ImportDateFormats = []ImportDateFormat{
+	{"2006-01-02", "YYYY-MM-DD"},
+	{"02/01/2006", "DD/MM/YYYY"},
+	{"02-01-2006", "DD-MM-YYYY"},
+	{"02/01/06", "DD/MM/YY"},
+	{"01/02/2006", "MM/DD/YYYY"},
+}
+
+// nama header yang dikenali untuk menebak kolom
+var importHeaderNames = map[string][]string{
+	"date":        {"tanggal", "tgl", "date", "tanggal transaksi", "transaction date"},
+	"amount":      {"nominal", "jumlah", "amount", "mutasi", "nilai"},
+	"description": {"keterangan", "deskripsi", "description", "uraian", "catatan"},
+	"type":        {"tipe", "jenis", "type", "db/cr", "d/k"},
+	"category":    {"kategori", "category"},
+}
+
+// nilai kolom tipe yang dikenali, selain itu baris dianggap tidak valid
+var importTypeValues = map[string]string{
+	"pemasukan": "pemasukan", "masuk": "pemasukan", "income": "pemasukan", "in": "pemasukan",
+	"cr": "pemasukan", "credit": "pemasukan", "kredit": "pemasukan", "k": "pemasukan", "c": "pemasukan",
+	"pengeluaran": "pengeluaran", "keluar": "pengeluaran", "expense": "pengeluaran", "out": "pengeluaran",
+	"db": "pengeluaran", "debit": "pengeluaran", "d": "pengeluaran",
+}
+
+// DetectStatementFormat mengenali file OFX ("ofx") dan QIF ("qif") dari awal isinya,
+// file lain dianggap CSV ("")
+func DetectStatementFormat(content []byte) string {
+
+	head := bytes.TrimPrefix(content[:min(len(content), 1024)], []byte("\xef\xbb\xbf"))
+	value := strings.ToUpper(strings.TrimSpace(string(head)))
+
+	switch {
+	case strings.HasPrefix(value, "OFXHEADER") || strings.Contains(value, "<?OFX") || strings.Contains(value, "<OFX>"):
+		return "ofx"
+	case strings.HasPrefix(value, "!TYPE:") || strings.HasPrefix(value, "!ACCOUNT") || strings.HasPrefix(value, "!OPTION"):
+		return "qif"
+	}
+	return ""
+}
+
+// baca nominal bertanda dari file OFX/QIF. Titik atau koma terakhir yang diikuti 1-2 angka
+// adalah pemisah desimal, contoh "-1,234.56", "1.234,56", "-50000,00" atau "1,500".
+func parseStatementDecimal(value string) (int64, bool) {
+
+	value = strings.NewReplacer(" ", "", "+", "").Replace(value)
+
+	separator := strings.LastIndexAny(value, ".,")
+	fraction := ""
+	if separator >= 0 && len(value)-separator-1 <= 2 {
+		value, fraction = value[:separator], value[separator+1:]
+	}
+	value = strings.NewReplacer(".", "", ",", "").Replace(value)
+	if fraction != "" {
+		value += "." + fraction
+	}
+
+	money, err := entities.ParseDecimalMoney(value, "")
+	return money.Amount, err == nil
+}
+
+// ReadStatementCSV membaca isi file CSV mutasi. Pemisah boleh koma, titik koma atau tab,
+// baris kosong dilewati.
+func ReadStatementCSV(content []byte) ([][]string, error) {
+
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	csvReader := csv.NewReader(bytes.NewReader(content))
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	csvReader.LazyQuotes = true
+
+	// pemisah yang paling banyak muncul di baris pertama
+	firstLine, _, _ := strings.Cut(string(content), "\n")
+	for _, comma := range []rune{';', '\t'} {
+		if strings.Count(firstLine, string(comma)) > strings.Count(firstLine, string(csvReader.Comma)) {
+			csvReader.Comma = comma
+		}
+	}
+
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var result [][]string
+	for _, row := range rows {
+		if len(row) == 0 || (len(row) == 1 && strings.TrimSpace(row[0]) == "") {
+			continue
+		}
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// GuessImportMapping menebak kolom dari nama header, kolom yang tidak ditemukan bernilai -1
+func GuessImportMapping(header []string) entities.ImportMapping {
+
+	mapping := entities.ImportMapping{Date: -1, Amount: -1, Description: -1, Type: -1, Category: -1, DateFormat: ImportDateFormats[0].Layout}
+
+	columns := map[string]*int{
+		"date":        &mapping.Date,
+		"amount":      &mapping.Amount,
+		"description": &mapping.Description,
+		"type":        &mapping.Type,
+		"category":    &mapping.Category,
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, names := range importHeaderNames {
+			for _, known := range names {
+				if name == known && *columns[field] == -1 {
+					*columns[field] = i
+					mapping.HasHeader = true
+				}
+			}
+		}
+	}
+
+	return mapping
+}
+
+// ParseStatementRows mengubah baris CSV menjadi catatan keuangan memakai mapping kolom.
+// base berisi data yang sama untuk semua baris (user, akun, mata uang). Jika kolom tipe tidak
+// dipakai, nominal negatif dianggap pengeluaran. Kategori kosong diisi dari defaultCategories
+// sesuai tipe. Error dikembalikan per baris supaya bisa ditampilkan di preview.
+func ParseStatementRows(rows [][]string, mapping entities.ImportMapping, base entities.AddFinancial, defaultCategories map[string]string) []entities.ImportRow {
+
+	var result []entities.ImportRow
+	for i, columns := range rows {
+		if i == 0 && mapping.HasHeader {
+			continue
+		}
+
+		row := entities.ImportRow{Line: i + 1, Columns: columns, Financial: base}
+		column := func(index int) string {
+			if index < 0 || index >= len(columns) {
+				return ""
+			}
+			return strings.TrimSpace(columns[index])
+		}
+
+		// tanggal
+		if date, err := time.Parse(mapping.DateFormat, column(mapping.Date)); err == nil {
+			row.Financial.Date = date
+		} else {
+			row.Errors = append(row.Errors, "Tanggal tidak sesuai format")
+		}
+
+		// nominal, tanda minus menentukan tipe jika kolom tipe tidak dipakai
+		money, err := entities.ParseMoney(column(mapping.Amount), "")
+		if err != nil || money.Amount == 0 {
+			row.Errors = append(row.Errors, "Nominal tidak valid")
+		}
+		row.Financial.Nominal = money.Amount
+		if money.Amount < 0 {
+			row.Financial.Nominal = -money.Amount
+		}
+
+		// tipe
+		if mapping.Type >= 0 {
+			if financialType, ok := importTypeValues[strings.ToLower(column(mapping.Type))]; ok {
+				row.Financial.Type = financialType
+			} else {
+				row.Errors = append(row.Errors, "Tipe \""+column(mapping.Type)+"\" tidak dikenali")
+			}
+		} else if money.Amount < 0 {
+			row.Financial.Type = "pengeluaran"
+		} else {
+			row.Financial.Type = "pemasukan"
+		}
+
+		// kategori
+		row.Financial.Category = column(mapping.Category)
+		if row.Financial.Category == "" {
+			row.Financial.Category = defaultCategories[row.Financial.Type]
+		}
+
+		// keterangan
+		if description := column(mapping.Description); description != "" {
+			row.Financial.Description = &description
+		}
+
+		result = append(result, row)
+	}
+
+	return result
+}
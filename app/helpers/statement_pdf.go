@@ -0,0 +1,256 @@
+package helpers
+
+import (
+	"bytes"
+	"financial-record/entities"
+	"fmt"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"strconv"
+	"time"
+)
+
+// StatementPDF berisi data laporan keuangan yang dicetak ke PDF
+type StatementPDF struct {
+	UserName         string
+	Period           string
+	AccountName      string
+	Currency         string
+	TotalPemasukan   int64
+	TotalPengeluaran int64
+	MissingRates     int
+	Categories       []entities.CategoryTotal
+	Financials       []entities.Financial
+	GeneratedAt      time.Time
+
+	// ReadAttachment membaca isi lampiran dari storage, nil jika thumbnail tidak ditampilkan
+	ReadAttachment func(key string) ([]byte, error)
+}
+
+// ukuran thumbnail lampiran dalam pixel dan dalam point di tabel
+const (
+	statementThumbnailPixels = 96
+	statementThumbnailSize   = 28
+)
+
+const (
+	statementMargin = 40
+	statementBottom = PDFPageHeight - 50
+)
+
+type statementColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+var statementCategoryColumns = []statementColumn{
+	{"Tipe", 90, false}, {"Kategori", 190, false}, {"Jumlah", 60, true}, {"Total", 120, true}, {"Persen", 55.28, true},
+}
+
+var statementFinancialColumns = []statementColumn{
+	{"No", 25, false}, {"Tanggal", 58, false}, {"Tipe", 68, false}, {"Kategori", 72, false}, {"Keterangan", 137.28, false},
+	{"Nominal", 105, true}, {"Lampiran", 50, false},
+}
+
+type statementWriter struct {
+	document *PDFDocument
+	y        float64
+}
+
+// pindah ke halaman baru jika sisa halaman kurang dari height, header tabel ditulis ulang
+func (statement *statementWriter) ensureSpace(height float64, columns []statementColumn) {
+
+	if statement.y+height <= statementBottom {
+		return
+	}
+	statement.document.AddPage()
+	statement.y = statementMargin
+	if columns != nil {
+		statement.tableHeader(columns)
+	}
+}
+
+func (statement *statementWriter) tableHeader(columns []statementColumn) {
+
+	document := statement.document
+	document.Rect(statementMargin, statement.y, PDFPageWidth-statementMargin*2, 18, 0.9, false)
+	statement.row(columns, 18, true, func(i int) string { return columns[i].title })
+}
+
+// tulis satu baris tabel, teks dipotong sesuai lebar kolom
+func (statement *statementWriter) row(columns []statementColumn, height float64, bold bool, cell func(i int) string) {
+
+	document := statement.document
+	x := float64(statementMargin)
+	for i, column := range columns {
+		text := document.FitText(cell(i), 8.5, bold, column.width-8)
+		if column.right {
+			document.TextRight(x+column.width-4, statement.y+12, 8.5, bold, text)
+		} else {
+			document.Text(x+4, statement.y+12, 8.5, bold, text)
+		}
+		x += column.width
+	}
+	statement.y += height
+	document.Line(statementMargin, statement.y, PDFPageWidth-statementMargin, statement.y)
+}
+
+// WriteStatementPDF menulis laporan keuangan sebagai file PDF: total pemasukan, pengeluaran
+// dan selisih, rincian per kategori, lalu tabel transaksi dengan thumbnail lampiran.
+func WriteStatementPDF(writer io.Writer, data StatementPDF) error {
+
+	document := NewPDFDocument()
+	document.AddPage()
+	statement := &statementWriter{document: document, y: statementMargin}
+	contentWidth := PDFPageWidth - statementMargin*2
+
+	// judul dan informasi laporan
+	document.Text(statementMargin, statement.y+18, 18, true, "Laporan Keuangan")
+	statement.y += 38
+	info := [][2]string{{"Nama", data.UserName}, {"Periode", data.Period}}
+	if data.AccountName != "" {
+		info = append(info, [2]string{"Akun", data.AccountName})
+	}
+	info = append(info, [2]string{"Dibuat", data.GeneratedAt.Format("02 Jan 2006 15:04")})
+	for _, line := range info {
+		document.Text(statementMargin, statement.y, 10, true, line[0])
+		document.Text(statementMargin+60, statement.y, 10, false, ": "+document.FitText(line[1], 10, false, contentWidth-70))
+		statement.y += 14
+	}
+	statement.y += 6
+
+	// kotak total pemasukan, pengeluaran dan selisih
+	boxWidth := (contentWidth - 20) / 3
+	totals := []struct {
+		label  string
+		amount int64
+	}{
+		{"Total Pemasukan", data.TotalPemasukan},
+		{"Total Pengeluaran", data.TotalPengeluaran},
+		{"Selisih", data.TotalPemasukan - data.TotalPengeluaran},
+	}
+	for i, total := range totals {
+		x := statementMargin + float64(i)*(boxWidth+10)
+		document.Rect(x, statement.y, boxWidth, 44, 0.95, true)
+		document.Text(x+8, statement.y+15, 9, false, total.label)
+		document.Text(x+8, statement.y+34, 12, true, document.FitText(entities.Money{Amount: total.amount, Currency: data.Currency}.Format(), 12, true, boxWidth-16))
+	}
+	statement.y += 56
+
+	if data.MissingRates > 0 {
+		document.Text(statementMargin, statement.y, 8, false,
+			fmt.Sprintf("* %d catatan belum punya kurs ke %s sehingga tidak ikut dihitung di total.", data.MissingRates, data.Currency))
+		statement.y += 14
+	}
+
+	// rincian per kategori, persen dihitung dari total tipe yang sama
+	statement.y += 10
+	statement.ensureSpace(60, nil)
+	document.Text(statementMargin, statement.y, 12, true, "Rincian per Kategori")
+	statement.y += 8
+
+	typeTotals := make(map[string]int64)
+	for _, category := range data.Categories {
+		typeTotals[category.Type] += category.Total
+	}
+
+	statement.tableHeader(statementCategoryColumns)
+	if len(data.Categories) == 0 {
+		statement.row(statementCategoryColumns, 18, false, func(i int) string { return []string{"-", "Belum ada data", "", "", ""}[i] })
+	}
+	for _, category := range data.Categories {
+		percent := "-"
+		if typeTotals[category.Type] > 0 {
+			percent = strconv.FormatFloat(float64(category.Total)*100/float64(typeTotals[category.Type]), 'f', 1, 64) + "%"
+		}
+		cells := []string{category.Type, category.Category, strconv.Itoa(category.Count),
+			entities.Money{Amount: category.Total, Currency: data.Currency}.Format(), percent}
+
+		statement.ensureSpace(18, statementCategoryColumns)
+		statement.row(statementCategoryColumns, 18, false, func(i int) string { return cells[i] })
+	}
+
+	// daftar transaksi
+	statement.y += 20
+	statement.ensureSpace(60, nil)
+	document.Text(statementMargin, statement.y, 12, true, "Daftar Transaksi")
+	statement.y += 8
+
+	statement.tableHeader(statementFinancialColumns)
+	if len(data.Financials) == 0 {
+		statement.row(statementFinancialColumns, 18, false, func(i int) string { return []string{"", "", "", "", "Belum ada data!", "", ""}[i] })
+	}
+	for index, financial := range data.Financials {
+		description := "-"
+		if financial.Description != nil && *financial.Description != "" {
+			description = *financial.Description
+		}
+		nominal := entities.Money{Amount: financial.Nominal, Currency: financial.Currency}.Format()
+		cells := []string{strconv.Itoa(index + 1), financial.Date.Format("02/01/2006"), financial.Type,
+			financial.Category, description, nominal, ""}
+
+		// thumbnail dari gambar pertama yang bisa dibaca, selain itu hanya jumlah file lampiran.
+		// Thumbnail yang dibuat saat upload dipakai supaya tidak membaca gambar ukuran penuh.
+		height := float64(18)
+		var thumbnail []byte
+		var width, imageHeight int
+		ok := false
+		for _, attachment := range financial.Attachments {
+			if !attachment.IsImage() || data.ReadAttachment == nil {
+				continue
+			}
+			key := attachment.StorageKey
+			if attachment.ThumbnailKey != nil {
+				key = *attachment.ThumbnailKey
+			}
+			if content, err := data.ReadAttachment(key); err == nil {
+				if thumbnail, width, imageHeight, ok = statementThumbnail(content); ok {
+					break
+				}
+			}
+		}
+		if ok {
+			height = statementThumbnailSize + 6
+		} else if len(financial.Attachments) > 0 {
+			cells[len(cells)-1] = fmt.Sprintf("%d file", len(financial.Attachments))
+		}
+
+		statement.ensureSpace(height, statementFinancialColumns)
+		if ok {
+			image := document.AddJPEG(thumbnail, width, imageHeight)
+			scale := float64(statementThumbnailSize) / float64(max(width, imageHeight))
+			x := PDFPageWidth - statementMargin - statementFinancialColumns[len(statementFinancialColumns)-1].width + 4
+			document.Image(image, x, statement.y+3, float64(width)*scale, float64(imageHeight)*scale)
+		}
+		statement.row(statementFinancialColumns, height, false, func(i int) string { return cells[i] })
+	}
+
+	// nomor halaman setelah jumlah halaman diketahui
+	for page := 1; page <= document.PageCount(); page++ {
+		document.SetPage(page)
+		document.Text(statementMargin, PDFPageHeight-25, 8, false, "Laporan Keuangan "+data.Period)
+		document.TextRight(PDFPageWidth-statementMargin, PDFPageHeight-25, 8, false, fmt.Sprintf("Halaman %d dari %d", page, document.PageCount()))
+	}
+
+	_, err := document.WriteTo(writer)
+	return err
+}
+
+// statementThumbnail mengubah lampiran menjadi JPEG kecil dengan latar putih,
+// ok false jika lampiran bukan gambar
+func statementThumbnail(content []byte) (thumbnail []byte, width int, height int, ok bool) {
+
+	source, err := DecodeImage(content)
+	if err != nil {
+		return nil, 0, 0, false
+	}
+	result := ResizeImage(source, statementThumbnailPixels)
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, flattenImage(result), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, 0, 0, false
+	}
+	return buffer.Bytes(), result.Bounds().Dx(), result.Bounds().Dy(), true
+}
@@ -0,0 +1,297 @@
+package helpers
+
+import (
+	"financial-record/entities"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatementPreset adalah format file mutasi bank atau e-wallet yang bisa diimport tanpa
+// memilih kolom. Kolom dicari dari nama header, baris sebelum header (info rekening) dan
+// baris yang tidak lengkap (saldo awal/akhir di bagian bawah) dilewati.
+type StatementPreset struct {
+	Value  string
+	Label  string
+	format statementFormat
+}
+
+type statementFormat struct {
+	dateColumn         string
+	dateLayouts        []string
+	descriptionColumns []string
+
+	// nominal memakai salah satu: kolom bertanda (-50.000), kolom dengan akhiran CR/DB,
+	// kolom debit dan kredit terpisah, atau kolom nominal dengan kolom tipe
+	amountColumn string
+	debitColumn  string
+	creditColumn string
+	typeColumn   string
+	incomeTypes  []string
+
+	// baris dengan status selain ini dilewati, contoh transaksi gagal di e-wallet
+	statusColumn    string
+	successStatuses []string
+
+	thousandsSeparator string
+	decimalSeparator   string
+}
+
+var StatementPresets = []StatementPreset{
+	{"bca", "BCA (KlikBCA / myBCA)", statementFormat{
+		dateColumn:         "Tanggal Transaksi",
+		dateLayouts:        []string{"02/01/2006", "02/01"},
+		descriptionColumns: []string{"Keterangan"},
+		amountColumn:       "Jumlah",
+		thousandsSeparator: ",",
+		decimalSeparator:   ".",
+	}},
+	{"mandiri", "Mandiri (Livin' / MCM)", statementFormat{
+		dateColumn:         "Date",
+		dateLayouts:        []string{"02/01/06", "02/01/2006"},
+		descriptionColumns: []string{"Description", "Reference No."},
+		debitColumn:        "Debit",
+		creditColumn:       "Credit",
+		thousandsSeparator: ",",
+		decimalSeparator:   ".",
+	}},
+	{"bri", "BRI (BRImo / Internet Banking)", statementFormat{
+		dateColumn:         "Tanggal Transaksi",
+		dateLayouts:        []string{"02/01/06 15:04:05", "02/01/06", "02/01/2006"},
+		descriptionColumns: []string{"Uraian Transaksi"},
+		debitColumn:        "Debet",
+		creditColumn:       "Kredit",
+		thousandsSeparator: ".",
+		decimalSeparator:   ",",
+	}},
+	{"gopay", "GoPay", statementFormat{
+		dateColumn:         "Date",
+		dateLayouts:        []string{"2006-01-02 15:04:05", "2006-01-02"},
+		descriptionColumns: []string{"Description"},
+		amountColumn:       "Amount",
+		statusColumn:       "Status",
+		successStatuses:    []string{"success", "completed", "berhasil"},
+		thousandsSeparator: ".",
+		decimalSeparator:   ",",
+	}},
+	{"ovo", "OVO", statementFormat{
+		dateColumn:         "Tanggal",
+		dateLayouts:        []string{"02 Jan 2006 15:04", "02 Jan 2006"},
+		descriptionColumns: []string{"Deskripsi"},
+		amountColumn:       "Nominal",
+		typeColumn:         "Jenis Transaksi",
+		incomeTypes:        []string{"uang masuk", "top up", "terima dana", "cashback"},
+		thousandsSeparator: ".",
+		decimalSeparator:   ",",
+	}},
+}
+
+// FindStatementPreset mencari preset berdasarkan value, false jika tidak ada
+func FindStatementPreset(value string) (StatementPreset, bool) {
+	for _, preset := range StatementPresets {
+		if preset.Value == value {
+			return preset, true
+		}
+	}
+	return StatementPreset{}, false
+}
+
+// Parse mengubah isi file mutasi menjadi catatan keuangan. base dan defaultCategories
+// dipakai sama seperti ParseStatementRows.
+func (preset StatementPreset) Parse(rows [][]string, base entities.AddFinancial, defaultCategories map[string]string) ([]entities.ImportRow, error) {
+
+	format := preset.format
+
+	// cari baris header
+	headerLine, columns := -1, map[string]int{}
+	for i, row := range rows {
+		found := make(map[string]int)
+		for index, name := range row {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, exists := found[name]; !exists {
+				found[name] = index
+			}
+		}
+		if _, ok := found[strings.ToLower(format.dateColumn)]; ok {
+			headerLine, columns = i, found
+			break
+		}
+	}
+	if headerLine < 0 {
+		return nil, fmt.Errorf("header kolom mutasi %s tidak ditemukan, pastikan file dan pilihan bank sesuai", preset.Label)
+	}
+
+	// baris yang lebih pendek dari kolom terakhir yang dipakai adalah baris ringkasan
+	lastColumn := 0
+	for _, name := range append([]string{format.dateColumn, format.amountColumn, format.debitColumn, format.creditColumn,
+		format.typeColumn, format.statusColumn}, format.descriptionColumns...) {
+		if index, ok := columns[strings.ToLower(name)]; ok && name != "" && index > lastColumn {
+			lastColumn = index
+		}
+	}
+
+	// periode di info rekening, dipakai untuk tanggal tanpa tahun
+	periodStart := statementPeriodStart(rows[:headerLine])
+
+	var result []entities.ImportRow
+	for i := headerLine + 1; i < len(rows); i++ {
+		values := rows[i]
+		column := func(name string) string {
+			index, ok := columns[strings.ToLower(name)]
+			if name == "" || !ok || index >= len(values) {
+				return ""
+			}
+			return strings.TrimSpace(values[index])
+		}
+
+		// baris ringkasan dan transaksi pending tidak punya tanggal yang lengkap
+		dateValue := strings.TrimPrefix(column(format.dateColumn), "'")
+		if len(values) <= lastColumn || dateValue == "" || strings.EqualFold(dateValue, "PEND") {
+			continue
+		}
+
+		if format.statusColumn != "" && !containsFold(format.successStatuses, column(format.statusColumn)) {
+			continue
+		}
+
+		row := entities.ImportRow{Line: i + 1, Columns: values, Financial: base}
+
+		// tanggal
+		date, ok := parseStatementDate(dateValue, format.dateLayouts, periodStart)
+		if ok {
+			row.Financial.Date = date
+		} else {
+			row.Errors = append(row.Errors, "Tanggal tidak sesuai format "+preset.Label)
+		}
+
+		// nominal dan tipe
+		nominal, financialType, ok := format.amount(column)
+		if ok {
+			row.Financial.Nominal = nominal
+			row.Financial.Type = financialType
+		} else {
+			row.Errors = append(row.Errors, "Nominal tidak valid")
+		}
+		row.Financial.Category = defaultCategories[financialType]
+
+		// keterangan dari beberapa kolom
+		var descriptions []string
+		for _, name := range format.descriptionColumns {
+			if value := strings.Join(strings.Fields(column(name)), " "); value != "" {
+				descriptions = append(descriptions, value)
+			}
+		}
+		if description := strings.Join(descriptions, " "); description != "" {
+			row.Financial.Description = &description
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// ambil nominal (positif) dan tipe dari baris sesuai format
+func (format statementFormat) amount(column func(string) string) (int64, string, bool) {
+
+	switch {
+	case format.debitColumn != "":
+		debit, debitOk := format.parseAmount(column(format.debitColumn))
+		credit, creditOk := format.parseAmount(column(format.creditColumn))
+		if creditOk && credit > 0 {
+			return credit, "pemasukan", true
+		}
+		if debitOk && debit > 0 {
+			return debit, "pengeluaran", true
+		}
+		return 0, "", false
+
+	case format.typeColumn != "":
+		nominal, ok := format.parseAmount(column(format.amountColumn))
+		if nominal < 0 {
+			nominal = -nominal
+		}
+		if containsFold(format.incomeTypes, column(format.typeColumn)) {
+			return nominal, "pemasukan", ok && nominal > 0
+		}
+		return nominal, "pengeluaran", ok && nominal > 0
+
+	default:
+		// akhiran CR/DB atau tanda minus
+		value := strings.ToUpper(column(format.amountColumn))
+		financialType := "pemasukan"
+		if strings.HasSuffix(value, "DB") {
+			financialType = "pengeluaran"
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(value, "CR"), "DB"))
+
+		nominal, ok := format.parseAmount(value)
+		if nominal < 0 {
+			nominal, financialType = -nominal, "pengeluaran"
+		}
+		return nominal, financialType, ok && nominal > 0
+	}
+}
+
+// baca nominal dengan pemisah ribuan dan desimal sesuai format
+func (format statementFormat) parseAmount(value string) (int64, bool) {
+
+	value = strings.NewReplacer("Rp.", "", "Rp", "", "RP.", "", "RP", "", "IDR", "", " ", "", "+", "").Replace(value)
+	if value == "" {
+		return 0, false
+	}
+	value = strings.ReplaceAll(value, format.thousandsSeparator, "")
+	value = strings.Replace(value, format.decimalSeparator, ".", 1)
+
+	money, err := entities.ParseDecimalMoney(value, "")
+	return money.Amount, err == nil
+}
+
+// tanggal tanpa tahun memakai tahun dari awal periode, dan tahun berikutnya jika
+// tanggalnya sebelum awal periode (periode Desember - Januari)
+func parseStatementDate(value string, layouts []string, periodStart time.Time) (time.Time, bool) {
+
+	for _, layout := range layouts {
+		date, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if date.Year() == 0 {
+			if periodStart.IsZero() {
+				return time.Time{}, false
+			}
+			date = date.AddDate(periodStart.Year(), 0, 0)
+			if date.Before(periodStart) {
+				date = date.AddDate(1, 0, 0)
+			}
+		}
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), true
+	}
+
+	return time.Time{}, false
+}
+
+// cari tanggal pertama (DD/MM/YYYY) di info rekening, contoh "Periode : 01/12/2023 - 31/01/2024"
+func statementPeriodStart(rows [][]string) time.Time {
+
+	for _, row := range rows {
+		for _, value := range row {
+			for _, word := range strings.Fields(value) {
+				if date, err := time.Parse("02/01/2006", word); err == nil {
+					return date
+				}
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, item := range values {
+		if strings.EqualFold(item, strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package helpers
 import (
 	"database/sql"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/go-playground/locales/en"
@@ -64,6 +65,59 @@ func initValidator(validation *Validation) (*validator.Validate, ut.Translator)
 		return t
 	})
 
+	// custom translate max
+	validate.RegisterTranslation("max", trans, func(ut ut.Translator) error {
+		return ut.Add("max", "{0} maksimal {1} karakter", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("max", fe.Field(), fe.Param())
+		return t
+	})
+
+	// custom translate gt
+	validate.RegisterTranslation("gt", trans, func(ut ut.Translator) error {
+		return ut.Add("gt", "{0} harus lebih besar dari {1}", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("gt", fe.Field(), fe.Param())
+		return t
+	})
+
+	// custom translate oneof
+	validate.RegisterTranslation("oneof", trans, func(ut ut.Translator) error {
+		return ut.Add("oneof", "{0} harus salah satu dari [{1}]", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("oneof", fe.Field(), fe.Param())
+		return t
+	})
+
+	// custom translate hexcolor
+	validate.RegisterTranslation("hexcolor", trans, func(ut ut.Translator) error {
+		return ut.Add("hexcolor", "{0} harus berupa kode warna hex yang valid", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("hexcolor", fe.Field())
+		return t
+	})
+
+	// custom translate iso4217
+	validate.RegisterTranslation("iso4217", trans, func(ut ut.Translator) error {
+		return ut.Add("iso4217", "{0} harus berupa kode mata uang yang valid", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("iso4217", fe.Field())
+		return t
+	})
+
+	// register currency, hanya mata uang di Currencies yang nominalnya memakai 2 angka desimal
+	validate.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return slices.Contains(Currencies, fl.Field().String())
+	})
+
+	// custom translate currency
+	validate.RegisterTranslation("currency", trans, func(ut ut.Translator) error {
+		return ut.Add("currency", "{0} harus salah satu dari mata uang yang didukung", true)
+	}, func(ut ut.Translator, fe validator.FieldError) string {
+		t, _ := ut.T("currency", fe.Field())
+		return t
+	})
+
 	// custom translate eqfield
 	validate.RegisterTranslation("eqfield", trans, func(ut ut.Translator) error {
 		return ut.Add("eqfield", "{0} harus sama dengan {1}", true)
@@ -2,21 +2,58 @@ package main
 
 import (
 	"financial-record/config"
+	"financial-record/helpers"
 	"financial-record/routes"
+	"financial-record/scheduler"
+	"flag"
 	"log"
 	"net/http"
+
+	"github.com/spf13/viper"
 )
 
 func main() {
 
-	// read file dari folder public
-	http.Handle("/user_photo/", http.StripPrefix("/user_photo/", http.FileServer(http.Dir("public/user_photo"))))
+	// -migrate-attachments memindahkan lampiran base64 lama ke storage lalu keluar
+	migrateAttachments := flag.Bool("migrate-attachments", false, "pindahkan lampiran base64 di record.attachment ke storage")
+	// -normalize-attachments membuang EXIF dan membuat thumbnail lampiran gambar lama lalu keluar
+	normalizeAttachments := flag.Bool("normalize-attachments", false, "buang EXIF, perkecil dan buat thumbnail lampiran gambar lama")
+	flag.Parse()
+
+	// read foto profil dari folder public, tanpa daftar isi folder
+	http.Handle("/user_photo/", helpers.ProfilePhotoHandler())
 
 	config.InitConfiguration()
+	config.InitStorage()
 
 	db := config.InitDatabase()
+
+	if *migrateAttachments {
+		moved, skipped, err := scheduler.MigrateAttachments(db, config.FileStorage)
+		log.Printf("%d lampiran dipindahkan ke storage, %d dilewati\n", moved, skipped)
+		if err != nil {
+			log.Fatal("Gagal memindahkan lampiran, ", err)
+		}
+		return
+	}
+
+	if *normalizeAttachments {
+		processed, skipped, err := scheduler.NormalizeAttachments(db, config.FileStorage)
+		log.Printf("%d lampiran gambar diproses, %d dilewati\n", processed, skipped)
+		if err != nil {
+			log.Fatal("Gagal memproses lampiran, ", err)
+		}
+		return
+	}
+
 	routes.Routes(db)
 
+	// jalankan transaksi berulang di background
+	go scheduler.RunRecurring(db, viper.GetDuration("SCHEDULER.INTERVAL"))
+
+	// hapus permanen catatan yang sudah melewati masa simpan sampah
+	go scheduler.RunTrashPurge(db, config.FileStorage, viper.GetDuration("SCHEDULER.INTERVAL"), config.TrashRetentionDays)
+
 	log.Println("Service berjalan di port :8000")
 	http.ListenAndServe(":8000", nil)
 
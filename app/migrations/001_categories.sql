@@ -0,0 +1,33 @@
+-- Kategori milik masing-masing user, menggantikan kategori yang sebelumnya
+-- ditulis langsung di template views/financial/create.html dan edit.html.
+
+CREATE TABLE `categories` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(36) NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `name` varchar(20) NOT NULL,
+  `color` varchar(7) NOT NULL DEFAULT '#6c757d',
+  `icon` varchar(50) NOT NULL DEFAULT '',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `categories_user_type_name` (`user_id`,`type`,`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- kategori bawaan untuk semua user yang sudah terdaftar
+INSERT IGNORE INTO `categories` (`user_id`, `type`, `name`, `color`, `icon`)
+SELECT u.id, d.type, d.name, d.color, d.icon
+FROM `users` u
+CROSS JOIN (
+  SELECT 'pemasukan' AS type, 'gaji' AS name, '#198754' AS color, 'bi-cash-stack' AS icon
+  UNION ALL SELECT 'pemasukan', 'tabungan', '#0d6efd', 'bi-piggy-bank'
+  UNION ALL SELECT 'pemasukan', 'hibah', '#20c997', 'bi-gift'
+  UNION ALL SELECT 'pengeluaran', 'belanja', '#dc3545', 'bi-cart'
+  UNION ALL SELECT 'pengeluaran', 'jajan', '#fd7e14', 'bi-cup-straw'
+  UNION ALL SELECT 'pengeluaran', 'bensin', '#6f42c1', 'bi-fuel-pump'
+) d;
+
+-- kategori lain yang sudah terlanjur dipakai di tabel record
+INSERT IGNORE INTO `categories` (`user_id`, `type`, `name`)
+SELECT DISTINCT `user_id`, `type`, `category`
+FROM `record`;
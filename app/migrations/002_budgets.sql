@@ -0,0 +1,15 @@
+-- Anggaran bulanan per kategori pengeluaran.
+
+CREATE TABLE `budgets` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(36) NOT NULL,
+  `category_id` bigint NOT NULL,
+  `year` smallint NOT NULL,
+  `month` tinyint NOT NULL,
+  `amount` bigint NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `budgets_category_period` (`category_id`,`year`,`month`),
+  KEY `budgets_user_period` (`user_id`,`year`,`month`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,35 @@
+-- Transaksi berulang (gaji, sewa, langganan) dan jadwal pengulangannya.
+-- Kombinasi recurring_id + occurrence_date unik supaya scheduler tidak
+-- membuat catatan keuangan ganda ketika aplikasi restart.
+
+CREATE TABLE `recurring` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(36) NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `category` varchar(20) NOT NULL,
+  `nominal` bigint NOT NULL,
+  `description` text,
+  `frequency` varchar(10) NOT NULL,
+  `start_date` date NOT NULL,
+  `end_date` date DEFAULT NULL,
+  `occurrences` int DEFAULT NULL,
+  `paused` tinyint(1) NOT NULL DEFAULT '0',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `recurring_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE `recurring_occurrences` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `recurring_id` bigint NOT NULL,
+  `occurrence_date` date NOT NULL,
+  `status` varchar(20) NOT NULL,
+  `record_id` int DEFAULT NULL,
+  `nominal` bigint DEFAULT NULL,
+  `description` text,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `recurring_occurrences_date` (`recurring_id`,`occurrence_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,38 @@
+-- Akun (tunai, rekening bank, e-wallet) dengan saldo awal. Setiap catatan
+-- keuangan dan transaksi berulang sekarang tercatat pada satu akun, saldo
+-- akun dihitung dari saldo awal + pemasukan - pengeluaran.
+
+CREATE TABLE `accounts` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(36) NOT NULL,
+  `name` varchar(50) NOT NULL,
+  `kind` varchar(20) NOT NULL,
+  `opening_balance` bigint NOT NULL DEFAULT '0',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `accounts_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `record`
+  ADD COLUMN `account_id` bigint DEFAULT NULL AFTER `category`,
+  ADD KEY `record_account_date` (`account_id`,`date`);
+
+ALTER TABLE `recurring`
+  ADD COLUMN `account_id` bigint DEFAULT NULL AFTER `category`;
+
+-- akun tunai bawaan untuk semua user yang sudah terdaftar
+INSERT INTO `accounts` (`user_id`, `name`, `kind`)
+SELECT `id`, 'Tunai', 'tunai'
+FROM `users`;
+
+-- catatan lama dipindahkan ke akun tunai bawaan
+UPDATE `record` r
+JOIN `accounts` a ON a.user_id = r.user_id AND a.name = 'Tunai'
+SET r.account_id = a.id
+WHERE r.account_id IS NULL;
+
+UPDATE `recurring` rc
+JOIN `accounts` a ON a.user_id = rc.user_id AND a.name = 'Tunai'
+SET rc.account_id = a.id
+WHERE rc.account_id IS NULL;
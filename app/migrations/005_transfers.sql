@@ -0,0 +1,22 @@
+-- Transfer antar akun. Setiap transfer menulis dua catatan di tabel record
+-- (transfer_keluar dari akun asal dan transfer_masuk ke akun tujuan) yang
+-- terhubung lewat transfer_id, sehingga saldo akun ikut berubah tetapi total
+-- pemasukan/pengeluaran tidak.
+
+CREATE TABLE `transfers` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(36) NOT NULL,
+  `from_account_id` bigint NOT NULL,
+  `to_account_id` bigint NOT NULL,
+  `date` date NOT NULL,
+  `nominal` bigint NOT NULL,
+  `description` text,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `transfers_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `record`
+  ADD COLUMN `transfer_id` bigint DEFAULT NULL AFTER `account_id`,
+  ADD KEY `record_transfer_id` (`transfer_id`);
@@ -0,0 +1,28 @@
+-- Catatan keuangan dalam beberapa mata uang. Setiap user punya mata uang
+-- utama, total dan saldo dikonversi memakai kurs terakhir di tabel
+-- exchange_rates sampai tanggal catatan. Nominal asli tetap disimpan.
+
+ALTER TABLE `users`
+  ADD COLUMN `base_currency` char(3) NOT NULL DEFAULT 'IDR' AFTER `photo`;
+
+ALTER TABLE `record`
+  ADD COLUMN `currency` char(3) NOT NULL DEFAULT 'IDR' AFTER `nominal`;
+
+ALTER TABLE `recurring`
+  ADD COLUMN `currency` char(3) NOT NULL DEFAULT 'IDR' AFTER `nominal`;
+
+ALTER TABLE `transfers`
+  ADD COLUMN `currency` char(3) NOT NULL DEFAULT 'IDR' AFTER `nominal`;
+
+CREATE TABLE `exchange_rates` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(36) NOT NULL,
+  `currency` char(3) NOT NULL,
+  `base_currency` char(3) NOT NULL,
+  `rate_date` date NOT NULL,
+  `rate` decimal(20,8) NOT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `exchange_rates_user_pair_date` (`user_id`,`currency`,`base_currency`,`rate_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
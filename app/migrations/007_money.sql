@@ -0,0 +1,17 @@
+-- Nominal disimpan dalam satuan terkecil (sen) supaya pecahan seperti
+-- 1.500.000,50 tersimpan tepat tanpa float. Semua nominal lama dikali 100.
+
+ALTER TABLE `record`
+  MODIFY `nominal` bigint NOT NULL;
+
+UPDATE `record` SET `nominal` = `nominal` * 100;
+
+UPDATE `recurring` SET `nominal` = `nominal` * 100;
+
+UPDATE `recurring_occurrences` SET `nominal` = `nominal` * 100 WHERE `nominal` IS NOT NULL;
+
+UPDATE `transfers` SET `nominal` = `nominal` * 100;
+
+UPDATE `budgets` SET `amount` = `amount` * 100;
+
+UPDATE `accounts` SET `opening_balance` = `opening_balance` * 100;
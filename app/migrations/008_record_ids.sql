@@ -0,0 +1,8 @@
+-- Id catatan keuangan diperlebar ke bigint supaya edit dan hapus tetap
+-- berjalan setelah jumlah catatan melewati 32.767.
+
+ALTER TABLE `record`
+  MODIFY `id` bigint NOT NULL AUTO_INCREMENT;
+
+ALTER TABLE `recurring_occurrences`
+  MODIFY `record_id` bigint DEFAULT NULL;
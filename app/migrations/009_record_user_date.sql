@@ -0,0 +1,5 @@
+-- Filter periode di halaman utama memakai `r.user_id = ? AND r.date BETWEEN ? AND ?`,
+-- index ini membuat query tersebut tidak perlu membaca seluruh catatan user.
+
+ALTER TABLE `record`
+  ADD KEY `record_user_date` (`user_id`,`date`);
@@ -0,0 +1,5 @@
+-- Pencarian catatan keuangan mencocokkan keterangan memakai
+-- MATCH ... AGAINST (... IN BOOLEAN MODE), butuh index FULLTEXT.
+
+ALTER TABLE `record`
+  ADD FULLTEXT KEY `record_description` (`description`);
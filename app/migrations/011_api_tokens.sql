@@ -0,0 +1,19 @@
+-- Token API pribadi untuk script dan aplikasi di luar browser. Token hanya
+-- ditampilkan sekali saat dibuat, yang disimpan hanya hash SHA-256 dan
+-- beberapa karakter awal untuk dikenali di halaman profil.
+
+CREATE TABLE `api_tokens` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(36) NOT NULL,
+  `name` varchar(50) NOT NULL,
+  `token_hash` char(64) NOT NULL,
+  `token_prefix` varchar(16) NOT NULL,
+  `scope` varchar(10) NOT NULL DEFAULT 'read',
+  `expires_at` datetime DEFAULT NULL,
+  `last_used_at` datetime DEFAULT NULL,
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `api_tokens_token_hash` (`token_hash`),
+  KEY `api_tokens_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,9 @@
+-- Id transaksi dari file bank (FITID di OFX) disimpan supaya file yang sama
+-- tidak terimport dua kali. Bukan unique key karena user tetap boleh
+-- mengimport baris yang ditandai duplikat.
+
+ALTER TABLE `record`
+  ADD `external_id` varchar(255) DEFAULT NULL AFTER `attachment`;
+
+ALTER TABLE `record`
+  ADD KEY `record_account_external_id` (`account_id`,`external_id`);
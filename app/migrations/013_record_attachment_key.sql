@@ -0,0 +1,7 @@
+-- Lampiran dipindah dari kolom base64 `attachment` ke storage file (folder lokal
+-- atau S3), database hanya menyimpan key file-nya. Lampiran lama dipindah ke
+-- storage dengan `./app -migrate-attachments` setelah 016_record_soft_delete.sql
+-- dan sebelum 017_drop_record_attachment.sql.
+
+ALTER TABLE `record`
+  ADD `attachment_key` varchar(255) DEFAULT NULL AFTER `attachment`;
@@ -0,0 +1,40 @@
+-- Satu catatan bisa punya beberapa lampiran (foto struk, invoice PDF beberapa
+-- halaman). Lampiran pindah dari kolom record.attachment_key ke tabel sendiri,
+-- tipe file dibaca dari isinya saat upload dan ukurannya dipakai untuk kuota
+-- per user (STORAGE_QUOTA_MB).
+--
+-- Jika masih ada lampiran base64 lama di record.attachment, jalankan migration
+-- sampai 016 dulu lalu `./app -migrate-attachments`, baru 017_drop_record_attachment.sql.
+
+CREATE TABLE `attachments` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(36) NOT NULL,
+  `record_id` bigint NOT NULL,
+  `storage_key` varchar(255) NOT NULL,
+  `filename` varchar(255) NOT NULL,
+  `content_type` varchar(100) NOT NULL,
+  `size` bigint NOT NULL DEFAULT '0',
+  `created_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `attachments_record_id` (`record_id`),
+  KEY `attachments_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Ukuran lampiran yang sudah ada tidak tersimpan di database, diisi 0 sehingga
+-- tidak ikut dihitung di kuota. Tipe file diambil dari ekstensi key yang dibuat
+-- aplikasi dari isi file, hanya JPEG, PNG dan WEBP yang didukung. GIF lama ikut
+-- dicatat sebagai image/jpeg lalu diubah jadi JPEG saat aplikasi menormalisasi
+-- lampiran (isi gambar dibaca dari filenya, bukan dari content type).
+INSERT INTO `attachments` (`user_id`, `record_id`, `storage_key`, `filename`, `content_type`, `size`, `created_at`)
+SELECT `user_id`, `id`, `attachment_key`, SUBSTRING_INDEX(`attachment_key`, '/', -1),
+  CASE SUBSTRING_INDEX(`attachment_key`, '.', -1)
+    WHEN 'png' THEN 'image/png'
+    WHEN 'webp' THEN 'image/webp'
+    ELSE 'image/jpeg'
+  END,
+  0, `created_at`
+FROM `record`
+WHERE `attachment_key` IS NOT NULL;
+
+ALTER TABLE `record`
+  DROP COLUMN `attachment_key`;
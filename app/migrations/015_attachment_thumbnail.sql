@@ -0,0 +1,7 @@
+-- Lampiran gambar disimpan dalam versi web (diputar sesuai EXIF, diperkecil,
+-- tanpa metadata EXIF termasuk lokasi GPS) dengan thumbnail JPEG untuk list dan
+-- laporan PDF. Setelah migration ini jalankan `./app -normalize-attachments`
+-- untuk memproses lampiran gambar yang sudah ada.
+
+ALTER TABLE `attachments`
+  ADD `thumbnail_key` varchar(255) DEFAULT NULL AFTER `storage_key`;
@@ -0,0 +1,7 @@
+-- Catatan yang dihapus dipindahkan ke sampah dengan mengisi deleted_at, catatan di
+-- sampah tidak ikut ditampilkan maupun dihitung. Catatan dihapus permanen beserta
+-- lampirannya oleh scheduler setelah TRASH_RETENTION_DAYS hari (default 30).
+
+ALTER TABLE `record`
+  ADD `deleted_at` timestamp NULL DEFAULT NULL AFTER `updated_at`,
+  ADD KEY `record_deleted_at` (`deleted_at`);
@@ -0,0 +1,6 @@
+-- Hapus kolom base64 lama. Sengaja diberi nomor terakhir, jalankan hanya setelah
+-- `./app -migrate-attachments` (yang dijalankan setelah 016) selesai tanpa lampiran
+-- yang dilewati, isi kolom ini tidak bisa dikembalikan.
+
+ALTER TABLE `record`
+  DROP COLUMN `attachment`;
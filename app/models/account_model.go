@@ -0,0 +1,182 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"financial-record/entities"
+	"time"
+)
+
+// ErrAccountInUse dikembalikan ketika akun yang akan dihapus masih dipakai oleh catatan keuangan
+var ErrAccountInUse = errors.New("akun masih digunakan oleh catatan keuangan, termasuk yang ada di sampah")
+
+type AccountModel struct {
+	db *sql.DB
+}
+
+func NewAccountModel(db *sql.DB) *AccountModel {
+	return &AccountModel{
+		db: db,
+	}
+}
+
+func (model AccountModel) AddAccount(data entities.Account) error {
+
+	query := `
+		INSERT INTO accounts (user_id, name, kind, opening_balance)
+		VALUES (?,?,?,?)
+	`
+
+	_, err := model.db.Exec(query, data.UserId, data.Name, data.Kind, data.OpeningBalance)
+
+	return err
+}
+
+// AddDefaultAccount membuat akun tunai untuk user baru
+func (model AccountModel) AddDefaultAccount(user_id string) error {
+
+	return model.AddAccount(entities.Account{UserId: user_id, Name: "Tunai", Kind: "tunai"})
+}
+
+// FindAllAccount menampilkan akun milik user beserta saldo saat ini dalam mata uang utama,
+// transfer ikut dihitung ke saldo
+func (model AccountModel) FindAllAccount(user_id string) ([]entities.Account, error) {
+
+	query := `
+		SELECT a.id, a.user_id, a.name, a.kind, a.opening_balance,
+			a.opening_balance + COALESCE(SUM(CASE
+				WHEN r.type IN ('pemasukan', 'transfer_masuk') THEN ` + baseNominalColumn + `
+				WHEN r.type IN ('pengeluaran', 'transfer_keluar') THEN -` + baseNominalColumn + `
+				ELSE 0
+			END), 0) AS balance
+		FROM accounts a
+		JOIN users u ON u.id = a.user_id
+		LEFT JOIN record r ON r.account_id = a.id AND r.user_id = a.user_id AND r.deleted_at IS NULL
+		WHERE a.user_id = ?
+		GROUP BY a.id, a.user_id, a.name, a.kind, a.opening_balance
+		ORDER BY a.name
+	`
+
+	rows, err := model.db.Query(query, user_id)
+	if err != nil {
+		return []entities.Account{}, err
+	}
+
+	defer rows.Close()
+
+	var accounts []entities.Account
+	for rows.Next() {
+		var account entities.Account
+		err := rows.Scan(
+			&account.Id,
+			&account.UserId,
+			&account.Name,
+			&account.Kind,
+			&account.OpeningBalance,
+			&account.Balance,
+		)
+		if err != nil {
+			return []entities.Account{}, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (model AccountModel) FindAccountById(id int64, user_id string) (*entities.Account, error) {
+
+	account := &entities.Account{}
+
+	query := `
+		SELECT id, user_id, name, kind, opening_balance
+		FROM accounts WHERE id = ? AND user_id = ?
+	`
+
+	err := model.db.QueryRow(query, id, user_id).Scan(
+		&account.Id,
+		&account.UserId,
+		&account.Name,
+		&account.Kind,
+		&account.OpeningBalance,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// IsUserAccount mengecek apakah akun dimiliki oleh user
+func (model AccountModel) IsUserAccount(user_id string, id int64) (bool, error) {
+
+	var count int
+	query := "SELECT COUNT(*) FROM accounts WHERE id = ? AND user_id = ?"
+
+	if err := model.db.QueryRow(query, id, user_id).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetBalanceBefore menghitung saldo akun sebelum tanggal tertentu, dipakai sebagai saldo awal running balance
+func (model AccountModel) GetBalanceBefore(id int64, user_id string, date time.Time) (int64, error) {
+
+	var balance int64
+	query := `
+		SELECT a.opening_balance + COALESCE((
+			SELECT SUM(CASE
+				WHEN r.type IN ('pemasukan', 'transfer_masuk') THEN ` + baseNominalColumn + `
+				WHEN r.type IN ('pengeluaran', 'transfer_keluar') THEN -` + baseNominalColumn + `
+				ELSE 0
+			END)
+			FROM record r
+			WHERE r.account_id = a.id AND r.user_id = a.user_id AND r.date < ? AND r.deleted_at IS NULL
+		), 0)
+		FROM accounts a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.id = ? AND a.user_id = ?
+	`
+
+	err := model.db.QueryRow(query, date, id, user_id).Scan(&balance)
+
+	return balance, err
+}
+
+func (model AccountModel) EditAccount(data entities.Account) error {
+
+	query := `
+		UPDATE accounts SET
+		name = ?,
+		kind = ?,
+		opening_balance = ?,
+		updated_at = ?
+		WHERE id = ? AND user_id = ?
+	`
+
+	_, err := model.db.Exec(query, data.Name, data.Kind, data.OpeningBalance, time.Now(), data.Id, data.UserId)
+
+	return err
+}
+
+func (model AccountModel) DeleteAccount(id int64, user_id string) error {
+
+	// catatan di sampah ikut dihitung supaya akunnya masih ada saat catatan dikembalikan
+	var count int
+	query := `
+		SELECT (SELECT COUNT(*) FROM record WHERE account_id = ? AND user_id = ?)
+			+ (SELECT COUNT(*) FROM recurring WHERE account_id = ? AND user_id = ?)
+	`
+	if err := model.db.QueryRow(query, id, user_id, id, user_id).Scan(&count); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrAccountInUse
+	}
+
+	_, err := model.db.Exec("DELETE FROM accounts WHERE id = ? AND user_id = ?", id, user_id)
+
+	return err
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"time"
+)
+
+var (
+	// ErrAPITokenInvalid dikembalikan ketika token tidak dikenal
+	ErrAPITokenInvalid = errors.New("token tidak valid")
+	// ErrAPITokenExpired dikembalikan ketika token sudah melewati masa berlaku
+	ErrAPITokenExpired = errors.New("token sudah kedaluwarsa")
+)
+
+type APITokenModel struct {
+	db *sql.DB
+}
+
+func NewAPITokenModel(db *sql.DB) *APITokenModel {
+	return &APITokenModel{
+		db: db,
+	}
+}
+
+// AddAPIToken menyimpan token baru, yang disimpan hanya hash dari token
+func (model APITokenModel) AddAPIToken(data entities.APIToken, hash string) error {
+
+	query := `
+		INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scope, expires_at)
+		VALUES (?,?,?,?,?,?)
+	`
+
+	_, err := model.db.Exec(query, data.UserId, data.Name, hash, data.Prefix, data.Scope, data.ExpiresAt)
+
+	return err
+}
+
+func (model APITokenModel) FindAllAPIToken(user_id string) ([]entities.APIToken, error) {
+
+	query := `
+		SELECT id, user_id, name, token_prefix, scope, expires_at, last_used_at, created_at
+		FROM api_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := model.db.Query(query, user_id)
+	if err != nil {
+		return []entities.APIToken{}, err
+	}
+
+	defer rows.Close()
+
+	var tokens []entities.APIToken
+	for rows.Next() {
+		var token entities.APIToken
+		err := rows.Scan(
+			&token.Id,
+			&token.UserId,
+			&token.Name,
+			&token.Prefix,
+			&token.Scope,
+			&token.ExpiresAt,
+			&token.LastUsedAt,
+			&token.CreatedAt,
+		)
+		if err != nil {
+			return []entities.APIToken{}, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// DeleteAPIToken mencabut token, token milik user lain dianggap tidak ada (sql.ErrNoRows)
+func (model APITokenModel) DeleteAPIToken(id int64, user_id string) error {
+
+	result, err := model.db.Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", id, user_id)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AuthenticateAPIToken mencari pemilik token dari header Authorization dan mencatat
+// waktu terakhir token dipakai. writable bernilai true untuk token dengan akses write.
+func (model APITokenModel) AuthenticateAPIToken(plain string) (userId string, writable bool, err error) {
+
+	var token entities.APIToken
+
+	query := `
+		SELECT id, user_id, scope, expires_at, last_used_at
+		FROM api_tokens
+		WHERE token_hash = ?
+	`
+
+	err = model.db.QueryRow(query, helpers.HashAPIToken(plain)).Scan(
+		&token.Id,
+		&token.UserId,
+		&token.Scope,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, ErrAPITokenInvalid
+	} else if err != nil {
+		return "", false, err
+	}
+
+	now := time.Now()
+	if token.Expired(now) {
+		return "", false, ErrAPITokenExpired
+	}
+
+	// cukup dicatat per menit supaya tidak menulis ke database di setiap request
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= time.Minute {
+		model.db.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", now, token.Id)
+	}
+
+	return token.UserId, token.AllowsWrite(), nil
+}
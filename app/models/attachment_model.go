@@ -0,0 +1,204 @@
+package models
+
+import (
+	"database/sql"
+	"financial-record/entities"
+	"strings"
+)
+
+type AttachmentModel struct {
+	db *sql.DB
+}
+
+func NewAttachmentModel(db *sql.DB) *AttachmentModel {
+	return &AttachmentModel{
+		db: db,
+	}
+}
+
+const attachmentSelectQuery = `
+	SELECT id, user_id, record_id, storage_key, thumbnail_key, filename, content_type, size, created_at
+	FROM attachments
+`
+
+func scanAttachments(rows *sql.Rows) ([]entities.Attachment, error) {
+
+	defer rows.Close()
+
+	var attachments []entities.Attachment
+	for rows.Next() {
+		var attachment entities.Attachment
+		err := rows.Scan(
+			&attachment.Id,
+			&attachment.UserId,
+			&attachment.RecordId,
+			&attachment.StorageKey,
+			&attachment.ThumbnailKey,
+			&attachment.Filename,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.CreatedAt,
+		)
+		if err != nil {
+			return []entities.Attachment{}, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// FindAttachmentsByRecord mengambil lampiran satu catatan sesuai urutan upload
+func (model AttachmentModel) FindAttachmentsByRecord(recordId int64, userId string) ([]entities.Attachment, error) {
+
+	rows, err := model.db.Query(attachmentSelectQuery+" WHERE record_id = ? AND user_id = ? ORDER BY id", recordId, userId)
+	if err != nil {
+		return []entities.Attachment{}, err
+	}
+
+	return scanAttachments(rows)
+}
+
+// FindAttachmentsByRecords mengambil lampiran beberapa catatan sekaligus, dikelompokkan per id catatan
+func (model AttachmentModel) FindAttachmentsByRecords(userId string, recordIds []int64) (map[int64][]entities.Attachment, error) {
+
+	result := make(map[int64][]entities.Attachment)
+	if len(recordIds) == 0 {
+		return result, nil
+	}
+
+	args := []interface{}{userId}
+	for _, id := range recordIds {
+		args = append(args, id)
+	}
+	query := attachmentSelectQuery + " WHERE user_id = ? AND record_id IN (?" + strings.Repeat(",?", len(recordIds)-1) + ") ORDER BY record_id, id"
+
+	rows, err := model.db.Query(query, args...)
+	if err != nil {
+		return result, err
+	}
+
+	attachments, err := scanAttachments(rows)
+	for _, attachment := range attachments {
+		result[attachment.RecordId] = append(result[attachment.RecordId], attachment)
+	}
+
+	return result, err
+}
+
+// lampiran milik user lain dianggap tidak ada (sql.ErrNoRows)
+func (model AttachmentModel) FindAttachmentById(id int64, userId string) (*entities.Attachment, error) {
+
+	rows, err := model.db.Query(attachmentSelectQuery+" WHERE id = ? AND user_id = ?", id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := scanAttachments(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &attachments[0], nil
+}
+
+// GetStorageUsage menghitung total ukuran lampiran milik user dalam byte, dipakai untuk kuota
+func (model AttachmentModel) GetStorageUsage(userId string) (int64, error) {
+
+	var usage int64
+	err := model.db.QueryRow("SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = ?", userId).Scan(&usage)
+
+	return usage, err
+}
+
+// UpdateRecordAttachments menambah dan menghapus lampiran satu catatan dalam satu transaksi.
+// Lampiran yang dihapus hanya yang memang milik catatan dan user tersebut.
+func (model AttachmentModel) UpdateRecordAttachments(recordId int64, userId string, added []entities.Attachment, removedIds []int64) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range removedIds {
+		if _, err := tx.Exec("DELETE FROM attachments WHERE id = ? AND record_id = ? AND user_id = ?", id, recordId, userId); err != nil {
+			return err
+		}
+	}
+
+	for _, attachment := range added {
+		_, err := tx.Exec(`
+			INSERT INTO attachments (user_id, record_id, storage_key, thumbnail_key, filename, content_type, size)
+			VALUES (?,?,?,?,?,?,?)
+		`, userId, recordId, attachment.StorageKey, attachment.ThumbnailKey, attachment.Filename, attachment.ContentType, attachment.Size)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteRecordAttachments menghapus data semua lampiran catatan yang sudah dihapus,
+// file di storage dihapus oleh pemanggil
+func (model AttachmentModel) DeleteRecordAttachments(recordId int64, userId string) error {
+
+	_, err := model.db.Exec("DELETE FROM attachments WHERE record_id = ? AND user_id = ?", recordId, userId)
+
+	return err
+}
+
+// MoveLegacyAttachment menyimpan lampiran base64 lama yang sudah dipindah ke storage dan
+// mengosongkan kolom lamanya, updated_at catatan tidak berubah karena isinya tetap sama
+func (model AttachmentModel) MoveLegacyAttachment(attachment entities.Attachment) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO attachments (user_id, record_id, storage_key, thumbnail_key, filename, content_type, size)
+		VALUES (?,?,?,?,?,?,?)
+	`, attachment.UserId, attachment.RecordId, attachment.StorageKey, attachment.ThumbnailKey, attachment.Filename, attachment.ContentType, attachment.Size)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE record SET attachment = NULL, updated_at = updated_at WHERE id = ?", attachment.RecordId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindUnprocessedImages mengambil lampiran gambar yang diupload sebelum ada thumbnail,
+// limit lampiran dengan id lebih besar dari afterId, diurutkan per id
+func (model AttachmentModel) FindUnprocessedImages(afterId int64, limit int) ([]entities.Attachment, error) {
+
+	query := attachmentSelectQuery + `
+		WHERE id > ? AND thumbnail_key IS NULL AND content_type IN ('image/jpeg', 'image/png', 'image/webp')
+		ORDER BY id LIMIT ?
+	`
+
+	rows, err := model.db.Query(query, afterId, limit)
+	if err != nil {
+		return []entities.Attachment{}, err
+	}
+
+	return scanAttachments(rows)
+}
+
+// UpdateAttachmentFile mengganti file lampiran dengan versi yang sudah diproses
+func (model AttachmentModel) UpdateAttachmentFile(attachment entities.Attachment) error {
+
+	query := "UPDATE attachments SET storage_key = ?, thumbnail_key = ?, content_type = ?, size = ? WHERE id = ?"
+
+	_, err := model.db.Exec(query, attachment.StorageKey, attachment.ThumbnailKey, attachment.ContentType, attachment.Size, attachment.Id)
+
+	return err
+}
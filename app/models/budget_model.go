@@ -0,0 +1,165 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"financial-record/entities"
+	"time"
+)
+
+// ErrInvalidBudgetMonth dikembalikan ketika bulan anggaran tidak berformat "January 2006"
+var ErrInvalidBudgetMonth = errors.New("bulan anggaran tidak valid")
+
+type BudgetModel struct {
+	db *sql.DB
+}
+
+func NewBudgetModel(db *sql.DB) *BudgetModel {
+	return &BudgetModel{
+		db: db,
+	}
+}
+
+func (model BudgetModel) AddBudget(data entities.Budget) error {
+
+	query := `
+		INSERT INTO budgets (user_id, category_id, year, month, amount)
+		VALUES (?,?,?,?,?)
+	`
+
+	_, err := model.db.Exec(query, data.UserId, data.CategoryId, data.Year, data.Month, data.Amount)
+
+	return err
+}
+
+// FindBudgetUsage menampilkan anggaran bulan yang dipilih beserta total pengeluaran kategorinya,
+// pengeluaran dihitung dari tanggal 1 sampai akhir bulan memakai BETWEEN seperti FinancialModel
+func (model BudgetModel) FindBudgetUsage(user_id string, monthYear string) ([]entities.BudgetUsage, error) {
+
+	parsedDate, err := time.Parse("January 2006", monthYear)
+	if err != nil {
+		return []entities.BudgetUsage{}, ErrInvalidBudgetMonth
+	}
+
+	query := `
+		SELECT b.id, b.category_id, c.name, c.color, c.icon, b.amount,
+			COALESCE((
+				SELECT SUM(` + baseNominalColumn + `) FROM record r
+				JOIN users u ON u.id = r.user_id
+				WHERE r.user_id = b.user_id AND r.deleted_at IS NULL
+				AND r.type = 'pengeluaran'
+				AND r.category = c.name
+				AND r.date BETWEEN ? AND ?
+			), 0) AS used
+		FROM budgets b
+		JOIN categories c ON c.id = b.category_id
+		WHERE b.user_id = ?
+		AND b.month = ?
+		AND b.year = ?
+		ORDER BY c.name
+	`
+
+	monthEnd := parsedDate.AddDate(0, 1, -1)
+	rows, err := model.db.Query(query, parsedDate.Format("2006-01-02"), monthEnd.Format("2006-01-02"), user_id, parsedDate.Month(), parsedDate.Year())
+	if err != nil {
+		return []entities.BudgetUsage{}, err
+	}
+
+	defer rows.Close()
+
+	var usages []entities.BudgetUsage
+	for rows.Next() {
+		var usage entities.BudgetUsage
+		err := rows.Scan(
+			&usage.Id,
+			&usage.CategoryId,
+			&usage.CategoryName,
+			&usage.CategoryColor,
+			&usage.CategoryIcon,
+			&usage.Amount,
+			&usage.Used,
+		)
+		if err != nil {
+			return []entities.BudgetUsage{}, err
+		}
+
+		if usage.Amount > 0 {
+			usage.Percent = int(usage.Used * 100 / usage.Amount)
+		}
+		usage.Over = usage.Used > usage.Amount
+		usages = append(usages, usage)
+	}
+
+	return usages, rows.Err()
+}
+
+func (model BudgetModel) FindBudgetById(id int64, user_id string) (*entities.Budget, error) {
+
+	budget := &entities.Budget{}
+
+	query := `
+		SELECT id, user_id, category_id, year, month, amount
+		FROM budgets WHERE id = ? AND user_id = ?
+	`
+
+	err := model.db.QueryRow(query, id, user_id).Scan(
+		&budget.Id,
+		&budget.UserId,
+		&budget.CategoryId,
+		&budget.Year,
+		&budget.Month,
+		&budget.Amount,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// IsBudgetExists mengecek apakah kategori sudah punya anggaran di bulan tersebut
+func (model BudgetModel) IsBudgetExists(user_id string, categoryId int64, year int, month int) (bool, error) {
+
+	var count int
+	query := "SELECT COUNT(*) FROM budgets WHERE user_id = ? AND category_id = ? AND year = ? AND month = ?"
+
+	if err := model.db.QueryRow(query, user_id, categoryId, year, month).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (model BudgetModel) EditBudget(data entities.Budget) error {
+
+	query := "UPDATE budgets SET amount = ?, updated_at = ? WHERE id = ? AND user_id = ?"
+
+	_, err := model.db.Exec(query, data.Amount, time.Now(), data.Id, data.UserId)
+
+	return err
+}
+
+func (model BudgetModel) DeleteBudget(id int64, user_id string) error {
+
+	_, err := model.db.Exec("DELETE FROM budgets WHERE id = ? AND user_id = ?", id, user_id)
+
+	return err
+}
+
+// CopyBudgets menyalin anggaran dari satu bulan ke bulan lain, anggaran yang sudah ada tidak ditimpa
+func (model BudgetModel) CopyBudgets(user_id string, from time.Time, to time.Time) (int64, error) {
+
+	query := `
+		INSERT IGNORE INTO budgets (user_id, category_id, year, month, amount)
+		SELECT user_id, category_id, ?, ?, amount
+		FROM budgets
+		WHERE user_id = ? AND year = ? AND month = ?
+	`
+
+	result, err := model.db.Exec(query, to.Year(), to.Month(), user_id, from.Year(), from.Month())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
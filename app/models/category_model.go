@@ -0,0 +1,271 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"financial-record/entities"
+	"time"
+)
+
+// ErrCategoryInUse dikembalikan ketika kategori yang akan dihapus masih dipakai oleh catatan keuangan
+var ErrCategoryInUse = errors.New("kategori masih digunakan oleh catatan keuangan (termasuk yang ada di sampah) atau transaksi berulang, gabungkan ke kategori lain terlebih dahulu")
+
+// kategori bawaan untuk user baru
+var defaultCategories = []entities.Category{
+	{Type: "pemasukan", Name: "gaji", Color: "#198754", Icon: "bi-cash-stack"},
+	{Type: "pemasukan", Name: "tabungan", Color: "#0d6efd", Icon: "bi-piggy-bank"},
+	{Type: "pemasukan", Name: "hibah", Color: "#20c997", Icon: "bi-gift"},
+	{Type: "pengeluaran", Name: "belanja", Color: "#dc3545", Icon: "bi-cart"},
+	{Type: "pengeluaran", Name: "jajan", Color: "#fd7e14", Icon: "bi-cup-straw"},
+	{Type: "pengeluaran", Name: "bensin", Color: "#6f42c1", Icon: "bi-fuel-pump"},
+}
+
+type CategoryModel struct {
+	db *sql.DB
+}
+
+func NewCategoryModel(db *sql.DB) *CategoryModel {
+	return &CategoryModel{
+		db: db,
+	}
+}
+
+func (model CategoryModel) AddCategory(data entities.Category) error {
+
+	query := `
+		INSERT INTO categories (user_id, type, name, color, icon)
+		VALUES (?,?,?,?,?)
+	`
+
+	_, err := model.db.Exec(query, data.UserId, data.Type, data.Name, data.Color, data.Icon)
+
+	return err
+}
+
+func (model CategoryModel) AddDefaultCategories(user_id string) error {
+
+	query := `
+		INSERT IGNORE INTO categories (user_id, type, name, color, icon)
+		VALUES (?,?,?,?,?)
+	`
+
+	for _, category := range defaultCategories {
+		if _, err := model.db.Exec(query, user_id, category.Type, category.Name, category.Color, category.Icon); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindAllCategory menampilkan kategori milik user, categoryType kosong berarti semua tipe
+func (model CategoryModel) FindAllCategory(user_id string, categoryType string) ([]entities.Category, error) {
+
+	query := `
+		SELECT id, user_id, type, name, color, icon
+		FROM categories
+		WHERE user_id = ?
+	`
+	args := []interface{}{user_id}
+
+	if categoryType != "" {
+		query += " AND type = ?"
+		args = append(args, categoryType)
+	}
+	query += " ORDER BY type, name"
+
+	rows, err := model.db.Query(query, args...)
+	if err != nil {
+		return []entities.Category{}, err
+	}
+
+	defer rows.Close()
+
+	var categories []entities.Category
+	for rows.Next() {
+		var category entities.Category
+		err := rows.Scan(
+			&category.Id,
+			&category.UserId,
+			&category.Type,
+			&category.Name,
+			&category.Color,
+			&category.Icon,
+		)
+		if err != nil {
+			return []entities.Category{}, err
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (model CategoryModel) FindCategoryById(id int64, user_id string) (*entities.Category, error) {
+
+	category := &entities.Category{}
+
+	query := `
+		SELECT id, user_id, type, name, color, icon
+		FROM categories WHERE id = ? AND user_id = ?
+	`
+
+	err := model.db.QueryRow(query, id, user_id).Scan(
+		&category.Id,
+		&category.UserId,
+		&category.Type,
+		&category.Name,
+		&category.Color,
+		&category.Icon,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// IsUserCategory mengecek apakah kategori dengan nama dan tipe tersebut dimiliki oleh user
+func (model CategoryModel) IsUserCategory(user_id string, categoryType string, name string) (bool, error) {
+
+	var count int
+	query := "SELECT COUNT(*) FROM categories WHERE user_id = ? AND type = ? AND name = ?"
+
+	if err := model.db.QueryRow(query, user_id, categoryType, name).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// IsCategoryNameTaken mengecek nama kategori yang sama pada tipe yang sama, kecuali kategori dengan id exceptId
+func (model CategoryModel) IsCategoryNameTaken(user_id string, categoryType string, name string, exceptId int64) (bool, error) {
+
+	var count int
+	query := "SELECT COUNT(*) FROM categories WHERE user_id = ? AND type = ? AND name = ? AND id <> ?"
+
+	if err := model.db.QueryRow(query, user_id, categoryType, name, exceptId).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// EditCategory mengubah kategori dan mengarahkan ulang catatan keuangan yang memakai nama lama
+func (model CategoryModel) EditCategory(old entities.Category, data entities.Category) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE categories SET
+		name = ?,
+		color = ?,
+		icon = ?,
+		updated_at = ?
+		WHERE id = ? AND user_id = ?
+	`
+
+	if _, err := tx.Exec(query, data.Name, data.Color, data.Icon, time.Now(), old.Id, old.UserId); err != nil {
+		return err
+	}
+
+	if old.Name != data.Name {
+		query = "UPDATE record SET category = ? WHERE user_id = ? AND type = ? AND category = ?"
+		if _, err := tx.Exec(query, data.Name, old.UserId, old.Type, old.Name); err != nil {
+			return err
+		}
+
+		// transaksi berulang ikut diubah supaya catatan berikutnya memakai nama baru
+		query = "UPDATE recurring SET category = ? WHERE user_id = ? AND type = ? AND category = ?"
+		if _, err := tx.Exec(query, data.Name, old.UserId, old.Type, old.Name); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MergeCategory memindahkan semua catatan keuangan dari kategori source ke target lalu menghapus source
+func (model CategoryModel) MergeCategory(source entities.Category, target entities.Category) error {
+
+	if source.UserId != target.UserId || source.Type != target.Type {
+		return errors.New("kategori harus milik user yang sama dan bertipe sama")
+	}
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE record SET category = ? WHERE user_id = ? AND type = ? AND category = ?"
+	if _, err := tx.Exec(query, target.Name, source.UserId, source.Type, source.Name); err != nil {
+		return err
+	}
+
+	// transaksi berulang dipindah juga supaya catatan berikutnya masuk ke kategori target
+	query = "UPDATE recurring SET category = ? WHERE user_id = ? AND type = ? AND category = ?"
+	if _, err := tx.Exec(query, target.Name, source.UserId, source.Type, source.Name); err != nil {
+		return err
+	}
+
+	// anggaran kategori source dijumlahkan ke anggaran target di bulan yang sama
+	query = `
+		INSERT INTO budgets (user_id, category_id, year, month, amount)
+		SELECT user_id, ?, year, month, amount FROM budgets WHERE category_id = ?
+		ON DUPLICATE KEY UPDATE amount = budgets.amount + VALUES(amount)
+	`
+	if _, err := tx.Exec(query, target.Id, source.Id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM budgets WHERE category_id = ?", source.Id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = ? AND user_id = ?", source.Id, source.UserId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (model CategoryModel) DeleteCategory(category entities.Category) error {
+
+	// catatan di sampah ikut dihitung supaya kategorinya masih ada saat catatan dikembalikan,
+	// transaksi berulang juga supaya tidak terus membuat catatan dengan kategori yang sudah dihapus
+	var count int
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM record WHERE user_id = ? AND type = ? AND category = ?) +
+			(SELECT COUNT(*) FROM recurring WHERE user_id = ? AND type = ? AND category = ?)
+	`
+	err := model.db.QueryRow(query, category.UserId, category.Type, category.Name, category.UserId, category.Type, category.Name).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return ErrCategoryInUse
+	}
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM budgets WHERE category_id = ?", category.Id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = ? AND user_id = ?", category.Id, category.UserId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
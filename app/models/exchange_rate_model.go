@@ -0,0 +1,110 @@
+package models
+
+import (
+	"database/sql"
+	"financial-record/entities"
+)
+
+// baseNominalColumn mengonversi nominal catatan r ke mata uang utama user u memakai kurs terakhir
+// sampai tanggal catatan, hasilnya NULL kalau kurs belum diisi. Query yang memakainya harus join
+// tabel users sebagai u.
+const baseNominalColumn = `CASE WHEN r.currency = u.base_currency THEN r.nominal ELSE ROUND(r.nominal * (
+		SELECT er.rate FROM exchange_rates er
+		WHERE er.user_id = r.user_id
+		AND er.currency = r.currency
+		AND er.base_currency = u.base_currency
+		AND er.rate_date <= r.date
+		ORDER BY er.rate_date DESC
+		LIMIT 1
+	)) END`
+
+type ExchangeRateModel struct {
+	db *sql.DB
+}
+
+func NewExchangeRateModel(db *sql.DB) *ExchangeRateModel {
+	return &ExchangeRateModel{
+		db: db,
+	}
+}
+
+const upsertExchangeRateQuery = `
+	INSERT INTO exchange_rates (user_id, currency, base_currency, rate_date, rate)
+	VALUES (?,?,?,?,?)
+	ON DUPLICATE KEY UPDATE rate = VALUES(rate)
+`
+
+// AddExchangeRate menyimpan kurs, kurs di tanggal yang sama akan ditimpa
+func (model ExchangeRateModel) AddExchangeRate(data entities.ExchangeRate) error {
+
+	_, err := model.db.Exec(upsertExchangeRateQuery, data.UserId, data.Currency, data.BaseCurrency, data.Date, data.Rate)
+
+	return err
+}
+
+// ImportExchangeRates menyimpan semua kurs hasil import CSV dalam satu transaksi
+func (model ExchangeRateModel) ImportExchangeRates(rates []entities.ExchangeRate) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(upsertExchangeRateQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.Exec(rate.UserId, rate.Currency, rate.BaseCurrency, rate.Date, rate.Rate); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (model ExchangeRateModel) FindAllExchangeRate(user_id string) ([]entities.ExchangeRate, error) {
+
+	query := `
+		SELECT id, user_id, currency, base_currency, rate_date, rate
+		FROM exchange_rates
+		WHERE user_id = ?
+		ORDER BY rate_date DESC, currency
+	`
+
+	rows, err := model.db.Query(query, user_id)
+	if err != nil {
+		return []entities.ExchangeRate{}, err
+	}
+
+	defer rows.Close()
+
+	var rates []entities.ExchangeRate
+	for rows.Next() {
+		var rate entities.ExchangeRate
+		err := rows.Scan(
+			&rate.Id,
+			&rate.UserId,
+			&rate.Currency,
+			&rate.BaseCurrency,
+			&rate.Date,
+			&rate.Rate,
+		)
+		if err != nil {
+			return []entities.ExchangeRate{}, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+func (model ExchangeRateModel) DeleteExchangeRate(id int64, user_id string) error {
+
+	_, err := model.db.Exec("DELETE FROM exchange_rates WHERE id = ? AND user_id = ?", id, user_id)
+
+	return err
+}
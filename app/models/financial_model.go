@@ -2,7 +2,12 @@ package models
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"errors"
 	"financial-record/entities"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,48 +21,69 @@ func NewFinancalModel(db *sql.DB) *FinancialModel {
 	}
 }
 
-func (model FinancialModel) AddFinacialRecord(data entities.AddFinancial) error {
+// AddFinacialRecord menyimpan catatan baru dan mengembalikan id-nya
+func (model FinancialModel) AddFinacialRecord(data entities.AddFinancial) (int64, error) {
 
 	query := `
-		INSERT INTO record (user_id, date, type, category, nominal, description, attachment)
-		VALUES (?,?,?,?,?,?,?)
+		INSERT INTO record (user_id, account_id, date, type, category, nominal, currency, description)
+		VALUES (?,?,?,?,?,?,?,?)
 	`
 
-	_, err := model.db.Exec(
+	result, err := model.db.Exec(
 		query,
 		data.UserId,
+		data.AccountId,
 		data.Date,
 		data.Type,
 		data.Category,
 		data.Nominal,
+		data.Currency,
 		data.Description,
-		data.Attachment,
 	)
+	if err != nil {
+		return 0, err
+	}
 
-	return err
+	return result.LastInsertId()
+}
+
+// tambahkan filter periode, tipe dan akun ke query. Periode memakai BETWEEN supaya
+// index (user_id, date) terpakai
+func applyFinancialFilter(query string, args []interface{}, filter entities.FinancialFilter) (string, []interface{}) {
+
+	query += " AND r.date BETWEEN ? AND ?"
+	args = append(args, filter.StartDate.Format("2006-01-02"), filter.EndDate.Format("2006-01-02"))
+
+	if filter.PemasukanOnly {
+		query += " AND r.type = 'pemasukan'"
+	}
+	if filter.PengeluaranOnly {
+		query += " AND r.type = 'pengeluaran'"
+	}
+	if filter.AccountId != 0 {
+		query += " AND r.account_id = ?"
+		args = append(args, filter.AccountId)
+	}
+
+	return query, args
 }
 
-func (model FinancialModel) GetFinancialTotalNominal(user_id string, monthYear string, pemasukanOnly bool, pengeluaranOnly bool) (total_pemasukan int64, total_pengeluaran int64, err error) {
+// GetFinancialTotalNominal menghitung total dalam mata uang utama user, catatan yang kursnya
+// belum tersedia tidak ikut dihitung
+func (model FinancialModel) GetFinancialTotalNominal(filter entities.FinancialFilter) (total_pemasukan int64, total_pengeluaran int64, err error) {
 
-	parsedDate, _ := time.Parse("January 2006", monthYear)
 	query := `
 		SELECT
-			COALESCE(SUM(CASE WHEN type = 'pemasukan' THEN nominal ELSE 0 END), 0) AS total_pemasukan,
-			COALESCE(SUM(CASE WHEN type = 'pengeluaran' THEN nominal ELSE 0 END), 0) AS total_pengeluaran
-		FROM record
-		WHERE user_id = ?
-		AND MONTH(date) = ?
-	    AND YEAR(date) = ?
+			COALESCE(SUM(CASE WHEN r.type = 'pemasukan' THEN ` + baseNominalColumn + ` ELSE 0 END), 0) AS total_pemasukan,
+			COALESCE(SUM(CASE WHEN r.type = 'pengeluaran' THEN ` + baseNominalColumn + ` ELSE 0 END), 0) AS total_pengeluaran
+		FROM record r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = ? AND r.deleted_at IS NULL
 	`
 
-	if pemasukanOnly {
-		query += " AND type = 'pemasukan'"
-	}
-	if pengeluaranOnly {
-		query += " AND type = 'pengeluaran'"
-	}
+	query, args := applyFinancialFilter(query, []interface{}{filter.UserId}, filter)
 
-	err = model.db.QueryRow(query, user_id, parsedDate.Month(), parsedDate.Year()).Scan(&total_pemasukan, &total_pengeluaran)
+	err = model.db.QueryRow(query, args...).Scan(&total_pemasukan, &total_pengeluaran)
 
 	if err != nil {
 		return 0, 0, err
@@ -66,26 +92,501 @@ func (model FinancialModel) GetFinancialTotalNominal(user_id string, monthYear s
 	return total_pemasukan, total_pengeluaran, nil
 }
 
-func (model FinancialModel) FindAllFinancial(user_id string, monthYear string, pemasukanOnly bool, pengeluaranOnly bool) ([]entities.Financial, error) {
+// GetCategoryTotals menghitung jumlah catatan dan total per kategori dalam mata uang utama,
+// diurutkan per tipe lalu total terbesar. Transfer tidak ikut dihitung.
+func (model FinancialModel) GetCategoryTotals(filter entities.FinancialFilter) ([]entities.CategoryTotal, error) {
+
+	query := `
+		SELECT r.type, r.category, COUNT(*), COALESCE(SUM(` + baseNominalColumn + `), 0) AS total
+		FROM record r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = ? AND r.deleted_at IS NULL
+		AND r.type IN ('pemasukan', 'pengeluaran')
+	`
+
+	query, args := applyFinancialFilter(query, []interface{}{filter.UserId}, filter)
+	query += " GROUP BY r.type, r.category ORDER BY r.type, total DESC, r.category"
+
+	rows, err := model.db.Query(query, args...)
+	if err != nil {
+		return []entities.CategoryTotal{}, err
+	}
+
+	defer rows.Close()
+
+	var totals []entities.CategoryTotal
+	for rows.Next() {
+		var total entities.CategoryTotal
+		if err := rows.Scan(&total.Type, &total.Category, &total.Count, &total.Total); err != nil {
+			return []entities.CategoryTotal{}, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+// CountMissingExchangeRates menghitung catatan pemasukan/pengeluaran yang belum punya kurs ke mata uang utama
+func (model FinancialModel) CountMissingExchangeRates(filter entities.FinancialFilter) (int, error) {
+
+	query := `
+		SELECT COUNT(*)
+		FROM record r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = ? AND r.deleted_at IS NULL
+		AND r.type IN ('pemasukan', 'pengeluaran')
+		AND (` + baseNominalColumn + `) IS NULL
+	`
+
+	query, args := applyFinancialFilter(query, []interface{}{filter.UserId}, filter)
+
+	var count int
+	err := model.db.QueryRow(query, args...).Scan(&count)
+
+	return count, err
+}
+
+// kolom yang dipakai list keuangan, baik semua data maupun per halaman. Lampiran dibaca
+// terpisah dari tabel attachments hanya jika ditampilkan.
+const financialColumns = `
+	    SELECT r.id, r.date, r.type, r.category, COALESCE(c.color, '#6c757d'), COALESCE(c.icon, ''),
+	        COALESCE(r.account_id, 0), COALESCE(a.name, ''), r.transfer_id, r.nominal, r.currency,
+	        ` + baseNominalColumn + `, r.description, r.created_at`
+
+const financialJoins = `
+	    FROM record r
+	    JOIN users u ON u.id = r.user_id
+	    LEFT JOIN categories c ON c.user_id = r.user_id AND c.type = r.type AND c.name = r.category
+	    LEFT JOIN accounts a ON a.id = r.account_id
+	`
+
+// catatan yang ada di sampah tidak ikut ditampilkan maupun dihitung
+const financialSelectQuery = financialColumns + financialJoins + `
+	    WHERE r.user_id = ? AND r.deleted_at IS NULL
+	`
+
+// extra diisi dari kolom tambahan setelah kolom financialColumns
+func scanFinancial(rows *sql.Rows, extra ...interface{}) (entities.Financial, error) {
+
+	var financial entities.Financial
+	dest := []interface{}{
+		&financial.Id,
+		&financial.Date,
+		&financial.Type,
+		&financial.Category,
+		&financial.CategoryColor,
+		&financial.CategoryIcon,
+		&financial.AccountId,
+		&financial.AccountName,
+		&financial.TransferId,
+		&financial.Nominal,
+		&financial.Currency,
+		&financial.BaseNominal,
+		&financial.Description,
+		&financial.CreatedAt,
+	}
+	err := rows.Scan(append(dest, extra...)...)
+
+	return financial, err
+}
+
+func scanFinancials(rows *sql.Rows) ([]entities.Financial, error) {
+
+	defer rows.Close()
+
+	var financials []entities.Financial
+	for rows.Next() {
+		financial, err := scanFinancial(rows)
+		if err != nil {
+			return []entities.Financial{}, err
+		}
+		financials = append(financials, financial)
+	}
+
+	return financials, rows.Err()
+}
+
+func (model FinancialModel) FindAllFinancial(filter entities.FinancialFilter) ([]entities.Financial, error) {
 
 	// mysql
-	parsedDate, _ := time.Parse("January 2006", monthYear)
+	query, args := applyFinancialFilter(financialSelectQuery, []interface{}{filter.UserId}, filter)
+	query += " ORDER BY r.date, r.id"
+
+	rows, err := model.db.Query(query, args...)
+	if err != nil {
+		return []entities.Financial{}, err
+	}
+
+	return scanFinancials(rows)
+}
+
+// EachFinancial membaca catatan keuangan sesuai filter satu per satu tanpa menyimpan semuanya
+// di memory, dipakai untuk export file
+func (model FinancialModel) EachFinancial(filter entities.FinancialFilter, fn func(entities.Financial) error) error {
+
+	query, args := applyFinancialFilter(financialSelectQuery, []interface{}{filter.UserId}, filter)
+	query += " ORDER BY r.date, r.id"
+
+	rows, err := model.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		financial, err := scanFinancial(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(financial); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// nominal diurutkan dalam mata uang utama supaya catatan beda mata uang bisa dibandingkan,
+// catatan yang belum punya kurs dianggap 0
+const financialSortNominal = "COALESCE(" + baseNominalColumn + ", 0)"
+
+// kolom yang bisa dipakai untuk mengurutkan list keuangan
+var financialSortColumns = map[string]string{
+	"date":       "r.date",
+	"nominal":    financialSortNominal,
+	"category":   "r.category",
+	"created_at": "r.created_at",
+}
+
+// ErrInvalidCursor dikembalikan ketika posisi halaman dari url tidak bisa dibaca
+var ErrInvalidCursor = errors.New("posisi halaman tidak valid")
+
+// EncodeFinancialCursor menyimpan nilai kolom urutan dan id sebuah catatan sebagai posisi halaman
+func EncodeFinancialCursor(sort string, financial entities.Financial) string {
+
+	var value string
+	switch sort {
+	case "nominal":
+		value = "0"
+		if financial.BaseNominal != nil {
+			value = strconv.FormatInt(*financial.BaseNominal, 10)
+		}
+	case "category":
+		value = financial.Category
+	case "created_at":
+		value = financial.CreatedAt.Format(time.RFC3339)
+	default:
+		value = financial.Date.Format("2006-01-02")
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value + "|" + strconv.FormatInt(financial.Id, 10)))
+}
+
+func decodeFinancialCursor(sort string, cursor string) (interface{}, int64, error) {
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	index := strings.LastIndex(string(decoded), "|")
+	if index < 0 {
+		return nil, 0, ErrInvalidCursor
+	}
+	rawValue := string(decoded[:index])
+	id, err := strconv.ParseInt(string(decoded[index+1:]), 10, 64)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	var value interface{}
+	switch sort {
+	case "nominal":
+		value, err = strconv.ParseInt(rawValue, 10, 64)
+	case "created_at":
+		value, err = time.Parse(time.RFC3339, rawValue)
+	case "category":
+		value = rawValue
+	default:
+		_, err = time.Parse("2006-01-02", rawValue)
+		value = rawValue
+	}
+
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	return value, id, nil
+}
+
+// FindFinancialPage menampilkan satu halaman list keuangan memakai keyset pagination,
+// id dipakai sebagai penentu urutan kedua supaya posisi halaman selalu stabil.
+// Nilai kedua bernilai true jika masih ada halaman berikutnya searah page.Backward.
+func (model FinancialModel) FindFinancialPage(filter entities.FinancialFilter, page entities.FinancialPage) ([]entities.Financial, bool, error) {
+
+	column, ok := financialSortColumns[page.Sort]
+	if !ok {
+		page.Sort, column = "date", "r.date"
+	}
+
+	// halaman sebelumnya diambil dengan urutan terbalik lalu dibalik lagi
+	desc := page.Desc != page.Backward
+
+	query, args := applyFinancialFilter(financialSelectQuery, []interface{}{filter.UserId}, filter)
+
+	if page.Cursor != "" {
+		value, id, err := decodeFinancialCursor(page.Sort, page.Cursor)
+		if err != nil {
+			return []entities.Financial{}, false, err
+		}
+		operator := ">"
+		if desc {
+			operator = "<"
+		}
+		query += fmt.Sprintf(" AND (%s %s ? OR (%s = ? AND r.id %s ?))", column, operator, column, operator)
+		args = append(args, value, value, id)
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, r.id %s LIMIT ?", column, direction, direction)
+	args = append(args, page.Limit+1)
+
+	rows, err := model.db.Query(query, args...)
+	if err != nil {
+		return []entities.Financial{}, false, err
+	}
+
+	financials, err := scanFinancials(rows)
+	if err != nil {
+		return []entities.Financial{}, false, err
+	}
+
+	hasMore := len(financials) > page.Limit
+	if hasMore {
+		financials = financials[:page.Limit]
+	}
+	if page.Backward {
+		for i, j := 0, len(financials)-1; i < j; i, j = i+1, j-1 {
+			financials[i], financials[j] = financials[j], financials[i]
+		}
+	}
+
+	return financials, hasMore, nil
+}
+
+// GetNetNominalBefore menghitung perubahan saldo dari catatan di periode filter yang urutannya
+// (tanggal, id) sebelum catatan tertentu, dipakai sebagai saldo awal running balance per halaman
+func (model FinancialModel) GetNetNominalBefore(filter entities.FinancialFilter, financial entities.Financial) (int64, error) {
+
+	query := `
+		SELECT COALESCE(SUM(CASE
+			WHEN r.type IN ('pemasukan', 'transfer_masuk') THEN ` + baseNominalColumn + `
+			WHEN r.type IN ('pengeluaran', 'transfer_keluar') THEN -` + baseNominalColumn + `
+			ELSE 0
+		END), 0)
+		FROM record r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = ? AND r.deleted_at IS NULL
+	`
+
+	query, args := applyFinancialFilter(query, []interface{}{filter.UserId}, filter)
+	query += " AND (r.date < ? OR (r.date = ? AND r.id < ?))"
+	date := financial.Date.Format("2006-01-02")
+	args = append(args, date, date, financial.Id)
+
+	var total int64
+	err := model.db.QueryRow(query, args...).Scan(&total)
+
+	return total, err
+}
+
+// escape karakter wildcard LIKE supaya % dan _ yang diketik user dicari apa adanya
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SearchFinancial mencari catatan di semua bulan. Kata dicocokkan ke keterangan memakai index
+// FULLTEXT dan ke nama kategori, hasil diurutkan dari yang paling relevan lalu tanggal terbaru.
+func (model FinancialModel) SearchFinancial(search entities.FinancialSearch) ([]entities.Financial, error) {
+
+	query := financialSelectQuery
+	args := []interface{}{search.UserId}
+
+	relevance := "0"
+	var relevanceArgs []interface{}
+	if len(search.Terms) > 0 {
+		// setiap kata dicari sebagai awalan kata, contoh "parkir*"
+		var booleanTerms []string
+		var categoryConditions []string
+		var categoryArgs []interface{}
+		for _, term := range search.Terms {
+			booleanTerms = append(booleanTerms, term+"*")
+			categoryConditions = append(categoryConditions, `r.category LIKE ? ESCAPE '\\'`)
+			categoryArgs = append(categoryArgs, "%"+likeEscaper.Replace(term)+"%")
+		}
+		booleanQuery := strings.Join(booleanTerms, " ")
+
+		query += " AND (MATCH(r.description) AGAINST (? IN BOOLEAN MODE) OR " + strings.Join(categoryConditions, " OR ") + ")"
+		args = append(args, booleanQuery)
+		args = append(args, categoryArgs...)
+
+		relevance = "MATCH(r.description) AGAINST (? IN BOOLEAN MODE)"
+		relevanceArgs = append(relevanceArgs, booleanQuery)
+	}
+
+	if len(search.Nominals) > 0 {
+		query += " AND r.nominal IN (?" + strings.Repeat(", ?", len(search.Nominals)-1) + ")"
+		for _, nominal := range search.Nominals {
+			args = append(args, nominal)
+		}
+	}
+	if search.NominalMin != nil {
+		query += " AND r.nominal >= ?"
+		args = append(args, *search.NominalMin)
+	}
+	if search.NominalMax != nil {
+		query += " AND r.nominal <= ?"
+		args = append(args, *search.NominalMax)
+	}
+	if search.StartDate != nil {
+		query += " AND r.date >= ?"
+		args = append(args, search.StartDate.Format("2006-01-02"))
+	}
+	if search.EndDate != nil {
+		query += " AND r.date <= ?"
+		args = append(args, search.EndDate.Format("2006-01-02"))
+	}
+
+	query += " ORDER BY " + relevance + " DESC, r.date DESC, r.id DESC LIMIT ?"
+	args = append(args, relevanceArgs...)
+	args = append(args, search.Limit)
+
+	rows, err := model.db.Query(query, args...)
+	if err != nil {
+		return []entities.Financial{}, err
+	}
+
+	return scanFinancials(rows)
+}
+
+// ImportFinancialRecords menyimpan semua catatan hasil import dalam satu transaksi,
+// kalau satu baris gagal tidak ada yang tersimpan
+func (model FinancialModel) ImportFinancialRecords(records []entities.AddFinancial) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO record (user_id, account_id, date, type, category, nominal, currency, description, external_id)
+		VALUES (?,?,?,?,?,?,?,?,?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, data := range records {
+		_, err := stmt.Exec(data.UserId, data.AccountId, data.Date, data.Type, data.Category, data.Nominal, data.Currency, data.Description, data.ExternalId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FinancialImportKey adalah kunci untuk mendeteksi catatan yang sudah ada saat import
+func FinancialImportKey(date time.Time, financialType string, nominal int64) string {
+	return date.Format("2006-01-02") + "|" + financialType + "|" + strconv.FormatInt(nominal, 10)
+}
+
+// CountFinancialImportKeys menghitung catatan di akun per tanggal, tipe dan nominal
+// antara startDate dan endDate, dipakai untuk menandai baris import yang duplikat
+func (model FinancialModel) CountFinancialImportKeys(userId string, accountId int64, startDate, endDate time.Time) (map[string]int, error) {
+
+	query := `
+		SELECT date, type, nominal
+		FROM record
+		WHERE user_id = ? AND account_id = ? AND date BETWEEN ? AND ? AND deleted_at IS NULL
+	`
+
+	rows, err := model.db.Query(query, userId, accountId, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	keys := make(map[string]int)
+	for rows.Next() {
+		var date time.Time
+		var financialType string
+		var nominal int64
+		if err := rows.Scan(&date, &financialType, &nominal); err != nil {
+			return nil, err
+		}
+		keys[FinancialImportKey(date, financialType, nominal)]++
+	}
+
+	return keys, rows.Err()
+}
+
+// FindFinancialExternalIds mencari id transaksi bank (FITID) yang sudah tersimpan di akun
+// antara startDate dan endDate beserta kunci import catatannya
+func (model FinancialModel) FindFinancialExternalIds(userId string, accountId int64, startDate, endDate time.Time) (map[string]string, error) {
+
 	query := `
-	    SELECT id, date, type, category, nominal, description, attachment
-	    FROM record
-	    WHERE user_id = ?
-	    AND MONTH(date) = ?
-	    AND YEAR(date) = ?
+		SELECT external_id, date, type, nominal
+		FROM record
+		WHERE user_id = ? AND account_id = ? AND date BETWEEN ? AND ? AND external_id IS NOT NULL AND deleted_at IS NULL
 	`
 
-	if pemasukanOnly {
-		query += " AND type = 'pemasukan'"
+	rows, err := model.db.Query(query, userId, accountId, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
 	}
-	if pengeluaranOnly {
-		query += " AND type = 'pengeluaran'"
+
+	defer rows.Close()
+
+	externalIds := make(map[string]string)
+	for rows.Next() {
+		var externalId, financialType string
+		var date time.Time
+		var nominal int64
+		if err := rows.Scan(&externalId, &date, &financialType, &nominal); err != nil {
+			return nil, err
+		}
+		externalIds[externalId] = FinancialImportKey(date, financialType, nominal)
 	}
 
-	rows, err := model.db.Query(query, user_id, parsedDate.Month(), parsedDate.Year())
+	return externalIds, rows.Err()
+}
+
+// DeleteFinancialRecord memindahkan catatan ke sampah, catatan masih bisa dikembalikan
+// sampai dihapus permanen atau melewati masa simpan sampah
+func (model FinancialModel) DeleteFinancialRecord(id int64, userId string) error {
+
+	query := "UPDATE record SET deleted_at = ?, updated_at = updated_at WHERE id = ? AND user_id = ? AND deleted_at IS NULL"
+
+	_, err := model.db.Exec(query, time.Now(), id, userId)
+
+	return err
+}
+
+// FindDeletedFinancial menampilkan isi sampah user, yang terakhir dihapus di atas
+func (model FinancialModel) FindDeletedFinancial(userId string) ([]entities.Financial, error) {
+
+	query := financialColumns + ", r.deleted_at" + financialJoins + `
+		WHERE r.user_id = ? AND r.deleted_at IS NOT NULL
+		ORDER BY r.deleted_at DESC, r.id DESC
+	`
+
+	rows, err := model.db.Query(query, userId)
 	if err != nil {
 		return []entities.Financial{}, err
 	}
@@ -94,51 +595,163 @@ func (model FinancialModel) FindAllFinancial(user_id string, monthYear string, p
 
 	var financials []entities.Financial
 	for rows.Next() {
-		var financial entities.Financial
-		err := rows.Scan(
-			&financial.Id,
-			&financial.Date,
-			&financial.Type,
-			&financial.Category,
-			&financial.Nominal,
-			&financial.Description,
-			&financial.Attachment,
-		)
+		var deletedAt time.Time
+		financial, err := scanFinancial(rows, &deletedAt)
 		if err != nil {
 			return []entities.Financial{}, err
 		}
+		financial.DeletedAt = &deletedAt
 		financials = append(financials, financial)
 	}
 
 	return financials, rows.Err()
 }
 
-func (model FinancialModel) DeleteFinancialRecord(id int16) error {
+// RestoreFinancialRecord mengembalikan catatan dari sampah, catatan transfer dikembalikan
+// bersama pasangannya. sql.ErrNoRows jika catatan tidak ada di sampah user.
+func (model FinancialModel) RestoreFinancialRecord(id int64, userId string) error {
 
-	query := "DELETE FROM record WHERE id = ?"
+	var transferId *int64
+	err := model.db.QueryRow("SELECT transfer_id FROM record WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userId).Scan(&transferId)
+	if err != nil {
+		return err
+	}
 
-	_, err := model.db.Exec(query, id)
+	query := "UPDATE record SET deleted_at = NULL, updated_at = updated_at WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL"
+	args := []interface{}{id, userId}
+	if transferId != nil {
+		query = "UPDATE record SET deleted_at = NULL, updated_at = updated_at WHERE transfer_id = ? AND user_id = ? AND deleted_at IS NOT NULL"
+		args = []interface{}{*transferId, userId}
+	}
+
+	result, err := model.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return requireAffectedRow(result)
+}
+
+// PurgeFinancialRecord menghapus permanen catatan di sampah beserta data lampirannya dalam
+// satu transaksi, catatan transfer dihapus bersama pasangan dan data transfernya. Lampiran
+// semua catatan yang terhapus dikembalikan supaya filenya dihapus oleh pemanggil.
+// sql.ErrNoRows jika catatan tidak ada di sampah user.
+func (model FinancialModel) PurgeFinancialRecord(id int64, userId string) ([]entities.Attachment, error) {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var transferId *int64
+	err = tx.QueryRow("SELECT transfer_id FROM record WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userId).Scan(&transferId)
+	if err != nil {
+		return nil, err
+	}
+
+	// lampiran catatan transfer diambil dari kedua sisinya
+	where, args := " WHERE record_id = ? AND user_id = ?", []interface{}{id, userId}
+	if transferId != nil {
+		where, args = " WHERE user_id = ? AND record_id IN (SELECT id FROM record WHERE transfer_id = ? AND user_id = ?)", []interface{}{userId, *transferId, userId}
+	}
+
+	rows, err := tx.Query(attachmentSelectQuery+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := scanAttachments(rows)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM attachments"+where, args...); err != nil {
+		return nil, err
+	}
+
+	if transferId == nil {
+		if _, err := tx.Exec("DELETE FROM record WHERE id = ? AND user_id = ?", id, userId); err != nil {
+			return nil, err
+		}
+		return attachments, tx.Commit()
+	}
+
+	if _, err := tx.Exec("DELETE FROM record WHERE transfer_id = ? AND user_id = ?", *transferId, userId); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM transfers WHERE id = ? AND user_id = ?", *transferId, userId); err != nil {
+		return nil, err
+	}
+
+	return attachments, tx.Commit()
+}
+
+// RemoveFinancialRecord menghapus permanen catatan tanpa lewat sampah, hanya untuk
+// membatalkan catatan yang gagal disimpan lengkap
+func (model FinancialModel) RemoveFinancialRecord(id int64, userId string) error {
+
+	_, err := model.db.Exec("DELETE FROM record WHERE id = ? AND user_id = ?", id, userId)
 
 	return err
 }
 
-func (model FinancialModel) FindFinancialById(id int16) (*entities.Financial, error) {
+// FindExpiredDeletedRecords mengambil limit catatan yang masuk sampah sebelum deletedBefore,
+// hanya id dan user id yang diisi
+func (model FinancialModel) FindExpiredDeletedRecords(deletedBefore time.Time, limit int) ([]entities.Financial, error) {
+
+	query := "SELECT id, user_id FROM record WHERE deleted_at < ? ORDER BY deleted_at, id LIMIT ?"
+
+	rows, err := model.db.Query(query, deletedBefore, limit)
+	if err != nil {
+		return []entities.Financial{}, err
+	}
+
+	defer rows.Close()
+
+	var financials []entities.Financial
+	for rows.Next() {
+		var financial entities.Financial
+		if err := rows.Scan(&financial.Id, &financial.UserId); err != nil {
+			return []entities.Financial{}, err
+		}
+		financials = append(financials, financial)
+	}
+
+	return financials, rows.Err()
+}
+
+// sql.ErrNoRows jika UPDATE/DELETE tidak mengubah baris apapun
+func requireAffectedRow(result sql.Result) error {
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// catatan milik user lain dianggap tidak ada (sql.ErrNoRows)
+func (model FinancialModel) FindFinancialById(id int64, userId string) (*entities.Financial, error) {
 
 	financial := &entities.Financial{}
 
 	query := `
-		SELECT id, date, type, category, nominal, description, attachment
-		FROM record WHERE id = ?
+		SELECT id, date, type, category, COALESCE(account_id, 0), transfer_id, nominal, currency, description
+		FROM record WHERE id = ? AND user_id = ? AND deleted_at IS NULL
 	`
 
-	err := model.db.QueryRow(query, id).Scan(
+	err := model.db.QueryRow(query, id, userId).Scan(
 		&financial.Id,
 		&financial.Date,
 		&financial.Type,
 		&financial.Category,
+		&financial.AccountId,
+		&financial.TransferId,
 		&financial.Nominal,
+		&financial.Currency,
 		&financial.Description,
-		&financial.Attachment,
 	)
 
 	if err != nil {
@@ -154,11 +767,12 @@ func (model FinancialModel) EditFinancialRecord(data entities.AddFinancial) erro
 		date = ?, 
 		type = ?, 
 		category = ?, 
+		account_id = ?, 
 		nominal = ?, 
+		currency = ?, 
 		description = ?, 
-		attachment = ?, 
 		updated_at = ? 
-		WHERE id = ?
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL
 	`
 
 	_, err := model.db.Exec(
@@ -166,12 +780,66 @@ func (model FinancialModel) EditFinancialRecord(data entities.AddFinancial) erro
 		data.Date,
 		data.Type,
 		data.Category,
+		data.AccountId,
 		data.Nominal,
+		data.Currency,
 		data.Description,
-		data.Attachment,
 		time.Now(),
 		data.Id,
+		data.UserId,
 	)
 
 	return err
 }
+
+// ErrLegacyAttachmentColumnDropped dikembalikan ketika kolom record.attachment sudah dihapus oleh
+// 017_drop_record_attachment.sql sehingga lampiran lama tidak bisa dipindahkan lagi
+var ErrLegacyAttachmentColumnDropped = errors.New("kolom record.attachment sudah tidak ada, -migrate-attachments harus dijalankan setelah 016_record_soft_delete.sql dan sebelum 017_drop_record_attachment.sql")
+
+// CheckLegacyAttachmentColumn memastikan kolom base64 lama record.attachment masih ada
+func (model FinancialModel) CheckLegacyAttachmentColumn() error {
+
+	query := `
+		SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'record' AND COLUMN_NAME = 'attachment'
+	`
+
+	var count int
+	if err := model.db.QueryRow(query).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLegacyAttachmentColumnDropped
+	}
+
+	return nil
+}
+
+// FindLegacyAttachments mengambil lampiran base64 lama yang belum dipindah ke storage,
+// limit catatan dengan id lebih besar dari afterId, diurutkan per id
+func (model FinancialModel) FindLegacyAttachments(afterId int64, limit int) ([]entities.LegacyAttachment, error) {
+
+	query := `
+		SELECT id, user_id, attachment FROM record
+		WHERE id > ? AND attachment IS NOT NULL AND attachment <> ''
+		ORDER BY id LIMIT ?
+	`
+
+	rows, err := model.db.Query(query, afterId, limit)
+	if err != nil {
+		return []entities.LegacyAttachment{}, err
+	}
+
+	defer rows.Close()
+
+	var attachments []entities.LegacyAttachment
+	for rows.Next() {
+		var attachment entities.LegacyAttachment
+		if err := rows.Scan(&attachment.Id, &attachment.UserId, &attachment.Attachment); err != nil {
+			return []entities.LegacyAttachment{}, err
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
@@ -0,0 +1,434 @@
+package models
+
+import (
+	"database/sql"
+	"financial-record/entities"
+	"financial-record/helpers"
+	"time"
+)
+
+type RecurringModel struct {
+	db *sql.DB
+}
+
+func NewRecurringModel(db *sql.DB) *RecurringModel {
+	return &RecurringModel{
+		db: db,
+	}
+}
+
+// potong jam supaya perbandingan tanggal sesuai kolom DATE
+func truncateDate(date time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+func (model RecurringModel) AddRecurring(data entities.Recurring) error {
+
+	query := `
+		INSERT INTO recurring (user_id, type, category, account_id, nominal, currency, description, frequency, start_date, end_date, occurrences)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?)
+	`
+
+	_, err := model.db.Exec(
+		query,
+		data.UserId,
+		data.Type,
+		data.Category,
+		data.AccountId,
+		data.Nominal,
+		data.Currency,
+		data.Description,
+		data.Frequency,
+		data.StartDate,
+		data.EndDate,
+		data.Occurrences,
+	)
+
+	return err
+}
+
+const recurringColumns = `id, user_id, type, category, COALESCE(account_id, 0), nominal, currency, description, frequency, start_date, end_date, occurrences, paused`
+
+func scanRecurring(scanner interface{ Scan(...interface{}) error }, recurring *entities.Recurring) error {
+	return scanner.Scan(
+		&recurring.Id,
+		&recurring.UserId,
+		&recurring.Type,
+		&recurring.Category,
+		&recurring.AccountId,
+		&recurring.Nominal,
+		&recurring.Currency,
+		&recurring.Description,
+		&recurring.Frequency,
+		&recurring.StartDate,
+		&recurring.EndDate,
+		&recurring.Occurrences,
+		&recurring.Paused,
+	)
+}
+
+func (model RecurringModel) queryRecurring(query string, args ...interface{}) ([]entities.Recurring, error) {
+
+	rows, err := model.db.Query(query, args...)
+	if err != nil {
+		return []entities.Recurring{}, err
+	}
+
+	defer rows.Close()
+
+	var recurrings []entities.Recurring
+	for rows.Next() {
+		var recurring entities.Recurring
+		if err := scanRecurring(rows, &recurring); err != nil {
+			return []entities.Recurring{}, err
+		}
+		recurrings = append(recurrings, recurring)
+	}
+
+	return recurrings, rows.Err()
+}
+
+func (model RecurringModel) FindAllRecurring(user_id string) ([]entities.Recurring, error) {
+
+	query := "SELECT " + recurringColumns + " FROM recurring WHERE user_id = ? ORDER BY start_date"
+
+	return model.queryRecurring(query, user_id)
+}
+
+func (model RecurringModel) FindRecurringById(id int64, user_id string) (*entities.Recurring, error) {
+
+	recurring := &entities.Recurring{}
+	query := "SELECT " + recurringColumns + " FROM recurring WHERE id = ? AND user_id = ?"
+
+	if err := scanRecurring(model.db.QueryRow(query, id, user_id), recurring); err != nil {
+		return nil, err
+	}
+	return recurring, nil
+}
+
+// EditRecurring mengubah seluruh seri, perubahan hanya berlaku untuk pengulangan setelah hari ini
+func (model RecurringModel) EditRecurring(data entities.Recurring) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE recurring SET
+		type = ?,
+		category = ?,
+		account_id = ?,
+		nominal = ?,
+		currency = ?,
+		description = ?,
+		frequency = ?,
+		start_date = ?,
+		end_date = ?,
+		occurrences = ?,
+		updated_at = ?
+		WHERE id = ? AND user_id = ?
+	`
+
+	_, err = tx.Exec(
+		query,
+		data.Type,
+		data.Category,
+		data.AccountId,
+		data.Nominal,
+		data.Currency,
+		data.Description,
+		data.Frequency,
+		data.StartDate,
+		data.EndDate,
+		data.Occurrences,
+		time.Now(),
+		data.Id,
+		data.UserId,
+	)
+	if err != nil {
+		return err
+	}
+
+	// jadwal baru tidak boleh membuat catatan untuk tanggal yang sudah lewat
+	if err := skipDueOccurrences(tx, data, time.Now()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetRecurringPaused menghentikan sementara atau melanjutkan seri, pengulangan sebelum
+// hari ini tidak akan dibuat ketika dilanjutkan, pengulangan hari ini tetap dibuat
+func (model RecurringModel) SetRecurringPaused(data entities.Recurring, paused bool) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE recurring SET paused = ?, updated_at = ? WHERE id = ? AND user_id = ?"
+	if _, err := tx.Exec(query, paused, time.Now(), data.Id, data.UserId); err != nil {
+		return err
+	}
+
+	if !paused {
+		if err := skipDueOccurrences(tx, data, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// tandai pengulangan sebelum hari ini sebagai dilewati, pengulangan hari ini tetap dibuat
+func skipDueOccurrences(tx *sql.Tx, data entities.Recurring, now time.Time) error {
+
+	yesterday := truncateDate(now, data.StartDate.Location()).AddDate(0, 0, -1)
+	dates := helpers.RecurrenceDates(data.StartDate, data.Frequency, data.EndDate, data.Occurrences, yesterday)
+
+	query := `
+		INSERT IGNORE INTO recurring_occurrences (recurring_id, occurrence_date, status)
+		VALUES (?,?,'skipped')
+	`
+	for _, date := range dates {
+		if _, err := tx.Exec(query, data.Id, date); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteRecurring menghapus seri beserta jadwalnya, catatan keuangan yang sudah dibuat tetap disimpan
+func (model RecurringModel) DeleteRecurring(id int64, user_id string) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE o FROM recurring_occurrences o
+		JOIN recurring r ON r.id = o.recurring_id
+		WHERE r.id = ? AND r.user_id = ?
+	`
+	if _, err := tx.Exec(query, id, user_id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM recurring WHERE id = ? AND user_id = ?", id, user_id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindOccurrences menampilkan pengulangan yang sudah tercatat (dibuat, dilewati atau diubah) per tanggal
+func (model RecurringModel) FindOccurrences(recurringId int64) (map[string]entities.RecurringOccurrence, error) {
+
+	query := `
+		SELECT recurring_id, occurrence_date, status, record_id, nominal, description
+		FROM recurring_occurrences
+		WHERE recurring_id = ?
+	`
+
+	rows, err := model.db.Query(query, recurringId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	occurrences := make(map[string]entities.RecurringOccurrence)
+	for rows.Next() {
+		var occurrence entities.RecurringOccurrence
+		err := rows.Scan(
+			&occurrence.RecurringId,
+			&occurrence.Date,
+			&occurrence.Status,
+			&occurrence.RecordId,
+			&occurrence.Nominal,
+			&occurrence.Description,
+		)
+		if err != nil {
+			return nil, err
+		}
+		occurrences[occurrence.Date.Format("2006-01-02")] = occurrence
+	}
+
+	return occurrences, rows.Err()
+}
+
+// SkipOccurrence melewati satu pengulangan yang belum dibuat
+func (model RecurringModel) SkipOccurrence(recurringId int64, date time.Time) error {
+
+	query := `
+		INSERT INTO recurring_occurrences (recurring_id, occurrence_date, status)
+		VALUES (?,?,'skipped')
+		ON DUPLICATE KEY UPDATE status = IF(record_id IS NULL, 'skipped', status)
+	`
+
+	_, err := model.db.Exec(query, recurringId, date)
+
+	return err
+}
+
+// OverrideOccurrence mengubah nominal dan keterangan satu pengulangan yang belum dibuat
+func (model RecurringModel) OverrideOccurrence(data entities.RecurringOccurrence) error {
+
+	query := `
+		INSERT INTO recurring_occurrences (recurring_id, occurrence_date, status, nominal, description)
+		VALUES (?,?,'overridden',?,?)
+		ON DUPLICATE KEY UPDATE
+			status = IF(record_id IS NULL, 'overridden', status),
+			nominal = IF(record_id IS NULL, VALUES(nominal), nominal),
+			description = IF(record_id IS NULL, VALUES(description), description)
+	`
+
+	_, err := model.db.Exec(query, data.RecurringId, data.Date, data.Nominal, data.Description)
+
+	return err
+}
+
+// RestoreOccurrence mengembalikan pengulangan yang dilewati atau diubah ke jadwal seri
+func (model RecurringModel) RestoreOccurrence(recurringId int64, date time.Time) error {
+
+	query := "DELETE FROM recurring_occurrences WHERE recurring_id = ? AND occurrence_date = ? AND record_id IS NULL"
+
+	_, err := model.db.Exec(query, recurringId, date)
+
+	return err
+}
+
+// MaterializeDueRecurring membuat catatan keuangan untuk semua pengulangan yang sudah jatuh tempo.
+// Aman dijalankan berulang kali, setiap tanggal hanya dibuat satu kali.
+func (model RecurringModel) MaterializeDueRecurring(now time.Time) (int, error) {
+
+	query := "SELECT " + recurringColumns + " FROM recurring WHERE paused = 0 AND start_date <= ?"
+	recurrings, err := model.queryRecurring(query, now)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	var firstErr error
+	for _, recurring := range recurrings {
+		created, err := model.materializeRecurring(recurring, now)
+		total += created
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return total, firstErr
+}
+
+func (model RecurringModel) materializeRecurring(recurring entities.Recurring, now time.Time) (int, error) {
+
+	today := truncateDate(now, recurring.StartDate.Location())
+	dates := helpers.RecurrenceDates(recurring.StartDate, recurring.Frequency, recurring.EndDate, recurring.Occurrences, today)
+	if len(dates) == 0 {
+		return 0, nil
+	}
+
+	occurrences, err := model.FindOccurrences(recurring.Id)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, date := range dates {
+		if occurrence, ok := occurrences[date.Format("2006-01-02")]; ok {
+			if occurrence.RecordId != nil || occurrence.Status == "skipped" {
+				continue
+			}
+		}
+
+		ok, err := model.materializeOccurrence(recurring, date)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+func (model RecurringModel) materializeOccurrence(recurring entities.Recurring, date time.Time) (bool, error) {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// pastikan baris jadwal ada lalu kunci supaya tidak dibuat dua kali
+	query := `
+		INSERT IGNORE INTO recurring_occurrences (recurring_id, occurrence_date, status)
+		VALUES (?,?,'scheduled')
+	`
+	if _, err := tx.Exec(query, recurring.Id, date); err != nil {
+		return false, err
+	}
+
+	var occurrence entities.RecurringOccurrence
+	query = `
+		SELECT status, record_id, nominal, description
+		FROM recurring_occurrences
+		WHERE recurring_id = ? AND occurrence_date = ?
+		FOR UPDATE
+	`
+	err = tx.QueryRow(query, recurring.Id, date).Scan(
+		&occurrence.Status,
+		&occurrence.RecordId,
+		&occurrence.Nominal,
+		&occurrence.Description,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if occurrence.RecordId != nil || occurrence.Status == "skipped" {
+		return false, tx.Commit()
+	}
+
+	// nilai dari pengulangan yang diubah menggantikan nilai seri
+	nominal := recurring.Nominal
+	if occurrence.Nominal != nil {
+		nominal = *occurrence.Nominal
+	}
+	description := recurring.Description
+	if occurrence.Description != nil {
+		description = occurrence.Description
+	}
+
+	query = `
+		INSERT INTO record (user_id, account_id, date, type, category, nominal, currency, description)
+		VALUES (?,?,?,?,?,?,?,?)
+	`
+	result, err := tx.Exec(query, recurring.UserId, recurring.AccountId, date, recurring.Type, recurring.Category, nominal, recurring.Currency, description)
+	if err != nil {
+		return false, err
+	}
+
+	recordId, err := result.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+
+	query = `
+		UPDATE recurring_occurrences SET status = 'materialized', record_id = ?
+		WHERE recurring_id = ? AND occurrence_date = ?
+	`
+	if _, err := tx.Exec(query, recordId, recurring.Id, date); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"database/sql"
+	"financial-record/entities"
+	"time"
+)
+
+// tipe catatan keuangan untuk kedua sisi transfer, tidak dihitung sebagai pemasukan/pengeluaran
+const (
+	TransferOutType  = "transfer_keluar"
+	TransferInType   = "transfer_masuk"
+	TransferCategory = "transfer"
+)
+
+type TransferModel struct {
+	db *sql.DB
+}
+
+func NewTransferModel(db *sql.DB) *TransferModel {
+	return &TransferModel{
+		db: db,
+	}
+}
+
+// AddTransfer menyimpan transfer beserta catatan keluar dan masuknya dalam satu transaksi
+func (model TransferModel) AddTransfer(data entities.Transfer) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO transfers (user_id, from_account_id, to_account_id, date, nominal, currency, description)
+		VALUES (?,?,?,?,?,?,?)
+	`
+
+	result, err := tx.Exec(query, data.UserId, data.FromAccountId, data.ToAccountId, data.Date, data.Nominal, data.Currency, data.Description)
+	if err != nil {
+		return err
+	}
+
+	transferId, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO record (user_id, account_id, transfer_id, date, type, category, nominal, currency, description)
+		VALUES (?,?,?,?,?,?,?,?,?), (?,?,?,?,?,?,?,?,?)
+	`
+
+	_, err = tx.Exec(
+		query,
+		data.UserId, data.FromAccountId, transferId, data.Date, TransferOutType, TransferCategory, data.Nominal, data.Currency, data.Description,
+		data.UserId, data.ToAccountId, transferId, data.Date, TransferInType, TransferCategory, data.Nominal, data.Currency, data.Description,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// transfer yang catatannya ada di sampah dianggap tidak ada (sql.ErrNoRows)
+func (model TransferModel) FindTransferById(id int64, user_id string) (*entities.Transfer, error) {
+
+	transfer := &entities.Transfer{}
+
+	query := `
+		SELECT t.id, t.user_id, t.date, t.from_account_id, t.to_account_id, t.nominal, t.currency, t.description,
+			COALESCE(f.name, ''), COALESCE(d.name, '')
+		FROM transfers t
+		LEFT JOIN accounts f ON f.id = t.from_account_id
+		LEFT JOIN accounts d ON d.id = t.to_account_id
+		WHERE t.id = ? AND t.user_id = ?
+		AND EXISTS (SELECT 1 FROM record r WHERE r.transfer_id = t.id AND r.deleted_at IS NULL)
+	`
+
+	err := model.db.QueryRow(query, id, user_id).Scan(
+		&transfer.Id,
+		&transfer.UserId,
+		&transfer.Date,
+		&transfer.FromAccountId,
+		&transfer.ToAccountId,
+		&transfer.Nominal,
+		&transfer.Currency,
+		&transfer.Description,
+		&transfer.FromAccountName,
+		&transfer.ToAccountName,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// EditTransfer mengubah transfer sekaligus kedua catatan keuangannya
+func (model TransferModel) EditTransfer(data entities.Transfer) error {
+
+	tx, err := model.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+
+	query := `
+		UPDATE transfers SET
+		from_account_id = ?,
+		to_account_id = ?,
+		date = ?,
+		nominal = ?,
+		currency = ?,
+		description = ?,
+		updated_at = ?
+		WHERE id = ? AND user_id = ?
+	`
+
+	_, err = tx.Exec(query, data.FromAccountId, data.ToAccountId, data.Date, data.Nominal, data.Currency, data.Description, now, data.Id, data.UserId)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		UPDATE record SET
+		account_id = CASE WHEN type = ? THEN ? ELSE ? END,
+		date = ?,
+		nominal = ?,
+		currency = ?,
+		description = ?,
+		updated_at = ?
+		WHERE transfer_id = ? AND user_id = ?
+	`
+
+	_, err = tx.Exec(query, TransferOutType, data.FromAccountId, data.ToAccountId, data.Date, data.Nominal, data.Currency, data.Description, now, data.Id, data.UserId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteTransfer memindahkan kedua catatan transfer ke sampah, transfer dihapus permanen
+// bersama catatannya lewat FinancialModel.PurgeFinancialRecord
+func (model TransferModel) DeleteTransfer(id int64, user_id string) error {
+
+	query := "UPDATE record SET deleted_at = ?, updated_at = updated_at WHERE transfer_id = ? AND user_id = ? AND deleted_at IS NULL"
+
+	_, err := model.db.Exec(query, time.Now(), id, user_id)
+
+	return err
+}
@@ -19,12 +19,13 @@ func NewUserModel(db *sql.DB) *UserModel {
 func (model UserModel) FindUserById(id string) (entities.User, error) {
 
 	var user entities.User
-	query := "SELECT email, name, photo FROM users WHERE id = ?"
+	query := "SELECT email, name, photo, base_currency FROM users WHERE id = ?"
 
 	err := model.db.QueryRow(query, id).Scan(
 		&user.Email,
 		&user.Name,
 		&user.Photo,
+		&user.BaseCurrency,
 	)
 
 	if err != nil {
@@ -34,6 +35,15 @@ func (model UserModel) FindUserById(id string) (entities.User, error) {
 	return user, nil
 }
 
+// GetBaseCurrency mengambil mata uang utama user, dipakai untuk menampilkan total hasil konversi
+func (model UserModel) GetBaseCurrency(id string) (string, error) {
+
+	var currency string
+	err := model.db.QueryRow("SELECT base_currency FROM users WHERE id = ?", id).Scan(&currency)
+
+	return currency, err
+}
+
 func (model UserModel) GetUserPhotoById(id string) (*string, error) {
 
 	var photo *string
@@ -55,11 +65,11 @@ func (model UserModel) UpdateProfile(user entities.User) error {
 	var args []interface{}
 
 	if user.Password != ""{
-		query = "UPDATE users SET name = ?, email = ?, password = ?, photo = ?, updated_at = ? WHERE id = ?"
-		args = []interface{}{user.Name, user.Email, user.Password, user.Photo, time.Now(), user.Id}
+		query = "UPDATE users SET name = ?, email = ?, password = ?, photo = ?, base_currency = ?, updated_at = ? WHERE id = ?"
+		args = []interface{}{user.Name, user.Email, user.Password, user.Photo, user.BaseCurrency, time.Now(), user.Id}
 	} else {
-		query = "UPDATE users SET name = ?, email = ?, photo = ?, updated_at = ? WHERE id = ?"
-		args = []interface{}{user.Name, user.Email, user.Photo, time.Now(), user.Id}
+		query = "UPDATE users SET name = ?, email = ?, photo = ?, base_currency = ?, updated_at = ? WHERE id = ?"
+		args = []interface{}{user.Name, user.Email, user.Photo, user.BaseCurrency, time.Now(), user.Id}
 	}
 
 	_, err := model.db.Exec(query, args...)
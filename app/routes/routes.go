@@ -4,24 +4,100 @@ import (
 	"database/sql"
 	"financial-record/config"
 	"financial-record/controllers"
+	"financial-record/models"
 	"net/http"
 )
 
+// Router tempat route didaftarkan, di aplikasi memakai http.DefaultServeMux
+type Router interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
 func Routes(db *sql.DB) {
+	Register(http.DefaultServeMux, db)
+}
+
+// Register mendaftarkan semua route aplikasi. Setiap route harus ditulis juga
+// di docs/openapi.json, test akan gagal jika ada route yang belum ditulis.
+func Register(router Router, db *sql.DB) {
 
 	authController := controllers.NewAuthController(db)
-	http.HandleFunc("/register", config.GuestOnly(authController.Register))
-	http.HandleFunc("/login", config.GuestOnly(authController.Login))
-	http.HandleFunc("/logout", config.AuthOnly(authController.Logout))
+	router.HandleFunc("/register", config.GuestOnly(authController.Register))
+	router.HandleFunc("/login", config.GuestOnly(authController.Login))
+	router.HandleFunc("/logout", config.AuthOnly(authController.Logout))
 
 	financialController := controllers.NewFinancialController(db)
-	http.HandleFunc("/", config.AuthOnly(financialController.Home))
-	http.HandleFunc("/home", config.AuthOnly(financialController.Home))
-	http.HandleFunc("/financial/add_financial_record", config.AuthOnly(financialController.AddFinacialRecord))
-	http.HandleFunc("/financial/delete_financial_record", config.AuthOnly(financialController.DeleteFinancialRecord))
-	http.HandleFunc("/financial/download_financial_record", config.AuthOnly(financialController.DownloadFinancialRecord))
-	http.HandleFunc("/financial/edit_financial_record", config.AuthOnly(financialController.EditFinancialRecord))
+	router.HandleFunc("/", config.AuthOnly(financialController.Home))
+	router.HandleFunc("/home", config.AuthOnly(financialController.Home))
+	router.HandleFunc("/financial/add_financial_record", config.AuthOnly(financialController.AddFinacialRecord))
+	router.HandleFunc("/financial/delete_financial_record", config.AuthOnly(financialController.DeleteFinancialRecord))
+	router.HandleFunc("/financial/trash", config.AuthOnly(financialController.Trash))
+	router.HandleFunc("/financial/restore_financial_record", config.AuthOnly(financialController.RestoreFinancialRecord))
+	router.HandleFunc("/financial/purge_financial_record", config.AuthOnly(financialController.PurgeFinancialRecord))
+	router.HandleFunc("/financial/attachment", config.AuthOnly(financialController.Attachment))
+	router.HandleFunc("/financial/download_financial_record", config.AuthOnly(financialController.DownloadFinancialRecord))
+	router.HandleFunc("/financial/export_financial_record", config.AuthOnly(financialController.ExportFinancialRecord))
+	router.HandleFunc("/financial/edit_financial_record", config.AuthOnly(financialController.EditFinancialRecord))
+	router.HandleFunc("/financial/search_financial_record", config.AuthOnly(financialController.SearchFinancialRecord))
+
+	importController := controllers.NewImportController(db)
+	router.HandleFunc("/financial/import_financial_record", config.AuthOnly(importController.Import))
+
+	categoryController := controllers.NewCategoryController(db)
+	router.HandleFunc("/categories", config.AuthOnly(categoryController.Index))
+	router.HandleFunc("/categories/add", config.AuthOnly(categoryController.AddCategory))
+	router.HandleFunc("/categories/edit", config.AuthOnly(categoryController.EditCategory))
+	router.HandleFunc("/categories/merge", config.AuthOnly(categoryController.MergeCategory))
+	router.HandleFunc("/categories/delete", config.AuthOnly(categoryController.DeleteCategory))
+
+	accountController := controllers.NewAccountController(db)
+	router.HandleFunc("/accounts", config.AuthOnly(accountController.Index))
+	router.HandleFunc("/accounts/add", config.AuthOnly(accountController.AddAccount))
+	router.HandleFunc("/accounts/edit", config.AuthOnly(accountController.EditAccount))
+	router.HandleFunc("/accounts/delete", config.AuthOnly(accountController.DeleteAccount))
+
+	transferController := controllers.NewTransferController(db)
+	router.HandleFunc("/transfers/add", config.AuthOnly(transferController.AddTransfer))
+	router.HandleFunc("/transfers/edit", config.AuthOnly(transferController.EditTransfer))
+	router.HandleFunc("/transfers/delete", config.AuthOnly(transferController.DeleteTransfer))
+
+	exchangeRateController := controllers.NewExchangeRateController(db)
+	router.HandleFunc("/exchange_rates", config.AuthOnly(exchangeRateController.Index))
+	router.HandleFunc("/exchange_rates/add", config.AuthOnly(exchangeRateController.AddExchangeRate))
+	router.HandleFunc("/exchange_rates/import", config.AuthOnly(exchangeRateController.ImportExchangeRates))
+	router.HandleFunc("/exchange_rates/delete", config.AuthOnly(exchangeRateController.DeleteExchangeRate))
+
+	budgetController := controllers.NewBudgetController(db)
+	router.HandleFunc("/budgets", config.AuthOnly(budgetController.Index))
+	router.HandleFunc("/budgets/add", config.AuthOnly(budgetController.AddBudget))
+	router.HandleFunc("/budgets/edit", config.AuthOnly(budgetController.EditBudget))
+	router.HandleFunc("/budgets/copy", config.AuthOnly(budgetController.CopyBudget))
+	router.HandleFunc("/budgets/delete", config.AuthOnly(budgetController.DeleteBudget))
+
+	recurringController := controllers.NewRecurringController(db)
+	router.HandleFunc("/recurring", config.AuthOnly(recurringController.Index))
+	router.HandleFunc("/recurring/add", config.AuthOnly(recurringController.AddRecurring))
+	router.HandleFunc("/recurring/edit", config.AuthOnly(recurringController.EditRecurring))
+	router.HandleFunc("/recurring/pause", config.AuthOnly(recurringController.PauseRecurring))
+	router.HandleFunc("/recurring/delete", config.AuthOnly(recurringController.DeleteRecurring))
+	router.HandleFunc("/recurring/occurrences", config.AuthOnly(recurringController.Occurrences))
+	router.HandleFunc("/recurring/occurrence/skip", config.AuthOnly(recurringController.SkipOccurrence))
+	router.HandleFunc("/recurring/occurrence/edit", config.AuthOnly(recurringController.EditOccurrence))
 
 	userController := controllers.NewUserController(db)
-	http.HandleFunc("/profile", config.AuthOnly(userController.Profile))
+	router.HandleFunc("/profile", config.AuthOnly(userController.Profile))
+	router.HandleFunc("/profile/tokens/add", config.AuthOnly(userController.AddAPIToken))
+	router.HandleFunc("/profile/tokens/delete", config.AuthOnly(userController.DeleteAPIToken))
+
+	apiController := controllers.NewAPIController(db)
+	apiAuthOnly := config.APIAuthOnly(models.NewAPITokenModel(db))
+	router.HandleFunc("GET /api/v1/records", apiAuthOnly(apiController.ListRecords))
+	router.HandleFunc("POST /api/v1/records", apiAuthOnly(apiController.CreateRecord))
+	router.HandleFunc("GET /api/v1/records/{id}", apiAuthOnly(apiController.GetRecord))
+	router.HandleFunc("PUT /api/v1/records/{id}", apiAuthOnly(apiController.UpdateRecord))
+	router.HandleFunc("DELETE /api/v1/records/{id}", apiAuthOnly(apiController.DeleteRecord))
+	router.HandleFunc("GET /api/v1/totals", apiAuthOnly(apiController.Totals))
+	router.HandleFunc("GET /api/v1/categories", apiAuthOnly(apiController.Categories))
+	router.HandleFunc("GET /api/openapi.json", apiController.OpenAPI)
+	router.HandleFunc("/api/", apiController.NotFound)
 }
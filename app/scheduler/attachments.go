@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"database/sql"
+	"errors"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/storage"
+	"io"
+	"log"
+)
+
+// jumlah lampiran lama yang dibaca sekali query, isinya bisa beberapa MB per catatan
+const attachmentMigrationBatch = 20
+
+// MigrateAttachments memindahkan lampiran base64 lama dari kolom record.attachment ke storage
+// dan menyimpannya di tabel attachments. Lampiran yang bukan gambar atau PDF valid dibiarkan
+// di kolom lama dan dicatat di log, sehingga aman dijalankan ulang.
+func MigrateAttachments(db *sql.DB, store storage.Storage) (moved int, skipped int, err error) {
+
+	model := models.NewFinancalModel(db)
+	attachmentModel := models.NewAttachmentModel(db)
+	afterId := int64(0)
+
+	// kolom lama sudah dihapus jika 017 terlanjur dijalankan
+	if err := model.CheckLegacyAttachmentColumn(); err != nil {
+		return 0, 0, err
+	}
+
+	for {
+		attachments, err := model.FindLegacyAttachments(afterId, attachmentMigrationBatch)
+		if err != nil {
+			return moved, skipped, err
+		}
+		if len(attachments) == 0 {
+			return moved, skipped, nil
+		}
+
+		for _, attachment := range attachments {
+			afterId = attachment.Id
+
+			content, err := helpers.DecodeLegacyAttachment(attachment.Attachment)
+			if err != nil {
+				log.Printf("Lampiran catatan %d bukan base64 yang valid, dilewati\n", attachment.Id)
+				skipped++
+				continue
+			}
+
+			saved, err := helpers.SaveAttachment(store, attachment.UserId, "", content)
+			if errors.Is(err, helpers.ErrAttachmentType) {
+				log.Printf("Lampiran catatan %d bukan gambar atau PDF, dilewati\n", attachment.Id)
+				skipped++
+				continue
+			} else if errors.Is(err, helpers.ErrImageDecode) {
+				log.Printf("Gambar lampiran catatan %d rusak atau terlalu besar, dilewati\n", attachment.Id)
+				skipped++
+				continue
+			} else if err != nil {
+				return moved, skipped, err
+			}
+
+			// file dan thumbnail yang sudah tersimpan dihapus lagi jika database gagal diubah
+			saved.RecordId = attachment.Id
+			if err := attachmentModel.MoveLegacyAttachment(saved); err != nil {
+				for _, key := range helpers.AttachmentFileKeys(saved) {
+					store.Delete(key)
+				}
+				return moved, skipped, err
+			}
+			moved++
+		}
+	}
+}
+
+// NormalizeAttachments memproses lampiran gambar yang diupload sebelum ada normalisasi:
+// diputar sesuai EXIF, diperkecil, metadata EXIF (termasuk lokasi GPS) dibuang dan dibuatkan
+// thumbnail. File lama dihapus setelah database diubah, gambar yang rusak dilewati.
+func NormalizeAttachments(db *sql.DB, store storage.Storage) (processed int, skipped int, err error) {
+
+	model := models.NewAttachmentModel(db)
+	afterId := int64(0)
+
+	for {
+		attachments, err := model.FindUnprocessedImages(afterId, attachmentMigrationBatch)
+		if err != nil {
+			return processed, skipped, err
+		}
+		if len(attachments) == 0 {
+			return processed, skipped, nil
+		}
+
+		for _, attachment := range attachments {
+			afterId = attachment.Id
+
+			file, err := store.Open(attachment.StorageKey)
+			if errors.Is(err, storage.ErrNotFound) {
+				log.Printf("File lampiran %d tidak ada di storage, dilewati\n", attachment.Id)
+				skipped++
+				continue
+			} else if err != nil {
+				return processed, skipped, err
+			}
+			content, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return processed, skipped, err
+			}
+
+			normalized := attachment
+			if err := helpers.PutAttachmentFile(store, &normalized, content); errors.Is(err, helpers.ErrImageDecode) {
+				log.Printf("Lampiran %d bukan gambar yang bisa dibaca, dilewati\n", attachment.Id)
+				skipped++
+				continue
+			} else if err != nil {
+				return processed, skipped, err
+			}
+
+			// file baru dihapus lagi jika database gagal diubah, file lama setelah berhasil
+			if err := model.UpdateAttachmentFile(normalized); err != nil {
+				store.Delete(normalized.StorageKey)
+				if normalized.ThumbnailKey != nil {
+					store.Delete(*normalized.ThumbnailKey)
+				}
+				return processed, skipped, err
+			}
+			store.Delete(attachment.StorageKey)
+			processed++
+		}
+	}
+}
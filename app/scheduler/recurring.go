@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"database/sql"
+	"financial-record/models"
+	"log"
+	"time"
+)
+
+// RunRecurring membuat catatan keuangan dari transaksi berulang yang sudah jatuh tempo,
+// sekali saat aplikasi mulai lalu setiap interval. Jalankan sebagai goroutine.
+func RunRecurring(db *sql.DB, interval time.Duration) {
+
+	materialize := func() {
+		created, err := models.NewRecurringModel(db).MaterializeDueRecurring(time.Now())
+		if err != nil {
+			log.Println("Gagal membuat transaksi berulang,", err)
+		}
+		if created > 0 {
+			log.Printf("Berhasil membuat %d transaksi berulang\n", created)
+		}
+	}
+
+	materialize()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		materialize()
+	}
+}
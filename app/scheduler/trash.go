@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"database/sql"
+	"errors"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/storage"
+	"log"
+	"time"
+)
+
+// jumlah catatan sampah yang dihapus per batch
+const trashPurgeBatch = 100
+
+// PurgeTrash menghapus permanen catatan yang masuk sampah sebelum deletedBefore beserta
+// lampirannya. File lampiran dihapus setelah datanya terhapus, kegagalan hanya dicatat.
+func PurgeTrash(db *sql.DB, store storage.Storage, deletedBefore time.Time) (purged int, err error) {
+
+	financialModel := models.NewFinancalModel(db)
+
+	for {
+		financials, err := financialModel.FindExpiredDeletedRecords(deletedBefore, trashPurgeBatch)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, financial := range financials {
+			// catatan yang baru saja dikembalikan user dilewati
+			attachments, err := financialModel.PurgeFinancialRecord(financial.Id, financial.UserId)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return purged, err
+			}
+			purged++
+
+			for _, attachment := range attachments {
+				for _, key := range helpers.AttachmentFileKeys(attachment) {
+					if err := store.Delete(key); err != nil {
+						log.Println("Gagal menghapus lampiran,", err)
+					}
+				}
+			}
+		}
+
+		if len(financials) < trashPurgeBatch {
+			return purged, nil
+		}
+	}
+}
+
+// RunTrashPurge menghapus permanen catatan yang sudah lebih dari retentionDays hari di sampah,
+// sekali saat aplikasi mulai lalu setiap interval. Jalankan sebagai goroutine.
+func RunTrashPurge(db *sql.DB, store storage.Storage, interval time.Duration, retentionDays int) {
+
+	// 0 berarti sampah tidak pernah dihapus otomatis
+	if retentionDays <= 0 {
+		return
+	}
+
+	purge := func() {
+		purged, err := PurgeTrash(db, store, time.Now().AddDate(0, 0, -retentionDays))
+		if err != nil {
+			log.Println("Gagal membersihkan sampah,", err)
+		}
+		if purged > 0 {
+			log.Printf("Berhasil menghapus permanen %d catatan dari sampah\n", purged)
+		}
+	}
+
+	purge()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purge()
+	}
+}
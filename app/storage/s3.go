@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config berisi koneksi ke storage S3 atau yang kompatibel (MinIO, Cloudflare R2, dll)
+type S3Config struct {
+	Endpoint  string // contoh "https://s3.ap-southeast-1.amazonaws.com" atau "http://localhost:9000"
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Storage menyimpan file di bucket S3 memakai path-style URL (endpoint/bucket/key)
+// dan tanda tangan AWS Signature Version 4
+type S3Storage struct {
+	config S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3Storage(config S3Config) *S3Storage {
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	return &S3Storage{config: config, client: &http.Client{Timeout: 60 * time.Second}, now: time.Now}
+}
+
+// Put membaca seluruh isi file untuk hash SHA-256 yang dibutuhkan tanda tangan
+func (s3 *S3Storage) Put(key string, content io.Reader, contentType string) error {
+
+	body, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	response, err := s3.do(http.MethodPut, key, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return s3.checkResponse(response, http.StatusOK)
+}
+
+func (s3 *S3Storage) Open(key string) (io.ReadCloser, error) {
+
+	response, err := s3.do(http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, ErrNotFound
+	}
+	if err := s3.checkResponse(response, http.StatusOK); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// Delete tidak mengembalikan error jika file sudah tidak ada, S3 membalas 204 untuk keduanya
+func (s3 *S3Storage) Delete(key string) error {
+
+	response, err := s3.do(http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return s3.checkResponse(response, http.StatusOK, http.StatusNoContent)
+}
+
+func (s3 *S3Storage) checkResponse(response *http.Response, statuses ...int) error {
+
+	for _, status := range statuses {
+		if response.StatusCode == status {
+			return nil
+		}
+	}
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("storage S3 membalas %s: %s", response.Status, strings.TrimSpace(string(message)))
+}
+
+func (s3 *S3Storage) do(method, key string, body []byte, contentType string) (*http.Response, error) {
+
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+
+	// setiap bagian path di-encode sesuai aturan SigV4, "/" tetap
+	segments := strings.Split(s3.config.Bucket+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	canonicalURI := "/" + strings.Join(segments, "/")
+
+	endpoint, err := url.Parse(s3.config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(method, s3.config.Endpoint+canonicalURI, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.URL.RawPath = endpoint.Path + canonicalURI
+
+	now := s3.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + s3.config.Region + "/s3/aws4_request"
+	payloadHash := sha256Hex(body)
+
+	headers := map[string]string{
+		"host":                 request.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if contentType != "" {
+		headers["content-type"] = contentType
+		request.Header.Set("Content-Type", contentType)
+	}
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	request.Header.Set("X-Amz-Date", amzDate)
+
+	// header diurutkan menurut nama
+	names := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	var canonicalHeaders, signedHeaders []string
+	for _, name := range names {
+		if value, ok := headers[name]; ok {
+			canonicalHeaders = append(canonicalHeaders, name+":"+value+"\n")
+			signedHeaders = append(signedHeaders, name)
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{method, endpoint.Path + canonicalURI, "",
+		strings.Join(canonicalHeaders, ""), strings.Join(signedHeaders, ";"), payloadHash}, "\n")
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	signingKey := []byte("AWS4" + s3.config.SecretKey)
+	for _, part := range []string{now.Format("20060102"), s3.config.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3.config.AccessKey, scope, strings.Join(signedHeaders, ";"), signature))
+
+	return s3.client.Do(request)
+}
+
+// encode karakter selain huruf, angka dan "-._~" seperti yang diminta SigV4
+func s3Escape(value string) string {
+
+	var result strings.Builder
+	for _, char := range []byte(value) {
+		if ('A' <= char && char <= 'Z') || ('a' <= char && char <= 'z') || ('0' <= char && char <= '9') || strings.IndexByte("-._~", char) >= 0 {
+			result.WriteByte(char)
+		} else {
+			fmt.Fprintf(&result, "%%%02X", char)
+		}
+	}
+	return result.String()
+}
+
+func sha256Hex(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound dikembalikan Open jika file dengan key tersebut tidak ada
+var ErrNotFound = errors.New("file tidak ditemukan")
+
+// Storage menyimpan file lampiran. Key berupa path relatif dengan "/", contoh
+// "attachments/<user id>/<nama file>", dan hanya key ini yang disimpan di database.
+type Storage interface {
+	Put(key string, content io.Reader, contentType string) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// key tidak boleh keluar dari folder storage, contoh "../config" atau "/etc/passwd"
+func validKey(key string) error {
+
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return fmt.Errorf("key storage %q tidak valid", key)
+	}
+	return nil
+}
+
+// LocalStorage menyimpan file di folder lokal, dipakai jika STORAGE.DRIVER tidak diisi
+type LocalStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+func (local *LocalStorage) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(local.dir, filepath.FromSlash(key)), nil
+}
+
+// Put menulis ke file sementara lalu rename supaya file tidak pernah terbaca setengah jadi
+func (local *LocalStorage) Put(key string, content io.Reader, contentType string) error {
+
+	filename, err := local.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o750); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(filename), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := io.Copy(temp, content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), filename)
+}
+
+func (local *LocalStorage) Open(key string) (io.ReadCloser, error) {
+
+	filename, err := local.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete tidak mengembalikan error jika file sudah tidak ada
+func (local *LocalStorage) Delete(key string) error {
+
+	filename, err := local.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"financial-record/controllers"
+	"financial-record/entities"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestFinancialController_Home_RunningBalanceNextPage(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+	rendered := captureRenderTemplate(t)
+
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	cursor := models.EncodeFinancialCursor("date", entities.Financial{Id: 10, Date: day})
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT base_currency FROM users WHERE id = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"base_currency"}).AddRow("IDR"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM accounts a")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "kind", "opening_balance", "balance"}).
+			AddRow(int64(1), "user-a", "Dompet", "cash", int64(1000), int64(1500)))
+	mock.ExpectQuery(regexp.QuoteMeta("AS total_pengeluaran")).
+		WillReturnRows(sqlmock.NewRows([]string{"total_pemasukan", "total_pengeluaran"}).AddRow(int64(800), int64(300)))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	// halaman kedua dimulai setelah catatan 10
+	mock.ExpectQuery(regexp.QuoteMeta("AND r.account_id = ? AND (r.date > ? OR (r.date = ? AND r.id > ?)) ORDER BY r.date ASC, r.id ASC LIMIT ?")).
+		WithArgs("user-a", "2024-01-01", "2024-01-31", int64(1), "2024-01-10", "2024-01-10", int64(10), 11).
+		WillReturnRows(sqlmock.NewRows(pageColumns).
+			AddRow(int64(11), day, "pemasukan", "gaji", "#198754", "", 1, "Dompet", nil, int64(500), "IDR", int64(500), nil, day).
+			AddRow(int64(12), day, "pengeluaran", "makan", "#6c757d", "", 1, "Dompet", nil, int64(200), "IDR", int64(200), nil, day).
+			AddRow(int64(13), day, "pengeluaran", "makan", "#6c757d", "", 1, "Dompet", nil, int64(5), "USD", nil, nil, day).
+			AddRow(int64(14), day, models.TransferOutType, "transfer", "#6c757d", "", 1, "Dompet", int64(2), int64(100), "IDR", int64(100), nil, day))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments")).
+		WillReturnRows(sqlmock.NewRows(attachmentColumns))
+
+	// saldo sebelum periode ditambah catatan periode ini di halaman pertama
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT a.opening_balance + COALESCE")).
+		WithArgs(time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), int64(1), "user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(int64(1000)))
+	mock.ExpectQuery(regexp.QuoteMeta("AND r.account_id = ? AND (r.date < ? OR (r.date = ? AND r.id < ?))")).
+		WithArgs("user-a", "2024-01-01", "2024-01-31", int64(1), "2024-01-10", "2024-01-10", int64(11)).
+		WillReturnRows(sqlmock.NewRows([]string{"net"}).AddRow(int64(300)))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM budgets b")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "name", "color", "icon", "amount", "used"}))
+
+	request, _ := newSessionRequest(http.MethodGet, "/home?preset=custom&start_date=2024-01-01&end_date=2024-01-31&account_id=1&per_page=10&after="+url.QueryEscape(cursor), "user-a", nil)
+	controllers.NewFinancialController(db).Home(httptest.NewRecorder(), request)
+
+	if rendered.path != "views/financial/home.html" || rendered.data["error"] != nil {
+		t.Fatalf("got template %q error %v", rendered.path, rendered.data["error"])
+	}
+	if rendered.data["openingBalance"] != int64(1300) {
+		t.Errorf("saldo awal halaman: got %v, want 1300", rendered.data["openingBalance"])
+	}
+
+	// catatan yang kursnya belum tersedia tidak mengubah saldo
+	want := []int64{1800, 1600, 1600, 1500}
+	financials := rendered.data["financials"].([]entities.Financial)
+	if len(financials) != len(want) {
+		t.Fatalf("got %d catatan, want %d", len(financials), len(want))
+	}
+	for i, balance := range want {
+		if financials[i].RunningBalance == nil || *financials[i].RunningBalance != balance {
+			t.Errorf("catatan %d: got saldo %v, want %d", financials[i].Id, financials[i].RunningBalance, balance)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
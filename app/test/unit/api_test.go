@@ -0,0 +1,263 @@
+package unit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"financial-record/config"
+	"financial-record/controllers"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// buat request API dengan user id di context, seperti setelah melewati APIAuthOnly
+func newAPIRequest(method, target, userId, body string) *http.Request {
+
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	return request.WithContext(config.WithUserId(request.Context(), userId))
+}
+
+// baca body error {"error": {...}}
+func decodeAPIError(t *testing.T, recorder *httptest.ResponseRecorder) map[string]interface{} {
+
+	var body struct {
+		Error map[string]interface{} `json:"error"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+		t.Fatalf("response bukan JSON: %v", err)
+	}
+	return body.Error
+}
+
+// token API palsu: "frt_read" akses read, "frt_write" akses write milik user-a
+type fakeTokens struct{}
+
+func (fakeTokens) AuthenticateAPIToken(token string) (string, bool, error) {
+	switch token {
+	case "frt_read":
+		return "user-a", false, nil
+	case "frt_write":
+		return "user-a", true, nil
+	}
+	return "", false, models.ErrAPITokenInvalid
+}
+
+func TestAPIAuthOnly_Unauthorized(t *testing.T) {
+
+	called := false
+	handler := config.APIAuthOnly(fakeTokens{})(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/api/v1/records", nil))
+
+	if recorder.Code != http.StatusUnauthorized || called {
+		t.Fatalf("got status %d, called %v; want 401 tanpa memanggil handler", recorder.Code, called)
+	}
+	if got := decodeAPIError(t, recorder)["code"]; got != "unauthorized" {
+		t.Errorf("error code mismatch: got %v", got)
+	}
+}
+
+// token yang gagal diperiksa karena database error
+type failingTokens struct{}
+
+func (failingTokens) AuthenticateAPIToken(token string) (string, bool, error) {
+	return "", false, errors.New("dial tcp 10.0.0.5:3306: connection refused")
+}
+
+func TestAPIAuthOnly_InvalidToken(t *testing.T) {
+
+	for _, tokens := range []config.APITokenAuthenticator{fakeTokens{}, failingTokens{}} {
+		handler := config.APIAuthOnly(tokens)(func(w http.ResponseWriter, r *http.Request) {})
+
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/records", nil)
+		request.Header.Set("Authorization", "Bearer frt_salah")
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("got status %d, want 401", recorder.Code)
+		}
+		// penyebab error tidak dikirim ke client
+		if body := decodeAPIError(t, recorder); body["code"] != "invalid_token" || body["message"] != "Token tidak valid" {
+			t.Errorf("got %v", body)
+		}
+	}
+}
+
+func TestAPIController_CreateRecord_Validation(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	body := `{"date":"2024-01-01","type":"","category":"","account_id":1,"nominal":"","currency":"IDR"}`
+	recorder := httptest.NewRecorder()
+	controllers.NewAPIController(db).CreateRecord(recorder, newAPIRequest(http.MethodPost, "/api/v1/records", "user-a", body))
+
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got status %d, want 422", recorder.Code)
+	}
+	fields, _ := decodeAPIError(t, recorder)["fields"].(map[string]interface{})
+	for _, field := range []string{"type", "category", "nominal"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("field %q harus ada di error, got %v", field, fields)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAPIController_CreateRecord_InvalidInput(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"bukan_json", "application/x-www-form-urlencoded", "type=pengeluaran", http.StatusUnsupportedMediaType},
+		{"json_rusak", "application/json", `{"type":`, http.StatusBadRequest},
+		{"field_tidak_dikenal", "application/json", `{"amount":5000}`, http.StatusBadRequest},
+		{"tanggal_salah", "application/json", `{"date":"01/02/2024","type":"pengeluaran","category":"makan","account_id":1,"nominal":5000,"currency":"IDR"}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, _, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			request := newAPIRequest(http.MethodPost, "/api/v1/records", "user-a", tc.body)
+			request.Header.Set("Content-Type", tc.contentType)
+
+			recorder := httptest.NewRecorder()
+			controllers.NewAPIController(db).CreateRecord(recorder, request)
+			if recorder.Code != tc.wantStatus {
+				t.Errorf("got status %d, want %d", recorder.Code, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAPIController_ListRecords_Errors(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		query      string
+		dbErr      error
+		wantStatus int
+		wantCode   string
+	}{
+		{"cursor_rusak", "?after=bukan-cursor", nil, http.StatusBadRequest, "invalid_request"},
+		// error database tidak boleh dianggap kesalahan request
+		{"database_gagal", "", errors.New("database mati"), http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			if tc.dbErr != nil {
+				mock.ExpectQuery(regexp.QuoteMeta("FROM record r")).WillReturnError(tc.dbErr)
+			}
+
+			recorder := httptest.NewRecorder()
+			controllers.NewAPIController(db).ListRecords(recorder, newAPIRequest(http.MethodGet, "/api/v1/records"+tc.query, "user-a", ""))
+
+			if recorder.Code != tc.wantStatus {
+				t.Fatalf("got status %d, want %d", recorder.Code, tc.wantStatus)
+			}
+			body := decodeAPIError(t, recorder)
+			if body["code"] != tc.wantCode {
+				t.Errorf("error code: got %v, want %s", body["code"], tc.wantCode)
+			}
+			if message, _ := body["message"].(string); strings.Contains(message, "database mati") {
+				t.Errorf("pesan error database tidak boleh dikirim ke client, got %q", message)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAPIController_ForeignRecord_NotFound(t *testing.T) {
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			expectForeignRecord(mock)
+
+			request := newAPIRequest(method, "/api/v1/records/7", "user-b", "")
+			request.SetPathValue("id", "7")
+
+			controller := controllers.NewAPIController(db)
+			recorder := httptest.NewRecorder()
+			if method == http.MethodGet {
+				controller.GetRecord(recorder, request)
+			} else {
+				controller.DeleteRecord(recorder, request)
+			}
+
+			if recorder.Code != http.StatusNotFound {
+				t.Errorf("got status %d, want 404", recorder.Code)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestAPIController_DeleteRecord(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM record WHERE id = ? AND user_id = ?")).
+		WithArgs(int64(7), "user-a").
+		WillReturnRows(recordRow(7))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE record SET deleted_at = ?, updated_at = updated_at WHERE id = ? AND user_id = ? AND deleted_at IS NULL")).
+		WithArgs(sqlmock.AnyArg(), int64(7), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	request := newAPIRequest(http.MethodDelete, "/api/v1/records/7", "user-a", "")
+	request.SetPathValue("id", "7")
+
+	recorder := httptest.NewRecorder()
+	controllers.NewAPIController(db).DeleteRecord(recorder, request)
+
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("got status %d, want 204", recorder.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"financial-record/config"
+	"financial-record/controllers"
+	"financial-record/helpers"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGenerateAPIToken(t *testing.T) {
+
+	token, hash, prefix, err := helpers.GenerateAPIToken()
+	if err != nil {
+		t.Fatalf("GenerateAPIToken error: %v", err)
+	}
+	if !strings.HasPrefix(token, helpers.APITokenPrefix) || !strings.HasPrefix(token, prefix) {
+		t.Errorf("token %q harus diawali %q dan %q", token, helpers.APITokenPrefix, prefix)
+	}
+	if hash != helpers.HashAPIToken(token) || len(hash) != 64 || strings.Contains(hash, token) {
+		t.Errorf("hash tidak sesuai: %q", hash)
+	}
+
+	other, _, _, _ := helpers.GenerateAPIToken()
+	if other == token {
+		t.Error("dua token tidak boleh sama")
+	}
+}
+
+func TestAPIAuthOnly_Bearer(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		wantStatus    int
+	}{
+		{"read_get", http.MethodGet, "Bearer frt_read", http.StatusOK},
+		{"read_post", http.MethodPost, "Bearer frt_read", http.StatusForbidden},
+		{"write_delete", http.MethodDelete, "bearer frt_write", http.StatusOK},
+		{"token_salah", http.MethodGet, "Bearer frt_salah", http.StatusUnauthorized},
+		{"bukan_bearer", http.MethodGet, "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotUserId string
+			handler := config.APIAuthOnly(fakeTokens{})(func(w http.ResponseWriter, r *http.Request) {
+				gotUserId = config.UserIdFromContext(r.Context())
+			})
+
+			request := httptest.NewRequest(tc.method, "/api/v1/records", nil)
+			request.Header.Set("Authorization", tc.authorization)
+
+			recorder := httptest.NewRecorder()
+			handler(recorder, request)
+
+			if recorder.Code != tc.wantStatus {
+				t.Fatalf("got status %d, want %d", recorder.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusOK && gotUserId != "user-a" {
+				t.Errorf("user id di context: got %q, want user-a", gotUserId)
+			}
+			if tc.wantStatus != http.StatusOK && recorder.Header().Get("WWW-Authenticate") == "" {
+				t.Error("header WWW-Authenticate harus diisi")
+			}
+		})
+	}
+}
+
+var apiTokenColumns = []string{"id", "user_id", "scope", "expires_at", "last_used_at"}
+
+func TestAuthenticateAPIToken(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	query := regexp.QuoteMeta("FROM api_tokens WHERE token_hash = ?")
+	expired := time.Now().Add(-time.Hour)
+
+	// token aktif, last_used_at diperbarui
+	mock.ExpectQuery(query).WithArgs(helpers.HashAPIToken("frt_aktif")).
+		WillReturnRows(sqlmock.NewRows(apiTokenColumns).AddRow(1, "user-a", "write", nil, nil))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE api_tokens SET last_used_at = ? WHERE id = ?")).
+		WithArgs(sqlmock.AnyArg(), int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// token kedaluwarsa
+	mock.ExpectQuery(query).WithArgs(helpers.HashAPIToken("frt_lama")).
+		WillReturnRows(sqlmock.NewRows(apiTokenColumns).AddRow(2, "user-a", "read", expired, nil))
+
+	// token tidak dikenal
+	mock.ExpectQuery(query).WithArgs(helpers.HashAPIToken("frt_salah")).
+		WillReturnRows(sqlmock.NewRows(apiTokenColumns))
+
+	model := models.NewAPITokenModel(db)
+
+	userId, writable, err := model.AuthenticateAPIToken("frt_aktif")
+	if err != nil || userId != "user-a" || !writable {
+		t.Errorf("token aktif: got (%q, %v, %v)", userId, writable, err)
+	}
+	if _, _, err := model.AuthenticateAPIToken("frt_lama"); !errors.Is(err, models.ErrAPITokenExpired) {
+		t.Errorf("token kedaluwarsa: got %v", err)
+	}
+	if _, _, err := model.AuthenticateAPIToken("frt_salah"); !errors.Is(err, models.ErrAPITokenInvalid) {
+		t.Errorf("token tidak dikenal: got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUserController_DeleteAPIToken(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// link GET lama tidak boleh mencabut token
+	request, session := newSessionRequest(http.MethodGet, "/profile/tokens/delete?id=5", "user-a", nil)
+	controllers.NewUserController(db).DeleteAPIToken(httptest.NewRecorder(), request)
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		t.Fatalf("GET tidak boleh diproses, got %v", flashes)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM api_tokens WHERE id = ? AND user_id = ?")).
+		WithArgs(int64(5), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	request, session = newSessionRequest(http.MethodPost, "/profile/tokens/delete", "user-a", url.Values{"id": {"5"}})
+	recorder := httptest.NewRecorder()
+	controllers.NewUserController(db).DeleteAPIToken(recorder, request)
+
+	if recorder.Code != http.StatusSeeOther || len(session.Flashes("success")) == 0 {
+		t.Errorf("got status %d, error %v", recorder.Code, session.Flashes("error"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,514 @@
+package unit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"financial-record/config"
+	"financial-record/controllers"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/scheduler"
+	"financial-record/storage"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// ganti storage lampiran dengan folder sementara selama test
+func useTempStorage(t *testing.T) storage.Storage {
+
+	original := config.FileStorage
+	store := storage.NewLocalStorage(t.TempDir())
+	config.FileStorage = store
+	t.Cleanup(func() { config.FileStorage = original })
+
+	return store
+}
+
+func readStoredFile(t *testing.T, store storage.Storage, key string) []byte {
+
+	file, err := store.Open(key)
+	if err != nil {
+		t.Fatalf("Open(%q) error: %v", key, err)
+	}
+	defer file.Close()
+
+	content, _ := io.ReadAll(file)
+	return content
+}
+
+func testStorage(t *testing.T, store storage.Storage) {
+
+	key := "attachments/user-a/struk.png"
+	if err := store.Put(key, strings.NewReader("isi file"), "image/png"); err != nil {
+		t.Fatalf("Put error: %v", err)
+	}
+	if got := string(readStoredFile(t, store, key)); got != "isi file" {
+		t.Errorf("Open: got %q", got)
+	}
+
+	if err := store.Delete(key); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if _, err := store.Open(key); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("file yang dihapus: got %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(key); err != nil {
+		t.Errorf("hapus file yang tidak ada tidak boleh error, got %v", err)
+	}
+
+	// key tidak boleh keluar dari folder storage
+	for _, key := range []string{"", "/etc/passwd", "../app.conf.json", "attachments/../../app.conf.json", `attachments\..\x`} {
+		if err := store.Put(key, strings.NewReader("x"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) harus error", key)
+		}
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	testStorage(t, storage.NewLocalStorage(t.TempDir()))
+}
+
+// pengganti S3 untuk test, menyimpan object di memory dan mengecek header tanda tangan
+func newFakeS3(t *testing.T) *httptest.Server {
+
+	var mutex sync.Mutex
+	objects := make(map[string][]byte)
+	authorization := regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=akses/\d{8}/ap-southeast-3/s3/aws4_request, SignedHeaders=(content-type;)?host;x-amz-content-sha256;x-amz-date, Signature=[0-9a-f]{64}$`)
+
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+
+		body, _ := io.ReadAll(request.Body)
+		hash := sha256.Sum256(body)
+		if !authorization.MatchString(request.Header.Get("Authorization")) || request.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]) {
+			t.Errorf("tanda tangan tidak valid: %q", request.Header.Get("Authorization"))
+			writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if !strings.HasPrefix(request.URL.Path, "/lampiran/") {
+			t.Errorf("URL harus path-style /bucket/key, got %q", request.URL.Path)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		switch request.Method {
+		case http.MethodPut:
+			objects[request.URL.Path] = body
+		case http.MethodGet:
+			content, ok := objects[request.URL.Path]
+			if !ok {
+				writer.WriteHeader(http.StatusNotFound)
+				io.WriteString(writer, "<Error><Code>NoSuchKey</Code></Error>")
+				return
+			}
+			writer.Write(content)
+		case http.MethodDelete:
+			delete(objects, request.URL.Path)
+			writer.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestS3Storage(t *testing.T) {
+
+	server := newFakeS3(t)
+	defer server.Close()
+
+	testStorage(t, storage.NewS3Storage(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "lampiran",
+		Region:    "ap-southeast-3",
+		AccessKey: "akses",
+		SecretKey: "rahasia",
+	}))
+}
+
+// kolom tabel attachments sesuai urutan query model
+var attachmentColumns = []string{"id", "user_id", "record_id", "storage_key", "thumbnail_key", "filename", "content_type", "size", "created_at"}
+
+func attachmentRow(id int64, recordId int64, key string, contentType string) []driver.Value {
+	return []driver.Value{id, "user-a", recordId, key, nil, path.Base(key), contentType, int64(1024), time.Now()}
+}
+
+var pdfAttachment = []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n%%EOF\n")
+
+func TestAttachmentContentType(t *testing.T) {
+
+	gif := append([]byte("GIF89a"), make([]byte, 16)...)
+	webp := append([]byte("RIFF\x10\x00\x00\x00WEBPVP8 "), make([]byte, 16)...)
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+		ok      bool
+	}{
+		{"png", pngAttachment(t), "image/png", true},
+		{"pdf", pdfAttachment, "application/pdf", true},
+		{"webp", webp, "image/webp", true},
+		{"gif", gif, "image/gif", false},
+		{"html", []byte("<html><script>alert(1)</script></html>"), "text/html; charset=utf-8", false},
+	}
+	for _, tt := range tests {
+		got, ok := helpers.AttachmentContentType(tt.content)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: got %q %v, want %q %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	for name, want := range map[string]string{
+		`C:\Users\budi\invoice.pdf`: "invoice.pdf",
+		"../../etc/passwd":          "passwd",
+		"struk\"\n.png":             "struk.png",
+		"":                          "lampiran.pdf",
+	} {
+		if got := helpers.AttachmentFilename(name, "application/pdf"); got != want {
+			t.Errorf("AttachmentFilename(%q): got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFinancialController_Attachment(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	store := useTempStorage(t)
+	store.Put("attachments/user-a/invoice.pdf", bytes.NewReader(pdfAttachment), "application/pdf")
+
+	rendered := captureRenderTemplate(t)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments")).
+		WithArgs(int64(3), "user-a").
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).AddRow(attachmentRow(3, 7, "attachments/user-a/invoice.pdf", "application/pdf")...))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments")).
+		WithArgs(int64(3), "user-b").
+		WillReturnRows(sqlmock.NewRows(attachmentColumns))
+
+	request, _ := newSessionRequest(http.MethodGet, "/financial/attachment?id=3", "user-a", nil)
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).Attachment(recorder, request)
+
+	if recorder.Code != http.StatusOK || !bytes.Equal(recorder.Body.Bytes(), pdfAttachment) {
+		t.Fatalf("pemilik harus menerima file lampiran, got status %d", recorder.Code)
+	}
+	if recorder.Header().Get("Content-Type") != "application/pdf" || recorder.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("header tidak sesuai: %v", recorder.Header())
+	}
+	if got := recorder.Header().Get("Content-Disposition"); got != "inline; filename=invoice.pdf" {
+		t.Errorf("Content-Disposition: got %q", got)
+	}
+
+	// lampiran milik user lain tidak bisa dibuka
+	request, _ = newSessionRequest(http.MethodGet, "/financial/attachment?id=3", "user-b", nil)
+	recorder = httptest.NewRecorder()
+	controllers.NewFinancialController(db).Attachment(recorder, request)
+
+	if recorder.Code != http.StatusNotFound || rendered.path != "views/error/not_found.html" {
+		t.Errorf("user lain: got status %d template %q, want 404", recorder.Code, rendered.path)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// form multipart dengan beberapa file lampiran di input "attachments"
+func newAttachmentRequest(t *testing.T, target string, fields url.Values, files map[string][]byte) *http.Request {
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, values := range fields {
+		for _, value := range values {
+			writer.WriteField(name, value)
+		}
+	}
+	for filename, content := range files {
+		part, _ := writer.CreateFormFile("attachments", filename)
+		part.Write(content)
+	}
+	writer.Close()
+
+	request, _ := newSessionRequest(http.MethodPost, target, "user-a", nil)
+	request.Body = io.NopCloser(&body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+// query yang dijalankan form edit sebelum validasi catatan, catatan 7 punya dua lampiran
+func expectEditForm(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("FROM categories").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM accounts").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT base_currency FROM users WHERE id = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"base_currency"}).AddRow("IDR"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM record WHERE id = ? AND user_id = ?")).
+		WithArgs(int64(7), "user-a").
+		WillReturnRows(recordRow(7))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments")).
+		WithArgs(int64(7), "user-a").
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).
+			AddRow(attachmentRow(3, 7, "attachments/user-a/lama.png", "image/png")...).
+			AddRow(attachmentRow(4, 7, "attachments/user-a/nota.pdf", "application/pdf")...))
+}
+
+func recordFields() url.Values {
+	return url.Values{"date": {"2024-01-01"}, "type": {"pengeluaran"}, "category": {"makan"}, "account_id": {"1"}, "nominal": {"1.500"}, "currency": {"IDR"}}
+}
+
+func TestFinancialController_EditAttachments(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	store := useTempStorage(t)
+	store.Put("attachments/user-a/lama.png", bytes.NewReader(pngAttachment(t)), "image/png")
+	store.Put("attachments/user-a/nota.pdf", bytes.NewReader(pdfAttachment), "application/pdf")
+	rendered := captureRenderTemplate(t)
+
+	// file HTML yang diberi nama .pdf ditolak sebelum validasi lain dan tidak disimpan
+	expectEditForm(mock)
+	request := newAttachmentRequest(t, "/financial/edit_financial_record?id=7", recordFields(),
+		map[string][]byte{"invoice.pdf": pdfAttachment, "palsu.pdf": []byte("<html>bukan pdf</html>")})
+	controllers.NewFinancialController(db).EditFinancialRecord(httptest.NewRecorder(), request)
+
+	validation, _ := rendered.data["validation"].(map[string]interface{})
+	if message, _ := validation["Attachments"].(string); !strings.HasPrefix(message, "palsu.pdf") {
+		t.Fatalf("lampiran palsu harus ditolak, got validation %v", rendered.data["validation"])
+	}
+
+	// PDF baru ditambahkan dan lampiran 3 dihapus, id 99 bukan milik catatan ini diabaikan
+	var newKey string
+	fields := recordFields()
+	fields["remove_attachment"] = []string{"3", "99"}
+	expectEditForm(mock)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = ?")).
+		WithArgs("user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"usage"}).AddRow(int64(2048)))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM categories")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM accounts")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec("UPDATE record SET").
+		WithArgs(sqlmock.AnyArg(), "pengeluaran", "makan", int64(1), int64(150000), "IDR", nil, sqlmock.AnyArg(), int64(7), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM attachments WHERE id = ? AND record_id = ? AND user_id = ?")).
+		WithArgs(int64(3), int64(7), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO attachments")).
+		WithArgs("user-a", int64(7), keyArg{&newKey}, nil, "invoice.pdf", "application/pdf", int64(len(pdfAttachment))).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectCommit()
+
+	request = newAttachmentRequest(t, "/financial/edit_financial_record?id=7", fields, map[string][]byte{"invoice.pdf": pdfAttachment})
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).EditFinancialRecord(recorder, request)
+
+	if recorder.Code != http.StatusSeeOther {
+		t.Fatalf("got status %d, want redirect: %v", recorder.Code, rendered.data["error"])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// ekstensi key mengikuti isi file
+	if !regexp.MustCompile(`^attachments/user-a/[0-9a-f-]{36}\.pdf$`).MatchString(newKey) {
+		t.Errorf("key lampiran baru: got %q", newKey)
+	}
+	if !bytes.Equal(readStoredFile(t, store, newKey), pdfAttachment) {
+		t.Error("isi lampiran baru tidak sesuai")
+	}
+	if _, err := store.Open("attachments/user-a/lama.png"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("lampiran yang dicentang harus dihapus, got %v", err)
+	}
+	if !bytes.Equal(readStoredFile(t, store, "attachments/user-a/nota.pdf"), pdfAttachment) {
+		t.Error("lampiran yang tidak dicentang harus tetap ada")
+	}
+}
+
+func TestFinancialController_AddAttachmentQuota(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	useTempStorage(t)
+	rendered := captureRenderTemplate(t)
+	original := config.AttachmentQuota
+	config.AttachmentQuota = 1024 * 1024
+	t.Cleanup(func() { config.AttachmentQuota = original })
+
+	// kuota tinggal kurang dari ukuran file, catatan dan lampiran tidak disimpan
+	mock.ExpectQuery("FROM categories").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("FROM accounts").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT base_currency FROM users WHERE id = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"base_currency"}).AddRow("IDR"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = ?")).
+		WithArgs("user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"usage"}).AddRow(int64(1024*1024 - 10)))
+
+	request := newAttachmentRequest(t, "/financial/add_financial_record", recordFields(), map[string][]byte{"invoice.pdf": pdfAttachment})
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).AddFinacialRecord(recorder, request)
+
+	validation, _ := rendered.data["validation"].(map[string]interface{})
+	if message, _ := validation["Attachments"].(string); message != "Kuota lampiran tidak cukup, terpakai 1024 KB dari 1.0 MB" {
+		t.Errorf("got validation %v", rendered.data["validation"])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// keyArg mencocokkan argumen key lampiran dan menyimpannya untuk dicek
+type keyArg struct {
+	key *string
+}
+
+func (arg keyArg) Match(value driver.Value) bool {
+	key, ok := value.(string)
+	if ok {
+		*arg.key = key
+	}
+	return ok
+}
+
+// query pengecekan kolom base64 lama record.attachment sebelum migrasi lampiran
+func expectLegacyAttachmentColumn(mock sqlmock.Sqlmock, exists bool) {
+	count := 0
+	if exists {
+		count = 1
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'record' AND COLUMN_NAME = 'attachment'")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func TestMigrateAttachments(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	store := useTempStorage(t)
+	legacy := "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngAttachment(t))
+
+	expectLegacyAttachmentColumn(mock, true)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, user_id, attachment FROM record")).
+		WithArgs(int64(0), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "attachment"}).
+			AddRow(int64(3), "user-a", legacy).
+			AddRow(int64(5), "user-b", base64.StdEncoding.EncodeToString([]byte("bukan gambar"))).
+			AddRow(int64(8), "user-b", "%%%").
+			AddRow(int64(9), "user-b", base64.StdEncoding.EncodeToString([]byte("\xFF\xD8\xFFjpeg terpotong"))))
+
+	var key, thumbnailKey string
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO attachments")).
+		WithArgs("user-a", int64(3), keyArg{&key}, keyArg{&thumbnailKey}, "lampiran.png", "image/png", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE record SET attachment = NULL, updated_at = updated_at WHERE id = ?")).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// batch berikutnya mulai setelah id terakhir, termasuk yang dilewati
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, user_id, attachment FROM record")).
+		WithArgs(int64(9), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "attachment"}))
+
+	// gambar yang rusak dilewati supaya migrasi tidak berhenti di lampiran yang sama
+	moved, skipped, err := scheduler.MigrateAttachments(db, store)
+	if err != nil {
+		t.Fatalf("MigrateAttachments error: %v", err)
+	}
+	if moved != 1 || skipped != 3 {
+		t.Errorf("got %d dipindah %d dilewati, want 1 dan 3", moved, skipped)
+	}
+	if _, err := png.Decode(bytes.NewReader(readStoredFile(t, store, key))); !strings.HasPrefix(key, "attachments/user-a/") || err != nil {
+		t.Errorf("lampiran tidak tersimpan di storage, key %q: %v", key, err)
+	}
+	if thumbnailKey != strings.TrimSuffix(key, ".png")+"_thumb.jpg" {
+		t.Errorf("thumbnail lampiran lama: got %q", thumbnailKey)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrateAttachments_ColumnDropped(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// 017 sudah dijalankan, migrasi berhenti dengan pesan urutan migration yang benar
+	expectLegacyAttachmentColumn(mock, false)
+
+	if _, _, err := scheduler.MigrateAttachments(db, useTempStorage(t)); !errors.Is(err, models.ErrLegacyAttachmentColumnDropped) {
+		t.Fatalf("got %v, want ErrLegacyAttachmentColumnDropped", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrateAttachments_RollbackDeletesFiles(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	store := useTempStorage(t)
+	expectLegacyAttachmentColumn(mock, true)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, user_id, attachment FROM record")).
+		WithArgs(int64(0), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "attachment"}).
+			AddRow(int64(3), "user-a", base64.StdEncoding.EncodeToString(pngAttachment(t))))
+
+	var key, thumbnailKey string
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO attachments")).
+		WithArgs("user-a", int64(3), keyArg{&key}, keyArg{&thumbnailKey}, "lampiran.png", "image/png", sqlmock.AnyArg()).
+		WillReturnError(errors.New("database mati"))
+	mock.ExpectRollback()
+
+	if _, _, err := scheduler.MigrateAttachments(db, store); err == nil {
+		t.Fatal("MigrateAttachments harus gagal")
+	}
+
+	// gambar dan thumbnail yang sudah tersimpan tidak boleh tertinggal di storage
+	for _, stored := range []string{key, thumbnailKey} {
+		if _, err := store.Open(stored); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("%q harus dihapus, got %v", stored, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
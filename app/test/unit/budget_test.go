@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"financial-record/controllers"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBudgetModel_FindBudgetUsage(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// pengeluaran dihitung dari tanggal 1 sampai akhir bulan, termasuk 29 Februari
+	mock.ExpectQuery(regexp.QuoteMeta("FROM budgets b")).
+		WithArgs("2024-02-01", "2024-02-29", "user-a", 2, 2024).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "name", "color", "icon", "amount", "used"}).
+			AddRow(int64(1), int64(3), "makan", "#dc3545", "bi-cup-hot", int64(1000000), int64(250000)).
+			AddRow(int64(2), int64(4), "transport", "#0d6efd", "bi-bus-front", int64(500000), int64(500000)).
+			AddRow(int64(3), int64(5), "hiburan", "#6c757d", "bi-film", int64(200000), int64(300000)).
+			AddRow(int64(4), int64(6), "lainnya", "#6c757d", "bi-tag", int64(0), int64(1000)))
+
+	usages, err := models.NewBudgetModel(db).FindBudgetUsage("user-a", "February 2024")
+	if err != nil {
+		t.Fatalf("FindBudgetUsage error: %v", err)
+	}
+
+	want := []struct {
+		percent int
+		over    bool
+	}{
+		{25, false},
+		// tepat sama dengan anggaran belum dianggap melebihi
+		{100, false},
+		{150, true},
+		// anggaran 0 tidak boleh membagi dengan nol
+		{0, true},
+	}
+	if len(usages) != len(want) {
+		t.Fatalf("got %d anggaran, want %d", len(usages), len(want))
+	}
+	for i, w := range want {
+		if usages[i].Percent != w.percent || usages[i].Over != w.over {
+			t.Errorf("%s: got percent %d over %v, want %d %v", usages[i].CategoryName, usages[i].Percent, usages[i].Over, w.percent, w.over)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBudgetModel_FindBudgetUsage_ExcludesTrash(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// hanya pengeluaran yang belum dihapus yang mengurangi anggaran
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE r.user_id = b.user_id AND r.deleted_at IS NULL") + `\s+` + regexp.QuoteMeta("AND r.type = 'pengeluaran'")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "name", "color", "icon", "amount", "used"}))
+
+	models.NewBudgetModel(db).FindBudgetUsage("user-a", "January 2024")
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBudgetController_Index_InvalidMonth(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// bulan yang tidak bisa dibaca tidak boleh menampilkan anggaran bulan Januari tahun 0
+	if _, err := models.NewBudgetModel(db).FindBudgetUsage("user-a", "Bulan 13"); !errors.Is(err, models.ErrInvalidBudgetMonth) {
+		t.Fatalf("got %v, want ErrInvalidBudgetMonth", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT base_currency FROM users")).
+		WillReturnRows(sqlmock.NewRows([]string{"base_currency"}).AddRow("IDR"))
+
+	// template dibaca relatif dari folder app
+	t.Chdir("../..")
+	request, _ := newSessionRequest(http.MethodGet, "/budgets?selected_month=Bulan+13", "user-a", nil)
+	recorder := httptest.NewRecorder()
+	controllers.NewBudgetController(db).Index(recorder, request)
+
+	if body := recorder.Body.String(); !strings.Contains(body, "Bulan tidak valid") {
+		t.Errorf("pesan validasi bulan harus tampil, got %q", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,151 @@
+package unit
+
+import (
+	"regexp"
+	"testing"
+
+	"financial-record/entities"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var categoryMakan = entities.Category{Id: 3, UserId: "user-a", Type: "pengeluaran", Name: "makan", Color: "#dc3545", Icon: "bi-cup-hot"}
+
+func TestCategoryModel_EditCategory_RenamesRecords(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	renamed := categoryMakan
+	renamed.Name = "kuliner"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE categories SET")).
+		WithArgs("kuliner", "#dc3545", "bi-cup-hot", sqlmock.AnyArg(), int64(3), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE record SET category = ? WHERE user_id = ? AND type = ? AND category = ?")).
+		WithArgs("kuliner", "user-a", "pengeluaran", "makan").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE recurring SET category = ? WHERE user_id = ? AND type = ? AND category = ?")).
+		WithArgs("kuliner", "user-a", "pengeluaran", "makan").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := models.NewCategoryModel(db).EditCategory(categoryMakan, renamed); err != nil {
+		t.Fatalf("EditCategory error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCategoryModel_EditCategory_SameName(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// hanya warna yang berubah, catatan keuangan tidak perlu diubah
+	recolored := categoryMakan
+	recolored.Color = "#198754"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE categories SET")).
+		WithArgs("makan", "#198754", "bi-cup-hot", sqlmock.AnyArg(), int64(3), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := models.NewCategoryModel(db).EditCategory(categoryMakan, recolored); err != nil {
+		t.Fatalf("EditCategory error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCategoryModel_MergeCategory(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	target := entities.Category{Id: 4, UserId: "user-a", Type: "pengeluaran", Name: "kuliner"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE record SET category = ? WHERE user_id = ? AND type = ? AND category = ?")).
+		WithArgs("kuliner", "user-a", "pengeluaran", "makan").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE recurring SET category = ? WHERE user_id = ? AND type = ? AND category = ?")).
+		WithArgs("kuliner", "user-a", "pengeluaran", "makan").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO budgets")).
+		WithArgs(int64(4), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM budgets WHERE category_id = ?")).
+		WithArgs(int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM categories WHERE id = ? AND user_id = ?")).
+		WithArgs(int64(3), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := models.NewCategoryModel(db).MergeCategory(categoryMakan, target); err != nil {
+		t.Fatalf("MergeCategory error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCategoryModel_DeleteCategory(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		count int
+		want  error
+	}{
+		{"masih dipakai", 2, models.ErrCategoryInUse},
+		{"tidak dipakai", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			// jumlah catatan keuangan ditambah transaksi berulang yang memakai kategori
+			mock.ExpectQuery(regexp.QuoteMeta("(SELECT COUNT(*) FROM record WHERE user_id = ? AND type = ? AND category = ?) +")+`\s+`+
+				regexp.QuoteMeta("(SELECT COUNT(*) FROM recurring WHERE user_id = ? AND type = ? AND category = ?)")).
+				WithArgs("user-a", "pengeluaran", "makan", "user-a", "pengeluaran", "makan").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+			if tt.want == nil {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM budgets WHERE category_id = ?")).
+					WithArgs(int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM categories WHERE id = ? AND user_id = ?")).
+					WithArgs(int64(3), "user-a").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			if err := models.NewCategoryModel(db).DeleteCategory(categoryMakan); err != tt.want {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"financial-record/helpers"
+)
+
+func TestResolveDateRange(t *testing.T) {
+
+	// Rabu, 14 Agustus 2024
+	now := time.Date(2024, 8, 14, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name       string
+		preset     string
+		start, end string
+		wantStart  string
+		wantEnd    string
+		wantPreset string
+	}{
+		{"default_this_month", "", "", "", "2024-08-01", "2024-08-31", "this_month"},
+		{"this_week", "this_week", "", "", "2024-08-12", "2024-08-18", "this_week"},
+		{"last_30_days", "last_30_days", "", "", "2024-07-16", "2024-08-14", "last_30_days"},
+		{"this_quarter", "this_quarter", "", "", "2024-07-01", "2024-09-30", "this_quarter"},
+		{"this_year", "this_year", "", "", "2024-01-01", "2024-12-31", "this_year"},
+		{"custom", "custom", "2023-12-25", "2024-01-05", "2023-12-25", "2024-01-05", "custom"},
+		{"custom_reversed", "custom", "2024-01-05", "2023-12-25", "2023-12-25", "2024-01-05", "custom"},
+		{"custom_invalid", "custom", "kemarin", "", "2024-08-01", "2024-08-31", "this_month"},
+		{"unknown_preset", "last_decade", "", "", "2024-08-01", "2024-08-31", "this_month"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start, end, preset := helpers.ResolveDateRange(tc.preset, tc.start, tc.end, now)
+			if got := start.Format("2006-01-02"); got != tc.wantStart {
+				t.Errorf("start mismatch: got %s, want %s", got, tc.wantStart)
+			}
+			if got := end.Format("2006-01-02"); got != tc.wantEnd {
+				t.Errorf("end mismatch: got %s, want %s", got, tc.wantEnd)
+			}
+			if preset != tc.wantPreset {
+				t.Errorf("preset mismatch: got %s, want %s", preset, tc.wantPreset)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// nominal dikonversi ke mata uang utama user memakai kurs terakhir sampai tanggal catatan,
+// catatan dengan mata uang yang sama dengan mata uang utama tidak dikonversi
+var baseNominalPattern = regexp.QuoteMeta("CASE WHEN r.currency = u.base_currency THEN r.nominal ELSE ROUND(r.nominal * ( " +
+	"SELECT er.rate FROM exchange_rates er WHERE er.user_id = r.user_id AND er.currency = r.currency " +
+	"AND er.base_currency = u.base_currency AND er.rate_date <= r.date ORDER BY er.rate_date DESC LIMIT 1 )) END")
+
+func TestBaseNominalConversion(t *testing.T) {
+
+	filter := entities.FinancialFilter{UserId: "user-a", StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name    string
+		pattern string
+		rows    *sqlmock.Rows
+		run     func(financial *models.FinancialModel, accounts *models.AccountModel, budgets *models.BudgetModel) error
+	}{
+		{
+			name:    "total pemasukan dan pengeluaran",
+			pattern: regexp.QuoteMeta("SUM(CASE WHEN r.type = 'pemasukan' THEN ") + baseNominalPattern,
+			rows:    sqlmock.NewRows([]string{"total_pemasukan", "total_pengeluaran"}).AddRow(int64(0), int64(0)),
+			run: func(model *models.FinancialModel, _ *models.AccountModel, _ *models.BudgetModel) error {
+				_, _, err := model.GetFinancialTotalNominal(filter)
+				return err
+			},
+		},
+		{
+			name:    "total per kategori",
+			pattern: regexp.QuoteMeta("COUNT(*), COALESCE(SUM(") + baseNominalPattern,
+			rows:    sqlmock.NewRows([]string{"type", "category", "count", "total"}),
+			run: func(model *models.FinancialModel, _ *models.AccountModel, _ *models.BudgetModel) error {
+				_, err := model.GetCategoryTotals(filter)
+				return err
+			},
+		},
+		{
+			name:    "saldo akun",
+			pattern: regexp.QuoteMeta("WHEN r.type IN ('pemasukan', 'transfer_masuk') THEN ") + baseNominalPattern,
+			rows:    sqlmock.NewRows([]string{"id", "user_id", "name", "kind", "opening_balance", "balance"}),
+			run: func(_ *models.FinancialModel, model *models.AccountModel, _ *models.BudgetModel) error {
+				_, err := model.FindAllAccount("user-a")
+				return err
+			},
+		},
+		{
+			name:    "pemakaian anggaran",
+			pattern: regexp.QuoteMeta("SELECT SUM(") + baseNominalPattern,
+			rows:    sqlmock.NewRows([]string{"id", "category_id", "name", "color", "icon", "amount", "used"}),
+			run: func(_ *models.FinancialModel, _ *models.AccountModel, model *models.BudgetModel) error {
+				_, err := model.FindBudgetUsage("user-a", "January 2024")
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			// kolom u.base_currency hanya tersedia kalau tabel users ikut di-join
+			mock.ExpectQuery(tt.pattern + `[\s\S]+` + regexp.QuoteMeta("JOIN users u ON u.id =")).
+				WillReturnRows(tt.rows)
+
+			if err := tt.run(models.NewFinancalModel(db), models.NewAccountModel(db), models.NewBudgetModel(db)); err != nil {
+				t.Fatalf("query error: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestFindFinancialPage_MissingExchangeRate(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	day := time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local)
+	mock.ExpectQuery(regexp.QuoteMeta("r.nominal, r.currency, ") + baseNominalPattern).
+		WillReturnRows(sqlmock.NewRows(pageColumns).
+			AddRow(int64(1), day, "pengeluaran", "makan", "#6c757d", "", 1, "Tunai", nil, int64(1000), "USD", int64(15500000), nil, day).
+			AddRow(int64(2), day, "pengeluaran", "makan", "#6c757d", "", 1, "Tunai", nil, int64(1000), "EUR", nil, nil, day))
+
+	financials, _, err := models.NewFinancalModel(db).FindFinancialPage(pageFilter(), entities.FinancialPage{Sort: "date", Limit: 25})
+	if err != nil {
+		t.Fatalf("FindFinancialPage error: %v", err)
+	}
+
+	// kurs yang belum diisi menghasilkan NULL, bukan 0
+	if financials[0].BaseNominal == nil || *financials[0].BaseNominal != 15500000 {
+		t.Errorf("catatan USD: got %v, want 15500000", financials[0].BaseNominal)
+	}
+	if financials[1].BaseNominal != nil {
+		t.Errorf("catatan EUR tanpa kurs: got %v, want nil", *financials[1].BaseNominal)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,226 @@
+package unit
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"financial-record/controllers"
+	"financial-record/entities"
+	"financial-record/helpers"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// catatan untuk export: satu IDR dan satu USD yang belum punya kurs
+func exportFinancials() []entities.Financial {
+
+	description := `Makan "siang" & kopi`
+	return []entities.Financial{
+		{Id: 1, Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Type: "pengeluaran", Category: "makan", AccountName: "Dompet", Nominal: 5000050, Currency: "IDR", Description: &description},
+		{Id: 2, Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Type: "pemasukan", Category: "gaji", AccountName: "BCA", Nominal: 150000, Currency: "USD"},
+	}
+}
+
+func writeFinancialExport(t *testing.T, format string) []byte {
+
+	var buffer bytes.Buffer
+	export, err := helpers.NewFinancialExport(&buffer, format, "IDR")
+	if err != nil {
+		t.Fatalf("NewFinancialExport error: %v", err)
+	}
+	for _, financial := range exportFinancials() {
+		if err := export.WriteFinancial(financial); err != nil {
+			t.Fatalf("WriteFinancial error: %v", err)
+		}
+	}
+	if err := export.WriteTotals(0, 5000050); err != nil {
+		t.Fatalf("WriteTotals error: %v", err)
+	}
+	if err := export.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestFinancialExport_CSV(t *testing.T) {
+
+	content := writeFinancialExport(t, "csv")
+	if !bytes.HasPrefix(content, []byte("\xef\xbb\xbf")) {
+		t.Error("CSV harus diawali BOM UTF-8")
+	}
+
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("CSV tidak valid: %v", err)
+	}
+
+	want := [][]string{
+		{"No", "Tanggal", "Tipe", "Kategori", "Akun", "Keterangan", "Mata Uang", "Nominal", "Nominal IDR"},
+		{"1", "2024-01-01", "pengeluaran", "makan", "Dompet", `Makan "siang" & kopi`, "IDR", "50000.50", "50000.50"},
+		{"2", "2024-01-02", "pemasukan", "gaji", "BCA", "", "USD", "1500.00", ""},
+		{"", "", "", "", "", "Total Pemasukan", "IDR", "", "0.00"},
+		{"", "", "", "", "", "Total Pengeluaran", "IDR", "", "50000.50"},
+		{"", "", "", "", "", "Selisih", "IDR", "", "-50000.50"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d baris, want %d: %q", len(records), len(want), records)
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("baris %d: got %q, want %q", i+1, records[i], want[i])
+		}
+	}
+}
+
+func TestFinancialExport_CSVFormula(t *testing.T) {
+
+	var buffer bytes.Buffer
+	export, _ := helpers.NewFinancialExport(&buffer, "csv", "IDR")
+
+	description := `=HYPERLINK("http://contoh.com","klik")`
+	export.WriteFinancial(entities.Financial{Id: 1, Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Type: "pengeluaran",
+		Category: "@SUM(A1)", AccountName: "+Dompet", Nominal: -150, Currency: "IDR", Description: &description})
+	export.Close()
+
+	records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(buffer.Bytes(), []byte("\xef\xbb\xbf")))).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV tidak valid: %v", err)
+	}
+
+	// teks diawali karakter formula diberi awalan ', nominal negatif tetap angka
+	want := []string{"1", "2024-01-01", "pengeluaran", "'@SUM(A1)", "'+Dompet", `'=HYPERLINK("http://contoh.com","klik")`, "IDR", "-1.50", "-1.50"}
+	if strings.Join(records[1], "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", records[1], want)
+	}
+}
+
+func TestFinancialExport_XLSX(t *testing.T) {
+
+	content := writeFinancialExport(t, "xlsx")
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("XLSX bukan zip yang valid: %v", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if files[name] == nil {
+			t.Fatalf("file %s tidak ada di XLSX", name)
+		}
+	}
+
+	sheetFile, _ := files["xl/worksheets/sheet1.xml"].Open()
+	sheetContent, _ := io.ReadAll(sheetFile)
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Reference string `xml:"r,attr"`
+				Value     string `xml:"v"`
+				Text      string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(sheetContent, &sheet); err != nil {
+		t.Fatalf("sheet bukan XML yang valid: %v", err)
+	}
+
+	// header, 2 catatan, baris kosong dan 3 baris total
+	if len(sheet.Rows) != 7 {
+		t.Fatalf("got %d baris, want 7", len(sheet.Rows))
+	}
+
+	cells := make(map[string]string)
+	for _, row := range sheet.Rows {
+		for _, cell := range row.Cells {
+			cells[cell.Reference] = cell.Value + cell.Text
+		}
+	}
+
+	// tanggal sebagai nomor seri Excel, nominal sebagai angka
+	want := map[string]string{
+		"A1": "No", "I1": "Nominal IDR",
+		"B2": "45292", "F2": `Makan "siang" & kopi`, "H2": "50000.50", "I2": "50000.50",
+		"G3": "USD", "H3": "1500.00", "I3": "",
+		"F7": "Selisih", "I7": "-50000.50",
+	}
+	for reference, value := range want {
+		if cells[reference] != value {
+			t.Errorf("sel %s: got %q, want %q", reference, cells[reference], value)
+		}
+	}
+}
+
+func TestFinancialController_ExportCSV(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT base_currency FROM users WHERE id = ?")).
+		WithArgs("user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"base_currency"}).AddRow("IDR"))
+	mock.ExpectQuery("AS total_pemasukan").
+		WillReturnRows(sqlmock.NewRows([]string{"total_pemasukan", "total_pengeluaran"}).AddRow(int64(0), int64(5000050)))
+
+	mock.ExpectQuery(regexp.QuoteMeta("r.description, r.created_at")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date", "type", "category", "color", "icon", "account_id", "account_name", "transfer_id", "nominal", "currency", "base_nominal", "description", "created_at"}).
+			AddRow(int64(1), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "pengeluaran", "makan", "#6c757d", "", int64(1), "Dompet", nil, int64(5000050), "IDR", int64(5000050), nil, time.Now()))
+
+	request, _ := newSessionRequest(http.MethodGet, "/financial/export_financial_record?format=csv&preset=custom&start_date=2024-01-01&end_date=2024-01-31", "user-a", nil)
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).ExportFinancialRecord(recorder, request)
+
+	if got := recorder.Header().Get("Content-Disposition"); got != `attachment; filename="financial-record_2024-01-01_2024-01-31.csv"` {
+		t.Errorf("Content-Disposition: got %q", got)
+	}
+	if got := recorder.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Errorf("Content-Type: got %q", got)
+	}
+
+	body := recorder.Body.String()
+	for _, want := range []string{"1,2024-01-01,pengeluaran,makan,Dompet,,IDR,50000.50,50000.50\n", ",,,,,Total Pengeluaran,IDR,,50000.50\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body harus berisi %q, got:\n%s", want, body)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFinancialController_ExportUnknownFormat(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	request, _ := newSessionRequest(http.MethodGet, "/financial/export_financial_record?format=pdf", "user-a", nil)
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).ExportFinancialRecord(recorder, request)
+
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/home" {
+		t.Errorf("got status %d location %q, want redirect ke /home", recorder.Code, recorder.Header().Get("Location"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,160 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"financial-record/config"
+	"financial-record/controllers"
+	"financial-record/views"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/sessions"
+)
+
+// buat request dengan session login, session disimpan di registry request
+// sehingga controller memakai session yang sama
+func newSessionRequest(method, target, userId string, form url.Values) (*http.Request, *sessions.Session) {
+
+	var request *http.Request
+	if form != nil {
+		request = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		request = httptest.NewRequest(method, target, nil)
+	}
+
+	session, _ := config.Store.Get(request, config.SESSION_ID)
+	session.Values["ID"] = userId
+	session.Values["LOGGED_IN"] = true
+
+	return request, session
+}
+
+type renderedTemplate struct {
+	path string
+	data map[string]interface{}
+}
+
+// ganti RenderTemplate supaya test tidak bergantung pada file html
+func captureRenderTemplate(t *testing.T) *renderedTemplate {
+
+	rendered := &renderedTemplate{}
+	original := views.RenderTemplate
+	views.RenderTemplate = func(writer http.ResponseWriter, path string, data interface{}) {
+		rendered.path = path
+		rendered.data, _ = data.(map[string]interface{})
+	}
+	t.Cleanup(func() { views.RenderTemplate = original })
+
+	return rendered
+}
+
+// catatan milik user A tidak ditemukan ketika dicari dengan user B
+func expectForeignRecord(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta("FROM record WHERE id = ? AND user_id = ?")).
+		WithArgs(int64(7), "user-b").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+}
+
+func TestFinancialController_EditForeignRecord_NotFound(t *testing.T) {
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			rendered := captureRenderTemplate(t)
+			expectForeignRecord(mock)
+
+			form := url.Values{
+				"date":     {"2024-01-01"},
+				"type":     {"pengeluaran"},
+				"category": {"makan"},
+				"nominal":  {"1.000"},
+				"currency": {"IDR"},
+			}
+			request, _ := newSessionRequest(method, "/financial/edit_financial_record?id=7", "user-b", form)
+			recorder := httptest.NewRecorder()
+
+			controllers.NewFinancialController(db).EditFinancialRecord(recorder, request)
+
+			if recorder.Code != http.StatusNotFound {
+				t.Fatalf("status mismatch: got %d, want %d", recorder.Code, http.StatusNotFound)
+			}
+			if rendered.path != "views/error/not_found.html" {
+				t.Errorf("template mismatch: got %q", rendered.path)
+			}
+			// ExpectationsWereMet gagal jika query UPDATE dijalankan karena tidak diharapkan
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestFinancialController_DeleteForeignRecord_NotFound(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	captureRenderTemplate(t)
+	expectForeignRecord(mock)
+
+	request, session := newSessionRequest(http.MethodPost, "/financial/delete_financial_record", "user-b", url.Values{"id": {"7"}})
+	recorder := httptest.NewRecorder()
+
+	controllers.NewFinancialController(db).DeleteFinancialRecord(recorder, request)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("status mismatch: got %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+	if flashes := session.Flashes("success"); len(flashes) > 0 {
+		t.Errorf("catatan user lain tidak boleh terhapus, flash: %v", flashes)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFinancialController_EditOwnRecord(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	rendered := captureRenderTemplate(t)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM record WHERE id = ? AND user_id = ?")).
+		WithArgs(int64(7), "user-a").
+		WillReturnRows(recordRow(7))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments")).
+		WithArgs(int64(7), "user-a").
+		WillReturnRows(sqlmock.NewRows(attachmentColumns))
+
+	request, _ := newSessionRequest(http.MethodGet, "/financial/edit_financial_record?id=7", "user-a", nil)
+	recorder := httptest.NewRecorder()
+
+	controllers.NewFinancialController(db).EditFinancialRecord(recorder, request)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status mismatch: got %d, want %d", recorder.Code, http.StatusOK)
+	}
+	if rendered.path != "views/financial/edit.html" || rendered.data["financial"] == nil {
+		t.Errorf("form edit tidak ditampilkan: %q %v", rendered.path, rendered.data["error"])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,146 @@
+package unit
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var pageColumns = []string{"id", "date", "type", "category", "color", "icon", "account_id", "account_name", "transfer_id",
+	"nominal", "currency", "base_nominal", "description", "created_at"}
+
+func pageRow(id int64, date time.Time, nominal int64) []driver.Value {
+	return []driver.Value{id, date, "pengeluaran", "makan", "#6c757d", "", 1, "Tunai", nil, nominal, "IDR", nominal, nil, date}
+}
+
+func pageFilter() entities.FinancialFilter {
+	return entities.FinancialFilter{
+		UserId:    "user-a",
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+		EndDate:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local),
+	}
+}
+
+func TestFindFinancialPage_FirstPage(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	day := time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local)
+	rows := sqlmock.NewRows(pageColumns).
+		AddRow(pageRow(1, day, 100)...).
+		AddRow(pageRow(2, day, 200)...).
+		AddRow(pageRow(3, day, 300)...)
+
+	// limit 2 diambil 3 untuk mengetahui masih ada halaman berikutnya, nominal diurutkan
+	// dalam mata uang utama
+	mock.ExpectQuery(regexp.QuoteMeta("AND r.date BETWEEN ? AND ? ORDER BY COALESCE(")+baseNominalPattern+regexp.QuoteMeta(", 0) DESC, r.id DESC LIMIT ?")).
+		WithArgs("user-a", "2024-01-01", "2024-01-31", 3).
+		WillReturnRows(rows)
+
+	page := entities.FinancialPage{Sort: "nominal", Desc: true, Limit: 2}
+	financials, hasMore, err := models.NewFinancalModel(db).FindFinancialPage(pageFilter(), page)
+	if err != nil {
+		t.Fatalf("FindFinancialPage error: %v", err)
+	}
+	if len(financials) != 2 || !hasMore {
+		t.Fatalf("got %d rows, hasMore %v; want 2 rows, hasMore true", len(financials), hasMore)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFindFinancialPage_NominalCursor(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// posisi halaman memakai nominal dalam mata uang utama, bukan nominal asli
+	day := time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local)
+	baseNominal := int64(15500000)
+	cursor := models.EncodeFinancialCursor("nominal", entities.Financial{Id: 7, Nominal: 1000, Currency: "USD", BaseNominal: &baseNominal})
+
+	sortColumn := regexp.QuoteMeta("COALESCE(") + baseNominalPattern + regexp.QuoteMeta(", 0)")
+	mock.ExpectQuery(regexp.QuoteMeta("AND (")+sortColumn+regexp.QuoteMeta(" < ? OR (")+sortColumn+regexp.QuoteMeta(" = ? AND r.id < ?)) ORDER BY ")+sortColumn+regexp.QuoteMeta(" DESC, r.id DESC LIMIT ?")).
+		WithArgs("user-a", "2024-01-01", "2024-01-31", baseNominal, baseNominal, int64(7), 3).
+		WillReturnRows(sqlmock.NewRows(pageColumns).AddRow(pageRow(3, day, 100)...))
+
+	page := entities.FinancialPage{Sort: "nominal", Desc: true, Limit: 2, Cursor: cursor}
+	if _, _, err := models.NewFinancalModel(db).FindFinancialPage(pageFilter(), page); err != nil {
+		t.Fatalf("FindFinancialPage error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFindFinancialPage_Cursor(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	day := time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local)
+	cursor := models.EncodeFinancialCursor("date", entities.Financial{Id: 40000, Date: day})
+
+	// halaman berikutnya: baris setelah (tanggal, id) terakhir
+	mock.ExpectQuery(regexp.QuoteMeta("AND (r.date > ? OR (r.date = ? AND r.id > ?)) ORDER BY r.date ASC, r.id ASC LIMIT ?")).
+		WithArgs("user-a", "2024-01-01", "2024-01-31", "2024-01-05", "2024-01-05", int64(40000), 3).
+		WillReturnRows(sqlmock.NewRows(pageColumns).AddRow(pageRow(40001, day, 100)...))
+
+	// halaman sebelumnya: urutan dibalik lalu hasilnya dibalik lagi
+	mock.ExpectQuery(regexp.QuoteMeta("AND (r.date < ? OR (r.date = ? AND r.id < ?)) ORDER BY r.date DESC, r.id DESC LIMIT ?")).
+		WithArgs("user-a", "2024-01-01", "2024-01-31", "2024-01-05", "2024-01-05", int64(40000), 3).
+		WillReturnRows(sqlmock.NewRows(pageColumns).AddRow(pageRow(39999, day, 100)...).AddRow(pageRow(39998, day, 100)...))
+
+	model := models.NewFinancalModel(db)
+
+	next, hasMore, err := model.FindFinancialPage(pageFilter(), entities.FinancialPage{Sort: "date", Limit: 2, Cursor: cursor})
+	if err != nil {
+		t.Fatalf("halaman berikutnya error: %v", err)
+	}
+	if len(next) != 1 || hasMore {
+		t.Errorf("halaman berikutnya: got %d rows, hasMore %v", len(next), hasMore)
+	}
+
+	previous, _, err := model.FindFinancialPage(pageFilter(), entities.FinancialPage{Sort: "date", Limit: 2, Cursor: cursor, Backward: true})
+	if err != nil {
+		t.Fatalf("halaman sebelumnya error: %v", err)
+	}
+	if len(previous) != 2 || previous[0].Id != 39998 || previous[1].Id != 39999 {
+		t.Errorf("halaman sebelumnya harus urut naik, got %+v", previous)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFindFinancialPage_InvalidCursor(t *testing.T) {
+
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	page := entities.FinancialPage{Sort: "date", Limit: 25, Cursor: "bukan-cursor"}
+	if _, _, err := models.NewFinancalModel(db).FindFinancialPage(pageFilter(), page); err == nil {
+		t.Error("cursor tidak valid harus mengembalikan error")
+	}
+}
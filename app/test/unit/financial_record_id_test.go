@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"financial-record/controllers"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// id di atas batas int16 (32.767) yang dulu membuat edit dan hapus gagal
+var largeRecordIds = []int64{32768, 40000, 2147483648}
+
+func recordRow(id int64) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "date", "type", "category", "account_id", "transfer_id", "nominal", "currency", "description"}).
+		AddRow(id, time.Now(), "pengeluaran", "makan", 1, nil, 1500000, "IDR", nil)
+}
+
+func TestFinancialModel_FindFinancialById_LargeId(t *testing.T) {
+	for _, id := range largeRecordIds {
+		t.Run(strconv.FormatInt(id, 10), func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery(regexp.QuoteMeta("FROM record WHERE id = ? AND user_id = ?")).
+				WithArgs(id, "user-a").
+				WillReturnRows(recordRow(id))
+
+			financial, err := models.NewFinancalModel(db).FindFinancialById(id, "user-a")
+			if err != nil {
+				t.Fatalf("FindFinancialById(%d) error: %v", id, err)
+			}
+			if financial.Id != id {
+				t.Errorf("Id mismatch: got %d, want %d", financial.Id, id)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestFinancialController_Delete_LargeId(t *testing.T) {
+	for _, id := range largeRecordIds {
+		t.Run(strconv.FormatInt(id, 10), func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery(regexp.QuoteMeta("FROM record WHERE id = ? AND user_id = ?")).
+				WithArgs(id, "user-a").
+				WillReturnRows(recordRow(id))
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE record SET deleted_at = ?")).
+				WithArgs(sqlmock.AnyArg(), id, "user-a").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			request, session := newSessionRequest(http.MethodPost, "/financial/delete_financial_record", "user-a", url.Values{"id": {strconv.FormatInt(id, 10)}})
+			recorder := httptest.NewRecorder()
+
+			controllers.NewFinancialController(db).DeleteFinancialRecord(recorder, request)
+
+			if recorder.Code != http.StatusSeeOther {
+				t.Fatalf("status mismatch: got %d, want %d", recorder.Code, http.StatusSeeOther)
+			}
+			if flashes := session.Flashes("success"); len(flashes) == 0 {
+				t.Errorf("flash sukses tidak ditemukan, error: %v", session.Flashes("error"))
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"financial-record/controllers"
+	"financial-record/helpers"
+	"financial-record/scheduler"
+	"financial-record/storage"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// foto JPEG dengan EXIF orientasi dan lokasi GPS seperti dari kamera HP,
+// setengah kiri merah dan setengah kanan biru sebelum diputar
+func exifJPEG(t *testing.T, width, height int, orientation uint16) []byte {
+
+	picture := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				picture.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				picture.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, picture, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("gagal membuat JPEG: %v", err)
+	}
+
+	// TIFF little endian dengan satu tag Orientation, diikuti teks GPS penanda
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, "GPS -6.2088,106.8456"...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	content := encoded.Bytes()
+	return append(append(append([]byte{}, content[:2]...), app1...), content[2:]...)
+}
+
+func decodeTestImage(t *testing.T, content []byte) image.Image {
+	picture, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("hasil bukan gambar: %v", err)
+	}
+	return picture
+}
+
+// warna dominan pixel, cukup untuk membedakan merah dan biru setelah kompresi JPEG
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > b
+}
+
+func TestNormalizeImage_Orientation(t *testing.T) {
+
+	tests := []struct {
+		orientation   uint16
+		width, height int
+		redAt         image.Point
+		blueAt        image.Point
+	}{
+		{1, 40, 20, image.Pt(5, 10), image.Pt(35, 10)},
+		{3, 40, 20, image.Pt(35, 10), image.Pt(5, 10)},
+		{6, 20, 40, image.Pt(10, 5), image.Pt(10, 35)},
+		{8, 20, 40, image.Pt(10, 35), image.Pt(10, 5)},
+	}
+	for _, tt := range tests {
+		normalized, contentType, err := helpers.NormalizeImage(exifJPEG(t, 40, 20, tt.orientation), helpers.AttachmentImageSize)
+		if err != nil || contentType != "image/jpeg" {
+			t.Fatalf("orientasi %d: got %q %v", tt.orientation, contentType, err)
+		}
+		if bytes.Contains(normalized, []byte("Exif")) || bytes.Contains(normalized, []byte("GPS")) {
+			t.Errorf("orientasi %d: metadata EXIF harus dibuang", tt.orientation)
+		}
+
+		picture := decodeTestImage(t, normalized)
+		if picture.Bounds().Dx() != tt.width || picture.Bounds().Dy() != tt.height {
+			t.Errorf("orientasi %d: got %v, want %dx%d", tt.orientation, picture.Bounds().Size(), tt.width, tt.height)
+		}
+		if !isRed(picture.At(tt.redAt.X, tt.redAt.Y)) || isRed(picture.At(tt.blueAt.X, tt.blueAt.Y)) {
+			t.Errorf("orientasi %d: gambar tidak diputar dengan benar", tt.orientation)
+		}
+	}
+}
+
+func TestNormalizeImage_ResizePNG(t *testing.T) {
+
+	picture := image.NewNRGBA(image.Rect(0, 0, 3000, 1000))
+	var encoded bytes.Buffer
+	png.Encode(&encoded, picture)
+
+	normalized, contentType, err := helpers.NormalizeImage(encoded.Bytes(), helpers.AttachmentImageSize)
+	if err != nil || contentType != "image/png" {
+		t.Fatalf("got %q %v, PNG harus tetap PNG", contentType, err)
+	}
+	result := decodeTestImage(t, normalized)
+	if result.Bounds().Dx() != 2048 || result.Bounds().Dy() != 682 {
+		t.Errorf("got %v, want 2048x682", result.Bounds().Size())
+	}
+	if _, _, _, alpha := result.At(10, 10).RGBA(); alpha != 0 {
+		t.Error("bagian transparan harus tetap transparan")
+	}
+
+	if _, _, err := helpers.NormalizeImage([]byte("\xFF\xD8\xFFbukan jpeg"), helpers.AttachmentImageSize); err != helpers.ErrImageDecode {
+		t.Errorf("gambar rusak: got %v, want ErrImageDecode", err)
+	}
+}
+
+// baca gambar contoh di test/fixtures/images
+func readImageFixture(t *testing.T, name string) []byte {
+	content, err := os.ReadFile(filepath.Join("fixtures", "images", name))
+	if err != nil {
+		t.Fatalf("gagal membaca fixture: %v", err)
+	}
+	return content
+}
+
+func TestNormalizeImage_WebP(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		contentType string
+		size        image.Point
+	}{
+		// WEBP tanpa transparansi jadi JPEG, yang transparan jadi PNG
+		{"struk.webp", "image/jpeg", image.Pt(150, 100)},
+		{"transparan.webp", "image/png", image.Pt(400, 301)},
+	}
+	for _, tt := range tests {
+		normalized, contentType, err := helpers.NormalizeImage(readImageFixture(t, tt.name), helpers.AttachmentImageSize)
+		if err != nil || contentType != tt.contentType {
+			t.Fatalf("%s: got %q %v, want %q", tt.name, contentType, err, tt.contentType)
+		}
+		if got := http.DetectContentType(normalized); got != tt.contentType {
+			t.Errorf("%s: isi file %q, want %q", tt.name, got, tt.contentType)
+		}
+		if size := decodeTestImage(t, normalized).Bounds().Size(); size != tt.size {
+			t.Errorf("%s: got %v, want %v", tt.name, size, tt.size)
+		}
+	}
+
+	store := storage.NewLocalStorage(t.TempDir())
+	attachment, err := helpers.SaveAttachment(store, "user-a", "struk.webp", readImageFixture(t, "struk.webp"))
+	if err != nil {
+		t.Fatalf("SaveAttachment error: %v", err)
+	}
+	if attachment.ContentType != "image/jpeg" || !strings.HasSuffix(attachment.StorageKey, ".jpg") || attachment.Filename != "struk.webp" {
+		t.Errorf("got %q %q %q", attachment.ContentType, attachment.StorageKey, attachment.Filename)
+	}
+	if attachment.ThumbnailKey == nil {
+		t.Fatal("WEBP harus dibuatkan thumbnail")
+	}
+	if size := decodeTestImage(t, readStoredFile(t, store, *attachment.ThumbnailKey)).Bounds().Size(); size != image.Pt(150, 100) {
+		t.Errorf("thumbnail: got %v, want 150x100", size)
+	}
+}
+
+func TestNormalizeImage_LegacyGIF(t *testing.T) {
+
+	// lampiran GIF lama dari sebelum migration 014 diubah jadi JPEG
+	var encoded bytes.Buffer
+	if err := gif.Encode(&encoded, image.NewPaletted(image.Rect(0, 0, 30, 20), color.Palette{color.White, color.Black}), nil); err != nil {
+		t.Fatalf("gagal membuat GIF: %v", err)
+	}
+	normalized, contentType, err := helpers.NormalizeImage(encoded.Bytes(), helpers.AttachmentImageSize)
+	if err != nil || contentType != "image/jpeg" {
+		t.Fatalf("got %q %v, want image/jpeg", contentType, err)
+	}
+	if size := decodeTestImage(t, normalized).Bounds().Size(); size != image.Pt(30, 20) {
+		t.Errorf("got %v, want 30x20", size)
+	}
+}
+
+// PNG kecil yang header IHDR-nya mengaku berukuran width x height
+func oversizedPNG(t *testing.T, width, height uint32) []byte {
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("gagal membuat png: %v", err)
+	}
+	content := buffer.Bytes()
+
+	// IHDR selalu chunk pertama setelah signature 8 byte, CRC dihitung ulang
+	binary.BigEndian.PutUint32(content[16:], width)
+	binary.BigEndian.PutUint32(content[20:], height)
+	binary.BigEndian.PutUint32(content[29:], crc32.ChecksumIEEE(content[12:29]))
+	return content
+}
+
+func TestDecodeImage_TooLarge(t *testing.T) {
+
+	// ditolak dari header tanpa mengalokasikan memory untuk seluruh pixel
+	for _, size := range [][2]uint32{{50000, 50000}, {8000, 5001}, {1, 40000001}} {
+		if _, err := helpers.DecodeImage(oversizedPNG(t, size[0], size[1])); err != helpers.ErrImageDecode {
+			t.Errorf("%dx%d: got %v, want ErrImageDecode", size[0], size[1], err)
+		}
+	}
+
+	if _, err := helpers.SaveAttachment(storage.NewLocalStorage(t.TempDir()), "user-a", "besar.png", oversizedPNG(t, 50000, 50000)); err != helpers.ErrImageDecode {
+		t.Errorf("SaveAttachment: got %v, want ErrImageDecode", err)
+	}
+}
+
+func TestSaveAttachment_Thumbnail(t *testing.T) {
+
+	store := storage.NewLocalStorage(t.TempDir())
+	attachment, err := helpers.SaveAttachment(store, "user-a", "IMG_0001.jpg", exifJPEG(t, 1200, 600, 6))
+	if err != nil {
+		t.Fatalf("SaveAttachment error: %v", err)
+	}
+
+	stored := readStoredFile(t, store, attachment.StorageKey)
+	if bytes.Contains(stored, []byte("GPS")) || attachment.Size != int64(len(stored)) {
+		t.Error("file yang disimpan harus tanpa EXIF dan ukurannya sesuai")
+	}
+	if size := decodeTestImage(t, stored).Bounds().Size(); size != image.Pt(600, 1200) {
+		t.Errorf("gambar harus diputar, got %v", size)
+	}
+
+	if attachment.ThumbnailKey == nil || *attachment.ThumbnailKey != strings.TrimSuffix(attachment.StorageKey, ".jpg")+"_thumb.jpg" {
+		t.Fatalf("thumbnail key: got %v", attachment.ThumbnailKey)
+	}
+	if size := decodeTestImage(t, readStoredFile(t, store, *attachment.ThumbnailKey)).Bounds().Size(); size != image.Pt(120, 240) {
+		t.Errorf("thumbnail: got %v, want 120x240", size)
+	}
+
+	// PDF tidak punya thumbnail
+	attachment, err = helpers.SaveAttachment(store, "user-a", "invoice.pdf", pdfAttachment)
+	if err != nil || attachment.ThumbnailKey != nil {
+		t.Errorf("PDF: got thumbnail %v error %v", attachment.ThumbnailKey, err)
+	}
+}
+
+func TestFinancialController_AttachmentThumbnail(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	store := useTempStorage(t)
+	attachment, err := helpers.SaveAttachment(store, "user-a", "struk.jpg", exifJPEG(t, 800, 400, 1))
+	if err != nil {
+		t.Fatalf("SaveAttachment error: %v", err)
+	}
+
+	row := attachmentRow(3, 7, attachment.StorageKey, "image/jpeg")
+	row[4] = *attachment.ThumbnailKey
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments")).
+		WithArgs(int64(3), "user-a").
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).AddRow(row...))
+
+	request, _ := newSessionRequest(http.MethodGet, "/financial/attachment?id=3&thumbnail=true", "user-a", nil)
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).Attachment(recorder, request)
+
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("got status %d %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if size := decodeTestImage(t, recorder.Body.Bytes()).Bounds().Size(); size != image.Pt(240, 120) {
+		t.Errorf("harus mengirim thumbnail, got %v", size)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNormalizeAttachments(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	store := useTempStorage(t)
+	store.Put("attachments/user-a/lama.jpg", bytes.NewReader(exifJPEG(t, 400, 200, 6)), "image/jpeg")
+	store.Put("attachments/user-a/rusak.jpg", strings.NewReader("\xFF\xD8\xFFrusak"), "image/jpeg")
+
+	mock.ExpectQuery(regexp.QuoteMeta("thumbnail_key IS NULL")).
+		WithArgs(int64(0), 20).
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).
+			AddRow(attachmentRow(3, 7, "attachments/user-a/lama.jpg", "image/jpeg")...).
+			AddRow(attachmentRow(4, 7, "attachments/user-a/rusak.jpg", "image/jpeg")...).
+			AddRow(attachmentRow(5, 8, "attachments/user-a/hilang.png", "image/png")...))
+
+	var key, thumbnailKey string
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE attachments SET storage_key = ?, thumbnail_key = ?, content_type = ?, size = ? WHERE id = ?")).
+		WithArgs(keyArg{&key}, keyArg{&thumbnailKey}, "image/jpeg", sqlmock.AnyArg(), int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("thumbnail_key IS NULL")).
+		WithArgs(int64(5), 20).
+		WillReturnRows(sqlmock.NewRows(attachmentColumns))
+
+	processed, skipped, err := scheduler.NormalizeAttachments(db, store)
+	if err != nil {
+		t.Fatalf("NormalizeAttachments error: %v", err)
+	}
+	if processed != 1 || skipped != 2 {
+		t.Errorf("got %d diproses %d dilewati, want 1 dan 2", processed, skipped)
+	}
+	if size := decodeTestImage(t, readStoredFile(t, store, key)).Bounds().Size(); size != image.Pt(200, 400) {
+		t.Errorf("lampiran harus diputar, got %v", size)
+	}
+	readStoredFile(t, store, thumbnailKey)
+	if _, err := store.Open("attachments/user-a/lama.jpg"); err != storage.ErrNotFound {
+		t.Errorf("file lama harus dihapus, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReadStatementCSV_Delimiter(t *testing.T) {
+
+	tests := map[string]string{
+		"koma":       "Tanggal,Nominal\n2024-01-02,5000\n",
+		"titik_koma": "\xef\xbb\xbfTanggal;Nominal\n2024-01-02;5.000,50\n\n",
+		"tab":        "Tanggal\tNominal\n2024-01-02\t5000\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			rows, err := helpers.ReadStatementCSV([]byte(content))
+			if err != nil {
+				t.Fatalf("ReadStatementCSV error: %v", err)
+			}
+			if len(rows) != 2 || len(rows[0]) != 2 || rows[0][0] != "Tanggal" {
+				t.Errorf("got %q", rows)
+			}
+		})
+	}
+}
+
+func TestGuessImportMapping(t *testing.T) {
+
+	mapping := helpers.GuessImportMapping([]string{"No", "Tanggal", "Keterangan", "Jumlah", "DB/CR"})
+	want := entities.ImportMapping{Date: 1, Amount: 3, Description: 2, Type: 4, Category: -1, DateFormat: "2006-01-02", HasHeader: true}
+	if mapping != want {
+		t.Errorf("got %+v, want %+v", mapping, want)
+	}
+
+	// file tanpa header
+	if mapping := helpers.GuessImportMapping([]string{"2024-01-02", "5000"}); mapping.HasHeader || mapping.Date != -1 {
+		t.Errorf("baris data tidak boleh dianggap header, got %+v", mapping)
+	}
+}
+
+func TestParseStatementRows(t *testing.T) {
+
+	rows := [][]string{
+		{"Tanggal", "Keterangan", "Nominal"},
+		{"02/01/2024", "Gaji Januari", "5.000.000"},
+		{"03/01/2024", "Makan siang", "-50.000,50"},
+		{"31/02/2024", "Tanggal salah", "1000"},
+		{"04/01/2024", "", "abc"},
+	}
+	mapping := entities.ImportMapping{Date: 0, Amount: 2, Description: 1, Type: -1, Category: -1, DateFormat: "02/01/2006", HasHeader: true}
+	base := entities.AddFinancial{UserId: "user-a", AccountId: 1, Currency: "IDR"}
+	defaults := map[string]string{"pemasukan": "gaji", "pengeluaran": "makan"}
+
+	result := helpers.ParseStatementRows(rows, mapping, base, defaults)
+	if len(result) != 4 {
+		t.Fatalf("got %d rows, want 4 (header dilewati)", len(result))
+	}
+
+	income, expense := result[0].Financial, result[1].Financial
+	if !result[0].Valid() || income.Type != "pemasukan" || income.Nominal != 500000000 || income.Category != "gaji" || income.AccountId != 1 {
+		t.Errorf("baris pemasukan: %+v", result[0])
+	}
+	if !result[1].Valid() || expense.Type != "pengeluaran" || expense.Nominal != 5000050 || expense.Category != "makan" || *expense.Description != "Makan siang" {
+		t.Errorf("baris pengeluaran: %+v", result[1])
+	}
+	if !expense.Date.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("tanggal: got %v", expense.Date)
+	}
+	if result[2].Valid() || result[2].Line != 4 {
+		t.Errorf("tanggal tidak valid harus error di baris 4, got %+v", result[2])
+	}
+	if result[3].Valid() || result[3].Financial.Description != nil {
+		t.Errorf("nominal tidak valid harus error, got %+v", result[3])
+	}
+}
+
+func TestParseStatementRows_TypeColumn(t *testing.T) {
+
+	rows := [][]string{{"2024-01-02", "100", "CR"}, {"2024-01-02", "100", "db"}, {"2024-01-02", "100", "?"}}
+	mapping := entities.ImportMapping{Date: 0, Amount: 1, Description: -1, Type: 2, Category: -1, DateFormat: "2006-01-02"}
+
+	result := helpers.ParseStatementRows(rows, mapping, entities.AddFinancial{}, nil)
+	if result[0].Financial.Type != "pemasukan" || result[1].Financial.Type != "pengeluaran" || result[2].Valid() {
+		t.Errorf("got %+v", result)
+	}
+}
+
+func TestImportFinancialRecords_Rollback(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	records := []entities.AddFinancial{
+		{UserId: "user-a", AccountId: 1, Date: time.Now(), Type: "pemasukan", Category: "gaji", Nominal: 100, Currency: "IDR"},
+		{UserId: "user-a", AccountId: 1, Date: time.Now(), Type: "pengeluaran", Category: "makan", Nominal: 200, Currency: "IDR"},
+	}
+
+	// baris kedua gagal, baris pertama ikut dibatalkan
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO record (user_id, account_id, date, type, category, nominal, currency, description, external_id)"))
+	prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	prepare.ExpectExec().WillReturnError(errors.New("koneksi terputus"))
+	mock.ExpectRollback()
+
+	if err := models.NewFinancalModel(db).ImportFinancialRecords(records); err == nil {
+		t.Error("import harus gagal")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCountFinancialImportKeys(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE user_id = ? AND account_id = ? AND date BETWEEN ? AND ?")).
+		WithArgs("user-a", int64(1), "2024-01-01", "2024-01-31").
+		WillReturnRows(sqlmock.NewRows([]string{"date", "type", "nominal"}).
+			AddRow(day, "pengeluaran", 5000000).
+			AddRow(day, "pengeluaran", 5000000))
+
+	keys, err := models.NewFinancalModel(db).CountFinancialImportKeys("user-a", 1, day.AddDate(0, 0, -1), day.AddDate(0, 0, 29))
+	if err != nil {
+		t.Fatalf("CountFinancialImportKeys error: %v", err)
+	}
+	if got := keys[models.FinancialImportKey(day, "pengeluaran", 5000000)]; got != 2 {
+		t.Errorf("got %d, want 2", got)
+	}
+}
@@ -0,0 +1,174 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/helpers"
+)
+
+func TestParseMoney(t *testing.T) {
+
+	tests := []struct {
+		input string
+		want  int64
+		err   bool
+	}{
+		{"1.500.000,50", 150000050, false},
+		// satu titik dengan tiga angka di belakangnya adalah pemisah ribuan
+		{"1.500", 150000, false},
+		{"1,500.50", 150050, false},
+		{"1,500,000.50", 150000050, false},
+		{"0,5", 50, false},
+		{"1500000", 150000000, false},
+		{"1500000.5", 150000050, false},
+		{"Rp. 1.500.000", 150000000, false},
+		{" Rp 25.000 ", 2500000, false},
+		{"-25.000,75", -2500075, false},
+		{"-0,5", -50, false},
+		{"", 0, true},
+		{"   ", 0, true},
+		{"-", 0, true},
+		{"abc", 0, true},
+		{"12a", 0, true},
+		{"1.5.0,0x", 0, true},
+		{"--5", 0, true},
+		// lebih dari dua angka desimal
+		{"1,5555", 0, true},
+		// batas terbesar int64 dalam sen
+		{"92233720368547757", 9223372036854775700, false},
+		{"92233720368547758", 0, true},
+		{"99999999999999999999", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			money, err := entities.ParseMoney(tt.input, "IDR")
+			if tt.err {
+				if err != entities.ErrInvalidMoney {
+					t.Errorf("got %d, %v; want ErrInvalidMoney", money.Amount, err)
+				}
+				return
+			}
+			if err != nil || money.Amount != tt.want || money.Currency != "IDR" {
+				t.Errorf("got %+v, %v; want %d", money, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDecimalMoney(t *testing.T) {
+
+	tests := []struct {
+		input string
+		want  int64
+		err   bool
+	}{
+		{"1500000.50", 150000050, false},
+		{"1500000", 150000000, false},
+		{"12.5", 1250, false},
+		{".5", 50, false},
+		{"-12.5", -1250, false},
+		{"", 0, true},
+		{"-", 0, true},
+		// pemisah ribuan tidak diterima supaya tidak ambigu
+		{"1.500.000", 0, true},
+		{"1,5", 0, true},
+		{"1500000.505", 0, true},
+		{"1e6", 0, true},
+		{"92233720368547758", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			money, err := entities.ParseDecimalMoney(tt.input, "USD")
+			if tt.err {
+				if err != entities.ErrInvalidMoney {
+					t.Errorf("got %d, %v; want ErrInvalidMoney", money.Amount, err)
+				}
+				return
+			}
+			if err != nil || money.Amount != tt.want {
+				t.Errorf("got %d, %v; want %d", money.Amount, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+
+	tests := []struct {
+		money  entities.Money
+		format string
+		input  string
+	}{
+		{entities.Money{Amount: 150000050, Currency: "IDR"}, "Rp. 1.500.000,50", "1.500.000,50"},
+		{entities.Money{Amount: 5}, "Rp. 0,05", "0,05"},
+		{entities.Money{Amount: 0, Currency: "IDR"}, "Rp. 0,00", "0,00"},
+		{entities.Money{Amount: 100000, Currency: "IDR"}, "Rp. 1.000,00", "1.000,00"},
+		{entities.Money{Amount: -1200, Currency: "USD"}, "-USD 12,00", "-12,00"},
+		{entities.Money{Amount: -2500075, Currency: "IDR"}, "-Rp. 25.000,75", "-25.000,75"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := tt.money.Format(); got != tt.format {
+				t.Errorf("Format: got %q, want %q", got, tt.format)
+			}
+			if got := tt.money.Input(); got != tt.input {
+				t.Errorf("Input: got %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestMoney_RoundTrip(t *testing.T) {
+
+	// nominal yang ditampilkan di form harus terbaca kembali menjadi nominal yang sama
+	for _, amount := range []int64{0, 5, 50, 100000, 150000050, -2500075, 9223372036854775700, -9223372036854775700} {
+		input := entities.Money{Amount: amount}.Input()
+		if money, err := entities.ParseMoney(input, ""); err != nil || money.Amount != amount {
+			t.Errorf("Input %q: got %d, %v; want %d", input, money.Amount, err, amount)
+		}
+
+		decimal := entities.Money{Amount: amount}.Decimal()
+		if money, err := entities.ParseDecimalMoney(decimal, ""); err != nil || money.Amount != amount {
+			t.Errorf("Decimal %q: got %d, %v; want %d", decimal, money.Amount, err, amount)
+		}
+	}
+}
+
+func TestAddFinancial_ValidateNominalAndCurrency(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		input    string
+		currency string
+		field    string
+	}{
+		{"valid", "1.500,50", "IDR", ""},
+		{"nominal negatif", "-5.000", "IDR", "Nominal"},
+		// JPY tidak punya angka desimal, nominal dalam sen akan salah 100 kali
+		{"mata uang tanpa desimal", "1.000", "JPY", "Currency"},
+	}
+	for _, tt := range tests {
+		money, err := entities.ParseMoney(tt.input, tt.currency)
+		if err != nil {
+			t.Fatalf("%s: ParseMoney error: %v", tt.name, err)
+		}
+		financial := entities.AddFinancial{
+			Date:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Type:      "pengeluaran",
+			Nominal:   money.Amount,
+			Currency:  money.Currency,
+			Category:  "makan",
+			AccountId: 1,
+		}
+
+		validation, _ := helpers.NewValidator(nil).Struct(financial).(map[string]interface{})
+		if _, ok := validation[tt.field]; tt.field != "" && !ok {
+			t.Errorf("%s: got %v, want error di %s", tt.name, validation, tt.field)
+		}
+		if tt.field == "" && len(validation) > 0 {
+			t.Errorf("%s: got %v, want valid", tt.name, validation)
+		}
+	}
+}
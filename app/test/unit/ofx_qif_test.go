@@ -0,0 +1,264 @@
+package unit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func readStatementFixture(t *testing.T, name string) []byte {
+
+	content, err := os.ReadFile(filepath.Join("fixtures", "statements", name))
+	if err != nil {
+		t.Fatalf("gagal membaca fixture: %v", err)
+	}
+	return content
+}
+
+// ringkasan baris import untuk dibandingkan
+func importRowSummary(row entities.ImportRow) presetRow {
+
+	description := ""
+	if row.Financial.Description != nil {
+		description = *row.Financial.Description
+	}
+	return presetRow{row.Financial.Date.Format("2006-01-02"), row.Financial.Type, row.Financial.Nominal, description}
+}
+
+func TestDetectStatementFormat(t *testing.T) {
+
+	tests := map[string]string{
+		"sample.ofx": "ofx",
+		"sample.qif": "qif",
+		"bca.csv":    "",
+	}
+	for name, want := range tests {
+		if got := helpers.DetectStatementFormat(readStatementFixture(t, name)); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+
+	if got := helpers.DetectStatementFormat([]byte("<?xml version=\"1.0\"?>\n<?OFX OFXHEADER=\"200\"?>\n<OFX></OFX>")); got != "ofx" {
+		t.Errorf("OFX 2: got %q", got)
+	}
+}
+
+func TestParseOFX_Fixture(t *testing.T) {
+
+	base := entities.AddFinancial{UserId: "user-a", AccountId: 3, Currency: "USD"}
+	rows, err := helpers.ParseOFX(readStatementFixture(t, "sample.ofx"), base, map[string]string{"pemasukan": "gaji", "pengeluaran": "lainnya"})
+	if err != nil {
+		t.Fatalf("ParseOFX error: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d transaksi, want 4", len(rows))
+	}
+
+	want := []presetRow{
+		{"2024-06-03", "pengeluaran", 12500000, "INDOMARET SUDIRMAN Belanja bulanan"},
+		{"2024-06-25", "pemasukan", 850000050, "GAJI PT MAJU & JAYA"},
+		{"2024-06-28", "pengeluaran", 1500000, "BIAYA ADMIN"},
+	}
+	for i, wantRow := range want {
+		row := rows[i]
+		if !row.Valid() {
+			t.Errorf("transaksi %d tidak valid: %v", row.Line, row.Errors)
+			continue
+		}
+		if got := importRowSummary(row); got != wantRow {
+			t.Errorf("transaksi %d: got %+v, want %+v", row.Line, got, wantRow)
+		}
+		// mata uang dari CURDEF, bukan dari base
+		if row.Financial.Currency != "IDR" || row.Financial.AccountId != 3 {
+			t.Errorf("transaksi %d: mata uang/akun tidak sesuai, got %+v", row.Line, row.Financial)
+		}
+	}
+
+	if rows[0].Financial.ExternalId == nil || *rows[0].Financial.ExternalId != "2024060300001" {
+		t.Errorf("FITID harus disimpan sebagai ExternalId, got %v", rows[0].Financial.ExternalId)
+	}
+
+	// tanggal 31 Juni dan nominal 0 tidak valid
+	if rows[3].Valid() || len(rows[3].Errors) != 2 {
+		t.Errorf("transaksi 4 harus punya 2 error, got %v", rows[3].Errors)
+	}
+}
+
+func TestParseOFX_NotOFX(t *testing.T) {
+
+	if _, err := helpers.ParseOFX([]byte("Tanggal,Nominal\n2024-01-02,5000\n"), entities.AddFinancial{}, nil); err == nil {
+		t.Error("file tanpa tag OFX harus mengembalikan error")
+	}
+}
+
+// data export untuk round trip OFX dan QIF
+func exportStatements() []helpers.ExportStatement {
+
+	longDescription := "Pembayaran tagihan kartu kredit bulan Juli <BCA> & lainnya"
+	lunch := "Makan siang"
+	usdBase := int64(16250000)
+	transferId := int64(9)
+
+	return []helpers.ExportStatement{
+		{
+			Account: entities.Account{Id: 3, Name: "BCA", Kind: "bank"},
+			Balance: 500000000,
+			Financials: []entities.Financial{
+				{Id: 11, Date: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), Type: "pengeluaran", Nominal: 5000000, Currency: "IDR", Category: "makan", Description: &lunch},
+				{Id: 12, Date: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC), Type: "pengeluaran", Nominal: 100000, Currency: "USD", BaseNominal: &usdBase, Category: "tagihan", Description: &longDescription},
+				{Id: 13, Date: time.Date(2024, 7, 3, 0, 0, 0, 0, time.UTC), Type: "transfer_masuk", Nominal: 20000000, Currency: "IDR", Category: "transfer", TransferId: &transferId},
+			},
+		},
+		{
+			Account:    entities.Account{Id: 4, Name: "Dompet", Kind: "tunai"},
+			Financials: []entities.Financial{{Id: 14, Date: time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC), Type: "pemasukan", Nominal: 7500050, Currency: "IDR", Category: "gaji"}},
+		},
+	}
+}
+
+func TestWriteOFX_RoundTrip(t *testing.T) {
+
+	var buffer bytes.Buffer
+	startDate, endDate := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC)
+	if err := helpers.WriteOFX(&buffer, exportStatements(), "IDR", startDate, endDate, endDate); err != nil {
+		t.Fatalf("WriteOFX error: %v", err)
+	}
+
+	output := buffer.String()
+	for _, want := range []string{"<CURDEF>IDR</CURDEF>", "<ACCTID>3</ACCTID>", "<TRNTYPE>XFER</TRNTYPE>", "<CURSYM>USD</CURSYM>", "<BALAMT>5000000.00</BALAMT>", "&lt;BCA&gt; &amp;"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output harus berisi %q", want)
+		}
+	}
+
+	rows, err := helpers.ParseOFX(buffer.Bytes(), entities.AddFinancial{Currency: "IDR"}, nil)
+	if err != nil {
+		t.Fatalf("ParseOFX error: %v", err)
+	}
+
+	// nominal USD ditulis dalam mata uang utama, keterangan panjang utuh dari MEMO
+	want := []presetRow{
+		{"2024-07-01", "pengeluaran", 5000000, "Makan siang"},
+		{"2024-07-02", "pengeluaran", 16250000, "Pembayaran tagihan kartu kredit bulan Juli <BCA> & lainnya"},
+		{"2024-07-03", "pemasukan", 20000000, "transfer"},
+		{"2024-07-04", "pemasukan", 7500050, "gaji"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d transaksi, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if got := importRowSummary(row); got != want[i] {
+			t.Errorf("transaksi %d: got %+v, want %+v", i+1, got, want[i])
+		}
+		if row.Financial.ExternalId == nil || *row.Financial.ExternalId != []string{"11", "12", "13", "14"}[i] {
+			t.Errorf("transaksi %d: FITID harus id catatan, got %v", i+1, row.Financial.ExternalId)
+		}
+	}
+}
+
+func TestParseQIF_Fixture(t *testing.T) {
+
+	categories := []entities.Category{{Name: "makan", Type: "pengeluaran"}, {Name: "gaji", Type: "pemasukan"}}
+	defaults := map[string]string{"pemasukan": "gaji", "pengeluaran": "lainnya"}
+
+	rows, err := helpers.ParseQIF(readStatementFixture(t, "sample.qif"), entities.AddFinancial{Currency: "IDR"}, defaults, categories)
+	if err != nil {
+		t.Fatalf("ParseQIF error: %v", err)
+	}
+
+	// tanggal 15/07 berarti file memakai DD/MM, bagian !Type:Invst dilewati
+	want := []presetRow{
+		{"2024-07-15", "pengeluaran", 4500000, "Warung Padang Makan siang"},
+		{"2024-07-16", "pemasukan", 250000000, "Transfer masuk"},
+		{"2024-07-20", "pengeluaran", 30000000, "PLN"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d transaksi, want %d: %+v", len(rows), len(want), rows)
+	}
+
+	wantCategories := []string{"makan", "gaji", "lainnya"}
+	wantLines := []int{2, 8, 13}
+	for i, row := range rows {
+		if !row.Valid() {
+			t.Errorf("baris %d tidak valid: %v", row.Line, row.Errors)
+			continue
+		}
+		if got := importRowSummary(row); got != want[i] {
+			t.Errorf("baris %d: got %+v, want %+v", row.Line, got, want[i])
+		}
+		if row.Financial.Category != wantCategories[i] || row.Line != wantLines[i] {
+			t.Errorf("transaksi %d: got kategori %q baris %d, want %q baris %d", i+1, row.Financial.Category, row.Line, wantCategories[i], wantLines[i])
+		}
+	}
+}
+
+func TestWriteQIF_RoundTrip(t *testing.T) {
+
+	var buffer bytes.Buffer
+	if err := helpers.WriteQIF(&buffer, exportStatements(), "IDR"); err != nil {
+		t.Fatalf("WriteQIF error: %v", err)
+	}
+	if !strings.Contains(buffer.String(), "!Account\nNDompet\nTCash\n^\n!Type:Cash\n") {
+		t.Errorf("akun tunai harus ditulis sebagai Cash:\n%s", buffer.String())
+	}
+
+	categories := []entities.Category{{Name: "makan", Type: "pengeluaran"}, {Name: "gaji", Type: "pemasukan"}}
+	rows, err := helpers.ParseQIF(buffer.Bytes(), entities.AddFinancial{Currency: "IDR"}, map[string]string{"pemasukan": "lainnya", "pengeluaran": "lainnya"}, categories)
+	if err != nil {
+		t.Fatalf("ParseQIF error: %v", err)
+	}
+
+	want := []presetRow{
+		{"2024-07-01", "pengeluaran", 5000000, "Makan siang"},
+		{"2024-07-02", "pengeluaran", 16250000, "Pembayaran tagihan kartu kredit bulan Juli <BCA> & lainnya"},
+		{"2024-07-03", "pemasukan", 20000000, ""},
+		{"2024-07-04", "pemasukan", 7500050, ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d transaksi, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if got := importRowSummary(row); got != want[i] {
+			t.Errorf("transaksi %d: got %+v, want %+v", i+1, got, want[i])
+		}
+	}
+	if rows[0].Financial.Category != "makan" || rows[3].Financial.Category != "gaji" {
+		t.Errorf("kategori harus dibaca ulang, got %q dan %q", rows[0].Financial.Category, rows[3].Financial.Category)
+	}
+}
+
+func TestFindFinancialExternalIds(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("AND external_id IS NOT NULL")).
+		WithArgs("user-a", int64(3), "2024-06-01", "2024-06-30").
+		WillReturnRows(sqlmock.NewRows([]string{"external_id", "date", "type", "nominal"}).
+			AddRow("2024060300001", time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC), "pengeluaran", int64(12500000)))
+
+	start, end := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	externalIds, err := models.NewFinancalModel(db).FindFinancialExternalIds("user-a", 3, start, end)
+	if err != nil {
+		t.Fatalf("FindFinancialExternalIds error: %v", err)
+	}
+	if got := externalIds["2024060300001"]; got != "2024-06-03|pengeluaran|12500000" {
+		t.Errorf("got key %q", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
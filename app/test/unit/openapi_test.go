@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"financial-record/routes"
+)
+
+// catat pattern yang didaftarkan routes.Register tanpa menjalankan handler
+type routeRecorder struct {
+	patterns []string
+}
+
+func (recorder *routeRecorder) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	recorder.patterns = append(recorder.patterns, pattern)
+}
+
+func loadOpenAPI(t *testing.T) map[string]map[string]json.RawMessage {
+
+	file, err := os.ReadFile("../../docs/openapi.json")
+	if err != nil {
+		t.Fatalf("gagal membaca docs/openapi.json: %v", err)
+	}
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(file, &spec); err != nil {
+		t.Fatalf("docs/openapi.json bukan JSON yang valid: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Fatalf("versi openapi harus 3.x, got %q", spec.OpenAPI)
+	}
+
+	return spec.Paths
+}
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func TestOpenAPI_CoversAllRoutes(t *testing.T) {
+
+	paths := loadOpenAPI(t)
+
+	recorder := &routeRecorder{}
+	routes.Register(recorder, nil)
+	if len(recorder.patterns) == 0 {
+		t.Fatal("tidak ada route yang didaftarkan")
+	}
+
+	registered := make(map[string]bool)
+	for _, pattern := range recorder.patterns {
+
+		// pattern "GET /api/v1/records" atau "/home" (semua method)
+		method, path, hasMethod := strings.Cut(pattern, " ")
+		if !hasMethod {
+			method, path = "", pattern
+		}
+		registered[path] = true
+
+		operations, ok := paths[path]
+		if !ok {
+			t.Errorf("route %q belum ditulis di docs/openapi.json", pattern)
+			continue
+		}
+
+		if method != "" {
+			if _, ok := operations[strings.ToLower(method)]; !ok {
+				t.Errorf("route %q: method %s belum ditulis di docs/openapi.json", pattern, method)
+			}
+			continue
+		}
+
+		hasOperation := false
+		for _, operation := range openAPIMethods {
+			if _, ok := operations[operation]; ok {
+				hasOperation = true
+			}
+		}
+		if !hasOperation {
+			t.Errorf("route %q belum punya operation di docs/openapi.json", pattern)
+		}
+	}
+
+	// path di dokumen yang route-nya sudah dihapus
+	for path := range paths {
+		if !registered[path] {
+			t.Errorf("path %q ada di docs/openapi.json tetapi tidak didaftarkan di routes", path)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package unit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"financial-record/controllers"
+	"financial-record/helpers"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// ganti folder foto profil ke folder sementara selama test
+func useTempPhotoDir(t *testing.T) string {
+	dir := t.TempDir()
+	previous := helpers.ProfilePhotoDir
+	helpers.ProfilePhotoDir = dir
+	t.Cleanup(func() { helpers.ProfilePhotoDir = previous })
+	return dir
+}
+
+func photoDirFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("gagal membaca folder foto: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func newProfileRequest(t *testing.T, filename string, photo []byte) *http.Request {
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("name", "User A")
+	writer.WriteField("email", "a@example.com")
+	writer.WriteField("base_currency", "IDR")
+	part, _ := writer.CreateFormFile("photo", filename)
+	part.Write(photo)
+	writer.Close()
+
+	request, _ := newSessionRequest(http.MethodPost, "/profile", "user-a", nil)
+	request.Body = io.NopCloser(&body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func TestSaveProfilePhoto(t *testing.T) {
+
+	dir := useTempPhotoDir(t)
+
+	first, err := helpers.SaveProfilePhoto([]byte("foto pertama"), ".jpg")
+	if err != nil {
+		t.Fatalf("SaveProfilePhoto error: %v", err)
+	}
+	second, _ := helpers.SaveProfilePhoto([]byte("foto kedua"), ".jpg")
+	if first == second || !regexp.MustCompile(`^profile_[A-Za-z0-9_-]{22}\.jpg$`).MatchString(first) {
+		t.Errorf("nama foto harus acak dan unik, got %q dan %q", first, second)
+	}
+	if files := photoDirFiles(t, dir); len(files) != 2 {
+		t.Errorf("file sementara harus sudah di-rename, got %v", files)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, first)); string(content) != "foto pertama" {
+		t.Errorf("isi foto: got %q", content)
+	}
+
+	// nama yang berisi path tidak boleh menghapus file di luar folder foto
+	outside := filepath.Join(t.TempDir(), "penting.txt")
+	os.WriteFile(outside, []byte("x"), 0644)
+	helpers.DeleteProfilePhoto("../" + filepath.Base(filepath.Dir(outside)) + "/penting.txt")
+	if _, err := os.Stat(outside); err != nil {
+		t.Error("file di luar folder foto ikut terhapus")
+	}
+	if err := helpers.DeleteProfilePhoto(first); err != nil || len(photoDirFiles(t, dir)) != 1 {
+		t.Errorf("foto harus terhapus, got %v", err)
+	}
+	if err := helpers.DeleteProfilePhoto(first); err != nil {
+		t.Errorf("foto yang sudah tidak ada bukan error, got %v", err)
+	}
+}
+
+func TestProfilePhotoHandler(t *testing.T) {
+
+	dir := useTempPhotoDir(t)
+	filename, _ := helpers.SaveProfilePhoto([]byte("foto"), ".png")
+	os.WriteFile(filepath.Join(dir, ".upload-123"), []byte("setengah"), 0644)
+	handler := helpers.ProfilePhotoHandler()
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/user_photo/" + filename, http.StatusOK},
+		{"/user_photo/", http.StatusNotFound},
+		{"/user_photo/.upload-123", http.StatusNotFound},
+		{"/user_photo/profile_tidak_ada.png", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.status {
+			t.Errorf("%s: got status %d, want %d", tt.path, recorder.Code, tt.status)
+		}
+	}
+}
+
+func TestUserController_ProfilePhoto(t *testing.T) {
+
+	dir := useTempPhotoDir(t)
+	os.WriteFile(filepath.Join(dir, "profile_lama.jpg"), []byte("foto lama"), 0644)
+
+	tests := []struct {
+		name      string
+		updateErr error
+		keepsOld  bool
+	}{
+		{"gagal update", errors.New("database mati"), true},
+		{"berhasil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+			captureRenderTemplate(t)
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT photo FROM users WHERE id = ?")).
+				WithArgs("user-a").
+				WillReturnRows(sqlmock.NewRows([]string{"photo"}).AddRow("profile_lama.jpg"))
+			update := mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET name = ?, email = ?, photo = ?"))
+			if tt.updateErr != nil {
+				update.WillReturnError(tt.updateErr)
+			} else {
+				update.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			recorder := httptest.NewRecorder()
+			controllers.NewUserController(db).Profile(recorder, newProfileRequest(t, "foto.jpg", exifJPEG(t, 40, 20, 6)))
+
+			// selalu tersisa satu foto: foto lama jika gagal, foto baru jika berhasil
+			files := photoDirFiles(t, dir)
+			if len(files) != 1 {
+				t.Fatalf("got %v, want satu file", files)
+			}
+			if keepsOld := files[0] == "profile_lama.jpg"; keepsOld != tt.keepsOld {
+				t.Errorf("foto tersisa: got %v", files)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestUserController_ProfilePhotoWebP(t *testing.T) {
+
+	dir := useTempPhotoDir(t)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+	captureRenderTemplate(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT photo FROM users WHERE id = ?")).
+		WithArgs("user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"photo"}).AddRow(nil))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET name = ?, email = ?, photo = ?")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	recorder := httptest.NewRecorder()
+	controllers.NewUserController(db).Profile(recorder, newProfileRequest(t, "foto.webp", readImageFixture(t, "struk.webp")))
+
+	// WEBP disimpan ulang sebagai JPEG
+	files := photoDirFiles(t, dir)
+	if len(files) != 1 || filepath.Ext(files[0]) != ".jpg" {
+		t.Fatalf("got %v, want satu foto .jpg", files)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
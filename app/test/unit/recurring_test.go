@@ -0,0 +1,165 @@
+package unit
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRecurrenceDate(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		frequency string
+		n         int
+		want      time.Time
+	}{
+		{"harian", date(2024, 12, 31), "daily", 1, date(2025, 1, 1)},
+		{"mingguan", date(2024, 2, 26), "weekly", 1, date(2024, 3, 4)},
+		{"bulanan", date(2024, 1, 15), "monthly", 3, date(2024, 4, 15)},
+		// tanggal 31 dibulatkan ke akhir bulan yang lebih pendek
+		{"akhir bulan kabisat", date(2024, 1, 31), "monthly", 1, date(2024, 2, 29)},
+		{"akhir bulan bukan kabisat", date(2023, 1, 31), "monthly", 1, date(2023, 2, 28)},
+		{"akhir bulan 30 hari", date(2024, 1, 31), "monthly", 3, date(2024, 4, 30)},
+		// bulan berikutnya kembali ke tanggal 31, bukan ikut tanggal bulan sebelumnya
+		{"kembali ke tanggal 31", date(2024, 1, 31), "monthly", 2, date(2024, 3, 31)},
+		{"lewat tahun", date(2024, 11, 30), "monthly", 3, date(2025, 2, 28)},
+		{"tahunan 29 Februari", date(2024, 2, 29), "yearly", 1, date(2025, 2, 28)},
+		{"tahunan kembali ke kabisat", date(2024, 2, 29), "yearly", 4, date(2028, 2, 29)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := helpers.RecurrenceDate(tt.start, tt.frequency, tt.n); !got.Equal(tt.want) {
+				t.Errorf("got %s, want %s", got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestRecurrenceDates_Limits(t *testing.T) {
+
+	end := date(2024, 4, 30)
+	count := 2
+
+	tests := []struct {
+		name  string
+		end   *time.Time
+		count *int
+		want  int
+	}{
+		{"sampai hari ini", nil, nil, 6},
+		{"tanggal akhir", &end, nil, 4},
+		{"jumlah pengulangan", nil, &count, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates := helpers.RecurrenceDates(date(2024, 1, 31), "monthly", tt.end, tt.count, date(2024, 6, 30))
+			if len(dates) != tt.want {
+				t.Errorf("got %d tanggal, want %d", len(dates), tt.want)
+			}
+		})
+	}
+
+	next := helpers.NextRecurrenceDates(date(2024, 1, 31), "monthly", nil, nil, date(2024, 2, 29), 2)
+	if len(next) != 2 || !next[0].Equal(date(2024, 3, 31)) || !next[1].Equal(date(2024, 4, 30)) {
+		t.Errorf("pengulangan berikutnya: got %v", next)
+	}
+}
+
+func TestRecurringModel_MaterializeDueRecurring_Idempotent(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2024, 3, 31, 8, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("FROM recurring WHERE paused = 0 AND start_date <= ?")).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "type", "category", "account_id", "nominal", "currency", "description", "frequency", "start_date", "end_date", "occurrences", "paused"}).
+			AddRow(int64(5), "user-a", "pengeluaran", "sewa", int64(1), int64(2000000), "IDR", nil, "monthly", date(2024, 1, 31), nil, nil, false))
+
+	// 31 Januari sudah dibuat pada jalannya scheduler sebelumnya
+	mock.ExpectQuery(regexp.QuoteMeta("FROM recurring_occurrences")).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"recurring_id", "occurrence_date", "status", "record_id", "nominal", "description"}).
+			AddRow(int64(5), date(2024, 1, 31), "materialized", int64(10), nil, nil))
+
+	// 29 Februari dibuat oleh proses lain setelah jadwal dibaca, tidak boleh dibuat lagi
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO recurring_occurrences")).
+		WithArgs(int64(5), date(2024, 2, 29)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).
+		WithArgs(int64(5), date(2024, 2, 29)).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "record_id", "nominal", "description"}).AddRow("materialized", int64(11), nil, nil))
+	mock.ExpectCommit()
+
+	// 31 Maret belum pernah dibuat
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO recurring_occurrences")).
+		WithArgs(int64(5), date(2024, 3, 31)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).
+		WithArgs(int64(5), date(2024, 3, 31)).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "record_id", "nominal", "description"}).AddRow("scheduled", nil, nil, nil))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO record")).
+		WithArgs("user-a", int64(1), date(2024, 3, 31), "pengeluaran", "sewa", int64(2000000), "IDR", nil).
+		WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE recurring_occurrences SET status = 'materialized', record_id = ?")).
+		WithArgs(int64(12), int64(5), date(2024, 3, 31)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	created, err := models.NewRecurringModel(db).MaterializeDueRecurring(now)
+	if err != nil || created != 1 {
+		t.Fatalf("got %d dibuat, error %v", created, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRecurringModel_SetRecurringPaused_SkipsBeforeToday(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC()
+	today := date(now.Year(), now.Month(), now.Day())
+	recurring := entities.Recurring{Id: 5, UserId: "user-a", Frequency: "daily", StartDate: today.AddDate(0, 0, -2)}
+
+	// dua hari sebelumnya dilewati, pengulangan hari ini tetap dibuat scheduler
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE recurring SET paused = ?")).
+		WithArgs(false, sqlmock.AnyArg(), int64(5), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, skipped := range []time.Time{today.AddDate(0, 0, -2), today.AddDate(0, 0, -1)} {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO recurring_occurrences (recurring_id, occurrence_date, status) VALUES (?,?,'skipped')")).
+			WithArgs(int64(5), skipped).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	if err := models.NewRecurringModel(db).SetRecurringPaused(recurring, false); err != nil {
+		t.Fatalf("SetRecurringPaused error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,114 @@
+package unit
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/helpers"
+	"financial-record/models"
+	"financial-record/views"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+
+	tests := []struct {
+		query        string
+		wantTerms    []string
+		wantNominals []int64
+	}{
+		{"parkir", []string{"parkir"}, nil},
+		{"Parkir mall 5.000", []string{"parkir", "mall"}, []int64{500000}},
+		{"1.500.000,50", nil, []int64{150000050}},
+		{"+bensin* -(tol)", []string{"bensin", "tol"}, nil},
+		{"kopi, 2x", []string{"kopi", "2x"}, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			terms, nominals := helpers.ParseSearchQuery(tc.query)
+			if !reflect.DeepEqual(terms, tc.wantTerms) {
+				t.Errorf("terms mismatch: got %v, want %v", terms, tc.wantTerms)
+			}
+			if !reflect.DeepEqual(nominals, tc.wantNominals) {
+				t.Errorf("nominals mismatch: got %v, want %v", nominals, tc.wantNominals)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+
+	got := views.Highlight("Bayar PARKIR <mall>", []string{"parkir", "mall"})
+	want := "Bayar <mark>PARKIR</mark> &lt;<mark>mall</mark>&gt;"
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if got := views.Highlight("<b>", nil); string(got) != "&lt;b&gt;" {
+		t.Errorf("teks tanpa kata kunci harus tetap di-escape, got %q", got)
+	}
+}
+
+func TestSearchFinancial(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	startDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	minimum := int64(100000)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`AND (MATCH(r.description) AGAINST (? IN BOOLEAN MODE) OR r.category LIKE ? ESCAPE '\\' OR r.category LIKE ? ESCAPE '\\')`+
+		" AND r.nominal IN (?) AND r.nominal >= ? AND r.date >= ?"+
+		" ORDER BY MATCH(r.description) AGAINST (? IN BOOLEAN MODE) DESC, r.date DESC, r.id DESC LIMIT ?")).
+		WithArgs("user-a", "parkir* mall*", "%parkir%", "%mall%", int64(500000), minimum, "2024-03-01", "parkir* mall*", 100).
+		WillReturnRows(sqlmock.NewRows(pageColumns).AddRow(pageRow(1, startDate, 500000)...))
+
+	search := entities.FinancialSearch{
+		UserId:     "user-a",
+		Terms:      []string{"parkir", "mall"},
+		Nominals:   []int64{500000},
+		NominalMin: &minimum,
+		StartDate:  &startDate,
+		Limit:      100,
+	}
+	financials, err := models.NewFinancalModel(db).SearchFinancial(search)
+	if err != nil {
+		t.Fatalf("SearchFinancial error: %v", err)
+	}
+	if len(financials) != 1 {
+		t.Errorf("got %d rows, want 1", len(financials))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSearchFinancial_EscapesLikeWildcards(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// % dan _ yang diketik user bukan wildcard
+	mock.ExpectQuery(regexp.QuoteMeta(`r.category LIKE ? ESCAPE '\\'`)).
+		WithArgs("user-a", `diskon_50%* a\b*`, `%diskon\_50\%%`, `%a\\b%`, `diskon_50%* a\b*`, 100).
+		WillReturnRows(sqlmock.NewRows(pageColumns))
+
+	search := entities.FinancialSearch{UserId: "user-a", Terms: []string{`diskon_50%`, `a\b`}, Limit: 100}
+	if _, err := models.NewFinancalModel(db).SearchFinancial(search); err != nil {
+		t.Fatalf("SearchFinancial error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
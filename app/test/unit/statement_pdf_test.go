@@ -0,0 +1,201 @@
+package unit
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"financial-record/controllers"
+	"financial-record/entities"
+	"financial-record/helpers"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// lampiran PNG 300x200 dengan bagian transparan
+func pngAttachment(t *testing.T) []byte {
+
+	picture := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			picture.Set(x, y, color.NRGBA{R: uint8(x), G: 80, B: 160, A: uint8(y)})
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, picture); err != nil {
+		t.Fatalf("gagal membuat PNG: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func writeStatementPDF(t *testing.T, statement helpers.StatementPDF) string {
+
+	var buffer bytes.Buffer
+	if err := helpers.WriteStatementPDF(&buffer, statement); err != nil {
+		t.Fatalf("WriteStatementPDF error: %v", err)
+	}
+	return buffer.String()
+}
+
+// cek header, trailer dan posisi setiap objek di tabel xref
+func checkPDFStructure(t *testing.T, content string) {
+
+	if !strings.HasPrefix(content, "%PDF-1.4\n") || !strings.HasSuffix(content, "%%EOF\n") {
+		t.Fatal("PDF harus diawali header PDF-1.4 dan diakhiri EOF")
+	}
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindStringSubmatch(content)
+	if match == nil {
+		t.Fatal("startxref tidak ditemukan")
+	}
+	xref, _ := strconv.Atoi(match[1])
+	if !strings.HasPrefix(content[xref:], "xref\n") {
+		t.Fatalf("startxref %d tidak menunjuk ke tabel xref", xref)
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(content[xref:], -1)
+	if len(entries) == 0 {
+		t.Fatal("tabel xref kosong")
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[1])
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !strings.HasPrefix(content[offset:], want) {
+			t.Errorf("xref objek %d menunjuk ke %q", i+1, content[offset:min(offset+12, len(content))])
+		}
+	}
+}
+
+func TestWriteStatementPDF(t *testing.T) {
+
+	attachments := map[string][]byte{"attachments/user-a/struk.png": pngAttachment(t), "attachments/user-a/rusak.jpg": []byte("bukan-gambar")}
+	description := "Makan (siang) \\ kopi"
+	content := writeStatementPDF(t, helpers.StatementPDF{
+		UserName:         "Budi Santoso",
+		Period:           "01 Jan 2024 - 31 Jan 2024",
+		Currency:         "IDR",
+		TotalPemasukan:   1000000000,
+		TotalPengeluaran: 5000050,
+		MissingRates:     1,
+		Categories: []entities.CategoryTotal{
+			{Type: "pemasukan", Category: "gaji", Count: 1, Total: 1000000000},
+			{Type: "pengeluaran", Category: "makan", Count: 1, Total: 3750000},
+			{Type: "pengeluaran", Category: "transport", Count: 1, Total: 1250050},
+		},
+		Financials: []entities.Financial{
+			{Id: 1, Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Type: "pengeluaran", Category: "makan", Nominal: 3750000, Currency: "IDR", Description: &description,
+				Attachments: []entities.Attachment{{StorageKey: "attachments/user-a/invoice.pdf", ContentType: "application/pdf"}, {StorageKey: "attachments/user-a/struk.png", ContentType: "image/png"}}},
+			{Id: 2, Date: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Type: "pengeluaran", Category: "transport", Nominal: 1250050, Currency: "IDR",
+				Attachments: []entities.Attachment{{StorageKey: "attachments/user-a/rusak.jpg", ContentType: "image/jpeg"}, {StorageKey: "attachments/user-a/nota.pdf", ContentType: "application/pdf"}}},
+			{Id: 3, Date: time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC), Type: "pemasukan", Category: "gaji", Nominal: 1000000000, Currency: "IDR"},
+		},
+		GeneratedAt: time.Date(2024, 2, 1, 8, 30, 0, 0, time.UTC),
+		ReadAttachment: func(key string) ([]byte, error) {
+			return attachments[key], nil
+		},
+	})
+	checkPDFStructure(t, content)
+
+	for _, want := range []string{
+		"(Laporan Keuangan)", "(: Budi Santoso)", "(: 01 Jan 2024 - 31 Jan 2024)",
+		"(Rp. 10.000.000,00)", "(Rp. 50.000,50)", "(Rp. 9.949.999,50)",
+		"(75.0%)", "(25.0%)", "(100.0%)",
+		`(Makan \(siang\) \\ kopi)`, "(Halaman 1 dari 1)", "(* 1 catatan belum punya kurs", "(2 file)",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("PDF harus berisi %q", want)
+		}
+	}
+
+	// hanya gambar pertama yang valid yang menjadi thumbnail, diperkecil ke 96 pixel. Catatan
+	// tanpa gambar yang bisa dibaca hanya menampilkan jumlah file
+	if got := strings.Count(content, "/Subtype /Image"); got != 1 {
+		t.Errorf("got %d gambar, want 1", got)
+	}
+	if !strings.Contains(content, "/Width 96 /Height 64") {
+		t.Error("thumbnail harus diperkecil menjadi 96x64")
+	}
+}
+
+func TestWriteStatementPDF_PageBreak(t *testing.T) {
+
+	var financials []entities.Financial
+	for i := 0; i < 100; i++ {
+		financials = append(financials, entities.Financial{Id: int64(i + 1), Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Type: "pengeluaran", Category: "makan", Nominal: 1000000, Currency: "IDR"})
+	}
+	content := writeStatementPDF(t, helpers.StatementPDF{Period: "Januari", Currency: "IDR", Financials: financials})
+	checkPDFStructure(t, content)
+
+	// 100 baris tidak muat di satu halaman, header tabel diulang di setiap halaman
+	if !strings.Contains(content, "/Count 3") || !strings.Contains(content, "(Halaman 3 dari 3)") {
+		t.Error("PDF harus terdiri dari 3 halaman")
+	}
+	if got := strings.Count(content, "(Lampiran)"); got != 3 {
+		t.Errorf("header tabel transaksi: got %d, want 3", got)
+	}
+	if !strings.Contains(content, "(100)") {
+		t.Error("baris terakhir tidak ditulis")
+	}
+}
+
+func TestFinancialController_DownloadPDF(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT base_currency FROM users WHERE id = ?")).
+		WithArgs("user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"base_currency"}).AddRow("IDR"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT email, name, photo, base_currency FROM users WHERE id = ?")).
+		WithArgs("user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"email", "name", "photo", "base_currency"}).AddRow("budi@example.com", "Budi", nil, "IDR"))
+	mock.ExpectQuery("AS total_pemasukan").
+		WillReturnRows(sqlmock.NewRows([]string{"total_pemasukan", "total_pengeluaran"}).AddRow(int64(0), int64(5000050)))
+	mock.ExpectQuery("IS NULL").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("GROUP BY r.type, r.category").
+		WillReturnRows(sqlmock.NewRows([]string{"type", "category", "count", "total"}).AddRow("pengeluaran", "makan", 1, int64(5000050)))
+	mock.ExpectQuery(regexp.QuoteMeta("r.description, r.created_at")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date", "type", "category", "color", "icon", "account_id", "account_name", "transfer_id", "nominal", "currency", "base_nominal", "description", "created_at"}).
+			AddRow(int64(1), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "pengeluaran", "makan", "#6c757d", "", int64(1), "Dompet", nil, int64(5000050), "IDR", int64(5000050), nil, time.Now()))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments")).
+		WithArgs("user-a", int64(1)).
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).AddRow(attachmentRow(3, 1, "attachments/user-a/struk.png", "image/png")...))
+
+	// lampiran dibaca dari storage
+	useTempStorage(t).Put("attachments/user-a/struk.png", bytes.NewReader(pngAttachment(t)), "image/png")
+
+	request, _ := newSessionRequest(http.MethodGet, "/financial/download_financial_record?preset=custom&start_date=2024-01-01&end_date=2024-01-31", "user-a", nil)
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).DownloadFinancialRecord(recorder, request)
+
+	if got := recorder.Header().Get("Content-Disposition"); got != `attachment; filename="financial-record_2024-01-01_2024-01-31.pdf"` {
+		t.Errorf("Content-Disposition: got %q", got)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != "application/pdf" {
+		t.Errorf("Content-Type: got %q", got)
+	}
+
+	content := recorder.Body.String()
+	checkPDFStructure(t, content)
+	for _, want := range []string{"(: Budi)", "(: 01 Jan 2024 - 31 Jan 2024)", "(makan)", "/Subtype /Image"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("PDF harus berisi %q", want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
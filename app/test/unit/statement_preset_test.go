@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/helpers"
+)
+
+// baris yang diharapkan dari file fixture
+type presetRow struct {
+	date        string
+	financeType string
+	nominal     int64
+	description string
+}
+
+// baca file mutasi di test/fixtures/statements lalu parse dengan preset
+func parseStatementFixture(t *testing.T, preset string) []entities.ImportRow {
+
+	content, err := os.ReadFile(filepath.Join("fixtures", "statements", preset+".csv"))
+	if err != nil {
+		t.Fatalf("gagal membaca fixture: %v", err)
+	}
+	rows, err := helpers.ReadStatementCSV(content)
+	if err != nil {
+		t.Fatalf("ReadStatementCSV error: %v", err)
+	}
+
+	statementPreset, ok := helpers.FindStatementPreset(preset)
+	if !ok {
+		t.Fatalf("preset %q tidak ditemukan", preset)
+	}
+
+	base := entities.AddFinancial{UserId: "user-a", AccountId: 3, Currency: "IDR"}
+	defaults := map[string]string{"pemasukan": "gaji", "pengeluaran": "lainnya"}
+	result, err := statementPreset.Parse(rows, base, defaults)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	return result
+}
+
+func TestStatementPresets_Fixtures(t *testing.T) {
+
+	tests := map[string][]presetRow{
+		// bulan Januari tanpa tahun mengikuti periode 28/12/2023 - 05/01/2024, baris PEND dan ringkasan saldo dilewati
+		"bca": {
+			{"2023-12-28", "pengeluaran", 5000000, "TRSF E-BANKING DB 2812/FTSCY/WS95031 50000.00 TOKOPEDIA"},
+			{"2023-12-30", "pemasukan", 750000000, "TRSF E-BANKING CR 3012/FTSCY/WS95031 GAJI DESEMBER"},
+			{"2024-01-02", "pengeluaran", 1000000, "BIAYA ADM"},
+		},
+		"mandiri": {
+			{"2024-03-01", "pengeluaran", 15000000, "Transfer ke BCA TRF 998877"},
+			{"2024-03-05", "pemasukan", 825000050, "Gaji Maret PYR-0324"},
+			{"2024-03-10", "pengeluaran", 2500000, "Pembelian Pulsa"},
+		},
+		"bri": {
+			{"2024-04-03", "pemasukan", 125000000, "TRANSFER DARI SITI AMINAH"},
+			{"2024-04-07", "pengeluaran", 34550000, "PEMBAYARAN PLN 5123456789"},
+			{"2024-04-15", "pengeluaran", 1250000, "BIAYA ADMIN BULANAN"},
+		},
+		// transaksi Failed dilewati
+		"gopay": {
+			{"2024-05-01", "pengeluaran", 4500000, "GoFood - Warung Padang"},
+			{"2024-05-02", "pemasukan", 50000000, "Top Up dari BCA"},
+			{"2024-05-04", "pemasukan", 225050, "Cashback GoFood"},
+		},
+		"ovo": {
+			{"2024-06-01", "pengeluaran", 6200000, "Grab - GrabFood"},
+			{"2024-06-03", "pemasukan", 30000000, "Top Up via BCA Virtual Account"},
+			{"2024-06-12", "pengeluaran", 10000000, "Transfer ke Bank Mandiri"},
+			{"2024-06-15", "pemasukan", 500000, "Cashback Tokopedia"},
+		},
+	}
+
+	for preset, want := range tests {
+		t.Run(preset, func(t *testing.T) {
+			rows := parseStatementFixture(t, preset)
+			if len(rows) != len(want) {
+				t.Fatalf("got %d baris, want %d: %+v", len(rows), len(want), rows)
+			}
+
+			for i, row := range rows {
+				if !row.Valid() {
+					t.Errorf("baris %d tidak valid: %v", row.Line, row.Errors)
+					continue
+				}
+				financial := row.Financial
+				description := ""
+				if financial.Description != nil {
+					description = *financial.Description
+				}
+				got := presetRow{financial.Date.Format("2006-01-02"), financial.Type, financial.Nominal, description}
+				if got != want[i] {
+					t.Errorf("baris %d: got %+v, want %+v", row.Line, got, want[i])
+				}
+
+				// kategori default dan data akun dari base
+				wantCategory := map[string]string{"pemasukan": "gaji", "pengeluaran": "lainnya"}[financial.Type]
+				if financial.Category != wantCategory || financial.AccountId != 3 || financial.UserId != "user-a" || financial.Currency != "IDR" {
+					t.Errorf("baris %d: kategori/akun tidak sesuai, got %+v", row.Line, financial)
+				}
+			}
+		})
+	}
+}
+
+func TestStatementPreset_WrongFile(t *testing.T) {
+
+	rows, err := helpers.ReadStatementCSV([]byte("Tanggal,Keterangan,Nominal\n2024-01-02,Gaji,5000\n"))
+	if err != nil {
+		t.Fatalf("ReadStatementCSV error: %v", err)
+	}
+
+	preset, _ := helpers.FindStatementPreset("mandiri")
+	if _, err := preset.Parse(rows, entities.AddFinancial{}, nil); err == nil {
+		t.Error("file dengan header berbeda harus mengembalikan error")
+	}
+
+	if _, ok := helpers.FindStatementPreset("bank-lain"); ok {
+		t.Error("preset yang tidak dikenal harus false")
+	}
+}
+
+func TestStatementPreset_InvalidRow(t *testing.T) {
+
+	rows := [][]string{
+		{"Date", "Transaction ID", "Description", "Amount", "Status"},
+		{"01-05-2024", "GP-1", "Format tanggal salah", "-5.000", "Success"},
+		{"2024-05-02 10:00:00", "GP-2", "Nominal kosong", "", "Success"},
+	}
+
+	preset, _ := helpers.FindStatementPreset("gopay")
+	result, err := preset.Parse(rows, entities.AddFinancial{}, nil)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(result) != 2 || result[0].Valid() || result[1].Valid() {
+		t.Fatalf("kedua baris harus tidak valid, got %+v", result)
+	}
+	if result[0].Line != 2 || !result[1].Financial.Date.Equal(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("nomor baris atau tanggal tidak sesuai, got %+v", result)
+	}
+}
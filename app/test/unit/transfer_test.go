@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"financial-record/entities"
+	"financial-record/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func transferData() entities.Transfer {
+	return entities.Transfer{
+		UserId:        "user-a",
+		Date:          time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		FromAccountId: 1,
+		ToAccountId:   2,
+		Nominal:       50000000,
+		Currency:      "IDR",
+	}
+}
+
+func TestTransferModel_AddTransfer(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	data := transferData()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transfers")).
+		WithArgs("user-a", int64(1), int64(2), data.Date, int64(50000000), "IDR", nil).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	// kedua sisi transfer disimpan dengan satu query dan memakai id transfer yang sama
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO record")).
+		WithArgs(
+			"user-a", int64(1), int64(9), data.Date, models.TransferOutType, models.TransferCategory, int64(50000000), "IDR", nil,
+			"user-a", int64(2), int64(9), data.Date, models.TransferInType, models.TransferCategory, int64(50000000), "IDR", nil,
+		).
+		WillReturnResult(sqlmock.NewResult(20, 2))
+	mock.ExpectCommit()
+
+	if err := models.NewTransferModel(db).AddTransfer(data); err != nil {
+		t.Fatalf("AddTransfer error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransferModel_AddTransfer_RollbackOnFailure(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// catatan gagal disimpan, transfer tanpa catatan tidak boleh tersisa
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO transfers")).
+		WillReturnResult(sqlmock.NewResult(9, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO record")).
+		WillReturnError(errors.New("database mati"))
+	mock.ExpectRollback()
+
+	if err := models.NewTransferModel(db).AddTransfer(transferData()); err == nil {
+		t.Fatal("AddTransfer harus gagal")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
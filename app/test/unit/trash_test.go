@@ -0,0 +1,331 @@
+package unit
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"financial-record/controllers"
+	"financial-record/entities"
+	"financial-record/models"
+	"financial-record/scheduler"
+	"financial-record/storage"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// query mencari catatan di sampah, transferId nil untuk catatan biasa
+func expectTrashedRecord(mock sqlmock.Sqlmock, recordId int64, found bool, transferId interface{}) {
+	rows := sqlmock.NewRows([]string{"transfer_id"})
+	if found {
+		rows.AddRow(transferId)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT transfer_id FROM record WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL")).
+		WithArgs(recordId, "user-a").
+		WillReturnRows(rows)
+}
+
+// query purge satu catatan biasa di sampah dalam satu transaksi, attachments berisi
+// baris lampiran catatan yang ikut terhapus
+func expectPurgeRecord(mock sqlmock.Sqlmock, recordId int64, found bool, attachments ...[]driver.Value) {
+	mock.ExpectBegin()
+	expectTrashedRecord(mock, recordId, found, nil)
+	if !found {
+		mock.ExpectRollback()
+		return
+	}
+	rows := sqlmock.NewRows(attachmentColumns)
+	for _, attachment := range attachments {
+		rows.AddRow(attachment...)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments WHERE record_id = ? AND user_id = ?")).
+		WithArgs(recordId, "user-a").
+		WillReturnRows(rows)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM attachments WHERE record_id = ? AND user_id = ?")).
+		WithArgs(recordId, "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM record WHERE id = ? AND user_id = ?")).
+		WithArgs(recordId, "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestFinancialModel_DeletedRecordsExcluded(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// total dan list tidak menghitung catatan di sampah
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE r.user_id = ? AND r.deleted_at IS NULL AND r.date BETWEEN ? AND ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"total_pemasukan", "total_pengeluaran"}).AddRow(int64(0), int64(0)))
+	mock.ExpectQuery(regexp.QuoteMeta("r.description, r.created_at") + `\s+FROM record r[\s\S]+` + regexp.QuoteMeta("WHERE r.user_id = ? AND r.deleted_at IS NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	filter := entities.FinancialFilter{UserId: "user-a", StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}
+	model := models.NewFinancalModel(db)
+	model.GetFinancialTotalNominal(filter)
+	model.FindAllFinancial(filter)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFinancialController_Delete_RequiresPost(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// link GET lama tidak boleh menghapus catatan
+	request, _ := newSessionRequest(http.MethodGet, "/financial/delete_financial_record?id=7", "user-a", nil)
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).DeleteFinancialRecord(recorder, request)
+
+	if recorder.Code != http.StatusSeeOther || recorder.Header().Get("Location") != "/home" {
+		t.Errorf("got status %d location %q", recorder.Code, recorder.Header().Get("Location"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFinancialController_Trash(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+	rendered := captureRenderTemplate(t)
+
+	deletedAt := time.Date(2024, 2, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT base_currency FROM users WHERE id = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"base_currency"}).AddRow("IDR"))
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE r.user_id = ? AND r.deleted_at IS NOT NULL")).
+		WithArgs("user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "date", "type", "category", "color", "icon", "account_id", "account_name", "transfer_id", "nominal", "currency", "base_nominal", "description", "created_at", "deleted_at"}).
+			AddRow(int64(7), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "pengeluaran", "makan", "#6c757d", "", int64(1), "Dompet", nil, int64(1500000), "IDR", int64(1500000), nil, time.Now(), deletedAt))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments")).
+		WithArgs("user-a", int64(7)).
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).AddRow(attachmentRow(3, 7, "attachments/user-a/struk.png", "image/png")...))
+
+	request, _ := newSessionRequest(http.MethodGet, "/financial/trash", "user-a", nil)
+	controllers.NewFinancialController(db).Trash(httptest.NewRecorder(), request)
+
+	if rendered.path != "views/financial/trash.html" || rendered.data["error"] != nil {
+		t.Fatalf("got template %q error %v", rendered.path, rendered.data["error"])
+	}
+	financials := rendered.data["financials"].([]entities.Financial)
+	if len(financials) != 1 || financials[0].DeletedAt == nil || !financials[0].DeletedAt.Equal(deletedAt) || len(financials[0].Attachments) != 1 {
+		t.Errorf("isi sampah: got %+v", financials)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFinancialController_RestoreFinancialRecord(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		found      bool
+		transferId interface{}
+		status     int
+	}{
+		{"dikembalikan", true, nil, http.StatusSeeOther},
+		// kedua catatan transfer dikembalikan bersama
+		{"transfer", true, int64(4), http.StatusSeeOther},
+		// catatan milik user lain atau yang tidak ada di sampah
+		{"tidak ada di sampah", false, nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("gagal membuat sqlmock: %v", err)
+			}
+			defer db.Close()
+			captureRenderTemplate(t)
+
+			expectTrashedRecord(mock, 7, tt.found, tt.transferId)
+			if tt.transferId != nil {
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE record SET deleted_at = NULL, updated_at = updated_at WHERE transfer_id = ? AND user_id = ? AND deleted_at IS NOT NULL")).
+					WithArgs(tt.transferId, "user-a").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			} else if tt.found {
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE record SET deleted_at = NULL, updated_at = updated_at WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL")).
+					WithArgs(int64(7), "user-a").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			request, session := newSessionRequest(http.MethodPost, "/financial/restore_financial_record", "user-a", url.Values{"id": {"7"}})
+			recorder := httptest.NewRecorder()
+			controllers.NewFinancialController(db).RestoreFinancialRecord(recorder, request)
+
+			if recorder.Code != tt.status {
+				t.Errorf("got status %d, want %d", recorder.Code, tt.status)
+			}
+			if flashes := session.Flashes("success"); (len(flashes) > 0) != tt.found {
+				t.Errorf("flash sukses: got %v", flashes)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestFinancialController_PurgeFinancialRecord(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	store := useTempStorage(t)
+	store.Put("attachments/user-a/struk.png", strings.NewReader("isi struk"), "image/png")
+	store.Put("attachments/user-a/struk_thumb.jpg", strings.NewReader("thumbnail"), "image/jpeg")
+
+	row := attachmentRow(3, 7, "attachments/user-a/struk.png", "image/png")
+	row[4] = "attachments/user-a/struk_thumb.jpg"
+	expectPurgeRecord(mock, 7, true, row)
+
+	request, session := newSessionRequest(http.MethodPost, "/financial/purge_financial_record", "user-a", url.Values{"id": {"7"}})
+	recorder := httptest.NewRecorder()
+	controllers.NewFinancialController(db).PurgeFinancialRecord(recorder, request)
+
+	if recorder.Code != http.StatusSeeOther || len(session.Flashes("success")) == 0 {
+		t.Fatalf("got status %d, error %v", recorder.Code, session.Flashes("error"))
+	}
+	for _, key := range []string{"attachments/user-a/struk.png", "attachments/user-a/struk_thumb.jpg"} {
+		if _, err := store.Open(key); err != storage.ErrNotFound {
+			t.Errorf("%s harus dihapus, got %v", key, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFinancialModel_PurgeFinancialRecord_Transfer(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// kedua catatan, lampiran dan data transfernya dihapus bersama
+	mock.ExpectBegin()
+	expectTrashedRecord(mock, 7, true, int64(4))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM attachments WHERE user_id = ? AND record_id IN (SELECT id FROM record WHERE transfer_id = ? AND user_id = ?)")).
+		WithArgs("user-a", int64(4), "user-a").
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).
+			AddRow(attachmentRow(3, 7, "attachments/user-a/keluar.pdf", "application/pdf")...).
+			AddRow(attachmentRow(5, 8, "attachments/user-a/masuk.pdf", "application/pdf")...))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM attachments WHERE user_id = ? AND record_id IN (SELECT id FROM record WHERE transfer_id = ? AND user_id = ?)")).
+		WithArgs("user-a", int64(4), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM record WHERE transfer_id = ? AND user_id = ?")).
+		WithArgs(int64(4), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM transfers WHERE id = ? AND user_id = ?")).
+		WithArgs(int64(4), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attachments, err := models.NewFinancalModel(db).PurgeFinancialRecord(7, "user-a")
+	if err != nil {
+		t.Fatalf("PurgeFinancialRecord error: %v", err)
+	}
+
+	// lampiran catatan pasangan transfer ikut dikembalikan supaya filenya dihapus
+	if len(attachments) != 2 || attachments[0].RecordId != 7 || attachments[1].RecordId != 8 {
+		t.Errorf("got %+v, want lampiran kedua catatan transfer", attachments)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransferController_DeleteTransfer(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// link GET lama tidak boleh menghapus transfer
+	request, session := newSessionRequest(http.MethodGet, "/transfers/delete?id=4", "user-a", nil)
+	controllers.NewTransferController(db).DeleteTransfer(httptest.NewRecorder(), request)
+	if flashes := session.Flashes("error"); len(flashes) > 0 {
+		t.Fatalf("GET tidak boleh diproses, got %v", flashes)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("FROM transfers t")).
+		WithArgs(int64(4), "user-a").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "date", "from_account_id", "to_account_id", "nominal", "currency", "description", "from_name", "to_name"}).
+			AddRow(int64(4), "user-a", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), int64(1), int64(2), int64(500), "IDR", nil, "Dompet", "Bank"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE record SET deleted_at = ?, updated_at = updated_at WHERE transfer_id = ? AND user_id = ? AND deleted_at IS NULL")).
+		WithArgs(sqlmock.AnyArg(), int64(4), "user-a").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	request, session = newSessionRequest(http.MethodPost, "/transfers/delete", "user-a", url.Values{"id": {"4"}})
+	recorder := httptest.NewRecorder()
+	controllers.NewTransferController(db).DeleteTransfer(recorder, request)
+
+	if recorder.Code != http.StatusSeeOther || len(session.Flashes("success")) == 0 {
+		t.Errorf("got status %d, error %v", recorder.Code, session.Flashes("error"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("gagal membuat sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	store := useTempStorage(t)
+	store.Put("attachments/user-a/lama.pdf", strings.NewReader("%PDF-1.4"), "application/pdf")
+
+	deletedBefore := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, user_id FROM record WHERE deleted_at < ?")).
+		WithArgs(deletedBefore, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(int64(7), "user-a").AddRow(int64(8), "user-a"))
+
+	expectPurgeRecord(mock, 7, true, attachmentRow(3, 7, "attachments/user-a/lama.pdf", "application/pdf"))
+
+	// catatan 8 sudah dikembalikan user sebelum sempat dihapus
+	expectPurgeRecord(mock, 8, false)
+
+	purged, err := scheduler.PurgeTrash(db, store, deletedBefore)
+	if err != nil || purged != 1 {
+		t.Fatalf("got %d dihapus, error %v", purged, err)
+	}
+	if _, err := store.Open("attachments/user-a/lama.pdf"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("lampiran harus dihapus, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,16 +1,68 @@
 package views
 
 import (
+	"financial-record/entities"
 	"html/template"
 	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
+// FormatMoney menampilkan nominal dalam sen sesuai mata uangnya, contoh "Rp. 1.500.000,50"
+func FormatMoney(amount int64, currency string) string {
+	return entities.Money{Amount: amount, Currency: currency}.Format()
+}
+
+// MoneyInput menampilkan nominal dalam sen sebagai value input form, contoh "1.500.000,50"
+func MoneyInput(amount int64) string {
+	return entities.Money{Amount: amount}.Input()
+}
+
+// Highlight menandai kata pencarian di teks dengan <mark>, bagian lain tetap di-escape
+func Highlight(text string, terms []string) template.HTML {
+
+	var patterns []string
+	for _, term := range terms {
+		if term != "" {
+			patterns = append(patterns, regexp.QuoteMeta(term))
+		}
+	}
+	if len(patterns) == 0 {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	pattern := regexp.MustCompile("(?i)" + strings.Join(patterns, "|"))
+
+	var result strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		result.WriteString(template.HTMLEscapeString(text[last:match[0]]))
+		result.WriteString("<mark>" + template.HTMLEscapeString(text[match[0]:match[1]]) + "</mark>")
+		last = match[1]
+	}
+	result.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(result.String())
+}
+
 // RenderTemplate is a function variable so tests can replace it.
 var RenderTemplate = func(writer http.ResponseWriter, path string, data interface{}) {
-	tmpl, err := template.ParseFiles(path)
+	tmpl, err := template.New(filepath.Base(path)).Funcs(template.FuncMap{
+		"formatMoney": FormatMoney,
+		"moneyInput":  MoneyInput,
+		"highlight":   Highlight,
+		"indexNo":     func(a, b int) int { return a + b },
+	}).ParseFiles(path)
 	if err != nil {
 		http.Error(writer, "Template error", http.StatusInternalServerError)
 		return
 	}
 	tmpl.Execute(writer, data)
 }
+
+// RenderNotFound menampilkan halaman 404, dipakai ketika data tidak ada atau bukan milik user
+func RenderNotFound(writer http.ResponseWriter, message string) {
+	writer.WriteHeader(http.StatusNotFound)
+	RenderTemplate(writer, "views/error/not_found.html", map[string]interface{}{"message": message})
+}